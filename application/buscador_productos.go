@@ -0,0 +1,49 @@
+package application
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"sort"
+)
+
+// BuscadorProductos busca productos recorriendo en memoria todos los que no
+// están eliminados. Sirve para los almacenamientos sin índice de texto
+// completo y como respaldo del buscador de MySQL.
+type BuscadorProductos struct {
+	repository ports.ProductoRepository
+}
+
+// NewBuscadorProductos crea un nuevo buscador de productos en memoria
+func NewBuscadorProductos(repository ports.ProductoRepository) *BuscadorProductos {
+	return &BuscadorProductos{
+		repository: repository,
+	}
+}
+
+// Buscar puntúa cada producto con domain.PuntuarProducto y retorna los que
+// coinciden con todos los términos. Los empates se ordenan por nombre.
+func (b *BuscadorProductos) Buscar(terminos []string, limite int) ([]*domain.ResultadoBusqueda, error) {
+	productos, _, err := b.repository.GetAll(ports.QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	resultados := []*domain.ResultadoBusqueda{}
+	for _, producto := range productos {
+		if relevancia := domain.PuntuarProducto(producto, terminos); relevancia > 0 {
+			resultados = append(resultados, domain.NewResultadoBusqueda(producto, terminos, relevancia))
+		}
+	}
+
+	sort.SliceStable(resultados, func(i, j int) bool {
+		if resultados[i].Relevancia != resultados[j].Relevancia {
+			return resultados[i].Relevancia > resultados[j].Relevancia
+		}
+		return domain.NormalizarBusqueda(resultados[i].Producto.Nombre) < domain.NormalizarBusqueda(resultados[j].Producto.Nombre)
+	})
+
+	if limite > 0 && len(resultados) > limite {
+		resultados = resultados[:limite]
+	}
+	return resultados, nil
+}
@@ -0,0 +1,72 @@
+package application
+
+import (
+	"ActividadDesempenioAPIz/core/ports"
+	"log"
+	"sync"
+	"time"
+)
+
+// CaducidadService revisa periódicamente los lotes con existencia que están
+// por caducar y envía una notificación expiring_stock por cada uno, a lo más
+// una vez al día por lote
+type CaducidadService struct {
+	loteRepo            ports.LoteRepository
+	notificationService ports.NotificationService
+	diasAviso           int
+
+	mutex    sync.Mutex
+	avisados map[int]string
+}
+
+// NewCaducidadService crea un nuevo servicio de caducidad que avisa con
+// diasAviso días de anticipación
+func NewCaducidadService(
+	loteRepo ports.LoteRepository,
+	notificationService ports.NotificationService,
+	diasAviso int,
+) *CaducidadService {
+	return &CaducidadService{
+		loteRepo:            loteRepo,
+		notificationService: notificationService,
+		diasAviso:           diasAviso,
+		avisados:            make(map[int]string),
+	}
+}
+
+// Iniciar ejecuta una revisión inmediata y luego una cada intervalo. Bloquea,
+// por lo que debe llamarse en su propia goroutine.
+func (s *CaducidadService) Iniciar(intervalo time.Duration) {
+	s.Revisar()
+
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.Revisar()
+	}
+}
+
+// Revisar notifica los lotes que caducan dentro de los próximos diasAviso
+// días, incluidos los ya caducados, que no se hayan notificado hoy
+func (s *CaducidadService) Revisar() {
+	ahora := time.Now()
+	lotes, err := s.loteRepo.GetPorCaducar(ahora.AddDate(0, 0, s.diasAviso), 0)
+	if err != nil {
+		log.Printf("Error al revisar lotes por caducar: %v", err)
+		return
+	}
+
+	hoy := ahora.Format("2006-01-02")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, lote := range lotes {
+		if s.avisados[lote.ID] == hoy {
+			continue
+		}
+		s.avisados[lote.ID] = hoy
+		s.notificationService.NotifyExpiringStock(lote, *lote.DiasParaCaducar(ahora))
+	}
+}
@@ -0,0 +1,315 @@
+package application
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Dimensiones de la página carta en puntos y márgenes del documento
+const (
+	anchoPagina    = 612
+	altoPagina     = 792
+	margenPagina   = 50
+	altoRenglon    = 14
+	tamanoLetra    = 9
+	tamanoTitulo   = 14
+	maxDescripcion = 38
+)
+
+// Columnas de la tabla de conceptos: posición izquierda de los textos y
+// posición derecha de los importes
+const (
+	colCantidad    = margenPagina + 30
+	colSKU         = margenPagina + 40
+	colDescripcion = margenPagina + 120
+	colUnitario    = 390
+	colDescuento   = 450
+	colIVA         = 505
+	colImporte     = anchoPagina - margenPagina
+)
+
+// textoPDF es un texto colocado en la página. Si derecha es true, x es la
+// posición donde termina el texto.
+type textoPDF struct {
+	x       float64
+	y       float64
+	tamano  float64
+	negrita bool
+	derecha bool
+	texto   string
+}
+
+// documentoPDF acumula los textos de cada página y agrega páginas conforme se
+// llena la actual
+type documentoPDF struct {
+	paginas [][]textoPDF
+	y       float64
+}
+
+// nuevaPagina inicia una página y coloca el cursor en su margen superior
+func (d *documentoPDF) nuevaPagina() {
+	d.paginas = append(d.paginas, nil)
+	d.y = altoPagina - margenPagina
+}
+
+// renglon reserva un renglón del alto indicado y retorna su posición vertical.
+// Si no cabe en la página actual se inicia otra.
+func (d *documentoPDF) renglon(alto float64) float64 {
+	if len(d.paginas) == 0 || d.y-alto < margenPagina {
+		d.nuevaPagina()
+	}
+	d.y -= alto
+	return d.y
+}
+
+// texto coloca un texto en la página actual
+func (d *documentoPDF) texto(t textoPDF) {
+	actual := len(d.paginas) - 1
+	d.paginas[actual] = append(d.paginas[actual], t)
+}
+
+// PDF genera una representación impresa del comprobante
+func (g *GeneradorFactura) PDF(factura *domain.Factura) ([]byte, error) {
+	doc := &documentoPDF{}
+
+	titulo := "FACTURA"
+	if factura.Tipo == domain.ComprobanteCancelacion {
+		titulo = "CANCELACIÓN DE FACTURA"
+	}
+	y := doc.renglon(tamanoTitulo + 4)
+	doc.texto(textoPDF{x: margenPagina, y: y, tamano: tamanoTitulo, negrita: true, texto: titulo})
+	doc.texto(textoPDF{x: colImporte, y: y, tamano: tamanoTitulo, negrita: true, derecha: true, texto: factura.FolioCompleto})
+
+	y = doc.renglon(altoRenglon)
+	doc.texto(textoPDF{x: colImporte, y: y, tamano: tamanoLetra, derecha: true,
+		texto: "Fecha de emisión: " + factura.FechaEmision.Format("2006-01-02 15:04:05")})
+	referencia := fmt.Sprintf("Venta %d del %s", factura.VentaID, factura.FechaVenta.Format("2006-01-02"))
+	if factura.PedidoID != nil {
+		referencia += fmt.Sprintf(" (pedido %d)", *factura.PedidoID)
+	}
+	doc.texto(textoPDF{x: margenPagina, y: y, tamano: tamanoLetra, texto: referencia})
+
+	doc.renglon(altoRenglon / 2)
+	pdfSeccion(doc, "Emisor", []string{
+		factura.Emisor.Nombre,
+		"RFC: " + factura.Emisor.RFC,
+		"Régimen fiscal: " + factura.Emisor.RegimenFiscal + "    Lugar de expedición: " + factura.Emisor.CodigoPostal,
+	})
+
+	receptor := []string{factura.Receptor.Nombre, "RFC: " + factura.Receptor.RFC}
+	if factura.Receptor.Direccion != "" {
+		receptor = append(receptor, factura.Receptor.Direccion)
+	}
+	pdfSeccion(doc, "Receptor", receptor)
+
+	if factura.Tipo == domain.ComprobanteCancelacion {
+		cancelacion := []string{"Motivo de cancelación: " + factura.MotivoCancelacion + " - Operación no realizada"}
+		if factura.Relacionado != nil {
+			relacionado := &domain.DocumentoFiscal{Serie: factura.Relacionado.Serie, Folio: factura.Relacionado.Folio}
+			cancelacion = append(cancelacion, fmt.Sprintf("Deja sin efecto la factura %s emitida el %s",
+				relacionado.FolioCompleto(), factura.Relacionado.FechaEmision.Format("2006-01-02 15:04:05")))
+		} else {
+			cancelacion = append(cancelacion, "La venta no tenía factura emitida")
+		}
+		pdfSeccion(doc, "Cancelación", cancelacion)
+	}
+
+	pdfEncabezadoConceptos(doc)
+	for _, concepto := range factura.Conceptos {
+		if doc.y-altoRenglon < margenPagina {
+			doc.nuevaPagina()
+			pdfEncabezadoConceptos(doc)
+		}
+		y = doc.renglon(altoRenglon)
+		doc.texto(textoPDF{x: colCantidad, y: y, tamano: tamanoLetra, derecha: true, texto: fmt.Sprintf("%d", concepto.Cantidad)})
+		doc.texto(textoPDF{x: colSKU, y: y, tamano: tamanoLetra, texto: recortar(concepto.SKU, 14)})
+		doc.texto(textoPDF{x: colDescripcion, y: y, tamano: tamanoLetra, texto: recortar(concepto.Descripcion, maxDescripcion)})
+		doc.texto(textoPDF{x: colUnitario, y: y, tamano: tamanoLetra, derecha: true, texto: concepto.ValorUnitario.String()})
+		doc.texto(textoPDF{x: colDescuento, y: y, tamano: tamanoLetra, derecha: true, texto: concepto.Descuento.String()})
+		doc.texto(textoPDF{x: colIVA, y: y, tamano: tamanoLetra, derecha: true, texto: concepto.TasaIVA.String() + "%"})
+		doc.texto(textoPDF{x: colImporte, y: y, tamano: tamanoLetra, derecha: true, texto: concepto.Importe.String()})
+	}
+
+	doc.renglon(altoRenglon / 2)
+	var devoluciones []string
+	for _, concepto := range factura.Conceptos {
+		if concepto.CantidadDevuelta > 0 {
+			devoluciones = append(devoluciones, fmt.Sprintf("%d de %d  %s", concepto.CantidadDevuelta, concepto.Cantidad,
+				recortar(concepto.Descripcion, maxDescripcion)))
+		}
+	}
+	if len(devoluciones) > 0 {
+		pdfSeccion(doc, "Devoluciones registradas (no modifican los importes de esta factura)", devoluciones)
+	}
+
+	totales := [][2]string{
+		{"Subtotal", factura.Subtotal.String()},
+		{"Descuento", factura.Descuento.String()},
+	}
+	for _, impuesto := range factura.Impuestos {
+		totales = append(totales, [2]string{"IVA " + impuesto.TasaIVA.String() + "%", impuesto.Importe.String()})
+	}
+	totales = append(totales, [2]string{"Total " + string(factura.Moneda), factura.Total.String()})
+	for i, total := range totales {
+		negrita := i == len(totales)-1
+		y = doc.renglon(altoRenglon)
+		doc.texto(textoPDF{x: colDescuento, y: y, tamano: tamanoLetra, negrita: negrita, derecha: true, texto: total[0]})
+		doc.texto(textoPDF{x: colImporte, y: y, tamano: tamanoLetra, negrita: negrita, derecha: true, texto: total[1]})
+	}
+
+	return doc.bytes(), nil
+}
+
+// pdfSeccion escribe un título seguido de sus renglones
+func pdfSeccion(doc *documentoPDF, titulo string, renglones []string) {
+	y := doc.renglon(altoRenglon)
+	doc.texto(textoPDF{x: margenPagina, y: y, tamano: tamanoLetra + 1, negrita: true, texto: titulo})
+	for _, renglon := range renglones {
+		y = doc.renglon(altoRenglon)
+		doc.texto(textoPDF{x: margenPagina, y: y, tamano: tamanoLetra, texto: renglon})
+	}
+	doc.renglon(altoRenglon / 2)
+}
+
+// pdfEncabezadoConceptos escribe los títulos de las columnas de conceptos
+func pdfEncabezadoConceptos(doc *documentoPDF) {
+	y := doc.renglon(altoRenglon)
+	doc.texto(textoPDF{x: colCantidad, y: y, tamano: tamanoLetra, negrita: true, derecha: true, texto: "Cant."})
+	doc.texto(textoPDF{x: colSKU, y: y, tamano: tamanoLetra, negrita: true, texto: "SKU"})
+	doc.texto(textoPDF{x: colDescripcion, y: y, tamano: tamanoLetra, negrita: true, texto: "Descripción"})
+	doc.texto(textoPDF{x: colUnitario, y: y, tamano: tamanoLetra, negrita: true, derecha: true, texto: "P. unitario"})
+	doc.texto(textoPDF{x: colDescuento, y: y, tamano: tamanoLetra, negrita: true, derecha: true, texto: "Descuento"})
+	doc.texto(textoPDF{x: colIVA, y: y, tamano: tamanoLetra, negrita: true, derecha: true, texto: "IVA"})
+	doc.texto(textoPDF{x: colImporte, y: y, tamano: tamanoLetra, negrita: true, derecha: true, texto: "Importe"})
+}
+
+// recortar limita un texto a max caracteres
+func recortar(texto string, max int) string {
+	runas := []rune(texto)
+	if len(runas) <= max {
+		return texto
+	}
+	return string(runas[:max-3]) + "..."
+}
+
+// bytes escribe el documento en formato PDF 1.4 con las fuentes estándar
+// Helvetica y Helvetica-Bold, que no requieren incrustarse
+func (d *documentoPDF) bytes() []byte {
+	if len(d.paginas) == 0 {
+		d.nuevaPagina()
+	}
+
+	// Objetos: 1 catálogo, 2 árbol de páginas, 3 y 4 fuentes, y después una
+	// página y su contenido por cada página del documento
+	var objetos []string
+	kids := make([]string, len(d.paginas))
+	for i := range d.paginas {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objetos = append(objetos,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.paginas)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, pagina := range d.paginas {
+		contenido := contenidoPagina(pagina)
+		objetos = append(objetos,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				anchoPagina, altoPagina, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(contenido), contenido),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objetos))
+	for i, objeto := range objetos {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, objeto)
+	}
+
+	inicioXref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objetos)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objetos)+1, inicioXref)
+	return buf.Bytes()
+}
+
+// contenidoPagina genera las instrucciones de dibujo de los textos de una página
+func contenidoPagina(textos []textoPDF) string {
+	var buf strings.Builder
+	for _, t := range textos {
+		fuente := "F1"
+		if t.negrita {
+			fuente = "F2"
+		}
+		texto := aWinAnsi(t.texto)
+		x := t.x
+		if t.derecha {
+			x -= anchoTexto(texto, t.negrita) * t.tamano / 1000
+		}
+		fmt.Fprintf(&buf, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", fuente, t.tamano, x, t.y, escaparPDF(texto))
+	}
+	return buf.String()
+}
+
+// aWinAnsi convierte un texto a la codificación de las fuentes. Los caracteres
+// latinos se conservan; los demás se reemplazan por '?'.
+func aWinAnsi(texto string) []byte {
+	resultado := make([]byte, 0, len(texto))
+	for _, r := range texto {
+		if r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff {
+			r = '?'
+		}
+		resultado = append(resultado, byte(r))
+	}
+	return resultado
+}
+
+// escaparPDF escapa los caracteres especiales de una cadena literal de PDF
+func escaparPDF(texto []byte) string {
+	var buf strings.Builder
+	for _, b := range texto {
+		if b == '(' || b == ')' || b == '\\' {
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(b)
+	}
+	return buf.String()
+}
+
+// anchoTexto estima el ancho de un texto en milésimas del tamaño de letra con
+// las métricas de Helvetica. Es exacto para cifras, que son lo que se alinea
+// a la derecha.
+func anchoTexto(texto []byte, negrita bool) float64 {
+	ancho := 0.0
+	for _, b := range texto {
+		switch {
+		case b >= '0' && b <= '9', b == '$':
+			ancho += 556
+		case b == '.' || b == ',' || b == ' ':
+			ancho += 278
+		case b == '-':
+			ancho += 333
+		case b == '%':
+			ancho += 889
+		case b >= 'A' && b <= 'Z':
+			ancho += 667
+		case b == 'i' || b == 'l' || b == 'j' || b == 't' || b == 'f' || b == 'r':
+			ancho += 278
+		case b == 'm' || b == 'w':
+			ancho += 833
+		default:
+			ancho += 556
+		}
+	}
+	if negrita {
+		ancho *= 1.05
+	}
+	return ancho
+}
@@ -0,0 +1,218 @@
+package application
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"encoding/xml"
+	"fmt"
+)
+
+// GeneradorFactura presenta los comprobantes de venta en XML, con una
+// estructura similar a la del CFDI 4.0, y en PDF. Los datos del emisor son los
+// mismos para todos los comprobantes.
+type GeneradorFactura struct {
+	emisor domain.Emisor
+}
+
+// NewGeneradorFactura crea un nuevo generador de facturas. Los datos del
+// emisor que falten se completan con valores de prueba.
+func NewGeneradorFactura(emisor domain.Emisor) *GeneradorFactura {
+	return &GeneradorFactura{
+		emisor: emisor.ConPredeterminados(),
+	}
+}
+
+// Emisor retorna los datos fiscales con los que se emiten los comprobantes
+func (g *GeneradorFactura) Emisor() domain.Emisor {
+	return g.emisor
+}
+
+// Claves del catálogo del CFDI que usan todos los comprobantes
+const (
+	claveProdServGenerica = "01010101"
+	claveUnidadPieza      = "H87"
+	impuestoIVA           = "002"
+	objetoImpuestoSi      = "02"
+	usoCFDIGastos         = "G03"
+	usoCFDISinEfectos     = "S01"
+	relacionNotaCredito   = "01"
+	formatoFechaCFDI      = "2006-01-02T15:04:05"
+)
+
+type comprobanteXML struct {
+	XMLName           xml.Name           `xml:"cfdi:Comprobante"`
+	XmlnsCfdi         string             `xml:"xmlns:cfdi,attr"`
+	Version           string             `xml:"Version,attr"`
+	Serie             string             `xml:"Serie,attr"`
+	Folio             int                `xml:"Folio,attr"`
+	Fecha             string             `xml:"Fecha,attr"`
+	SubTotal          string             `xml:"SubTotal,attr"`
+	Descuento         string             `xml:"Descuento,attr,omitempty"`
+	Moneda            string             `xml:"Moneda,attr"`
+	Total             string             `xml:"Total,attr"`
+	TipoDeComprobante string             `xml:"TipoDeComprobante,attr"`
+	Exportacion       string             `xml:"Exportacion,attr"`
+	LugarExpedicion   string             `xml:"LugarExpedicion,attr"`
+	MotivoCancelacion string             `xml:"MotivoCancelacion,attr,omitempty"`
+	Relacionados      *relacionadosXML   `xml:"cfdi:CfdiRelacionados,omitempty"`
+	Emisor            emisorXML          `xml:"cfdi:Emisor"`
+	Receptor          receptorXML        `xml:"cfdi:Receptor"`
+	Conceptos         []conceptoXML      `xml:"cfdi:Conceptos>cfdi:Concepto"`
+	Impuestos         *impuestosTotalXML `xml:"cfdi:Impuestos,omitempty"`
+}
+
+type relacionadosXML struct {
+	TipoRelacion string         `xml:"TipoRelacion,attr"`
+	Relacionado  relacionadoXML `xml:"cfdi:CfdiRelacionado"`
+}
+
+type relacionadoXML struct {
+	Serie string `xml:"Serie,attr"`
+	Folio int    `xml:"Folio,attr"`
+	Fecha string `xml:"Fecha,attr"`
+}
+
+type emisorXML struct {
+	Rfc           string `xml:"Rfc,attr"`
+	Nombre        string `xml:"Nombre,attr"`
+	RegimenFiscal string `xml:"RegimenFiscal,attr"`
+}
+
+type receptorXML struct {
+	Rfc     string `xml:"Rfc,attr"`
+	Nombre  string `xml:"Nombre,attr"`
+	UsoCFDI string `xml:"UsoCFDI,attr"`
+}
+
+type conceptoXML struct {
+	ClaveProdServ    string        `xml:"ClaveProdServ,attr"`
+	NoIdentificacion string        `xml:"NoIdentificacion,attr,omitempty"`
+	Cantidad         int           `xml:"Cantidad,attr"`
+	ClaveUnidad      string        `xml:"ClaveUnidad,attr"`
+	Descripcion      string        `xml:"Descripcion,attr"`
+	ValorUnitario    string        `xml:"ValorUnitario,attr"`
+	Importe          string        `xml:"Importe,attr"`
+	Descuento        string        `xml:"Descuento,attr,omitempty"`
+	ObjetoImp        string        `xml:"ObjetoImp,attr"`
+	Traslados        []trasladoXML `xml:"cfdi:Impuestos>cfdi:Traslados>cfdi:Traslado"`
+}
+
+type trasladoXML struct {
+	Base       string `xml:"Base,attr"`
+	Impuesto   string `xml:"Impuesto,attr"`
+	TipoFactor string `xml:"TipoFactor,attr"`
+	TasaOCuota string `xml:"TasaOCuota,attr"`
+	Importe    string `xml:"Importe,attr"`
+}
+
+type impuestosTotalXML struct {
+	TotalImpuestosTrasladados string        `xml:"TotalImpuestosTrasladados,attr"`
+	Traslados                 []trasladoXML `xml:"cfdi:Traslados>cfdi:Traslado"`
+}
+
+// XML genera el comprobante con una estructura similar a la del CFDI. Una
+// factura es un comprobante de ingreso; una cancelación es de egreso y
+// relaciona la factura que deja sin efecto.
+func (g *GeneradorFactura) XML(factura *domain.Factura) ([]byte, error) {
+	comprobante := comprobanteXML{
+		XmlnsCfdi:         "http://www.sat.gob.mx/cfd/4",
+		Version:           "4.0",
+		Serie:             factura.Serie,
+		Folio:             factura.Folio,
+		Fecha:             factura.FechaEmision.Format(formatoFechaCFDI),
+		SubTotal:          factura.Subtotal.String(),
+		Descuento:         importeOpcional(factura.Descuento),
+		Moneda:            string(factura.Moneda),
+		Total:             factura.Total.String(),
+		TipoDeComprobante: "I",
+		Exportacion:       "01",
+		LugarExpedicion:   factura.Emisor.CodigoPostal,
+		Emisor: emisorXML{
+			Rfc:           factura.Emisor.RFC,
+			Nombre:        factura.Emisor.Nombre,
+			RegimenFiscal: factura.Emisor.RegimenFiscal,
+		},
+		Receptor: receptorXML{
+			Rfc:     factura.Receptor.RFC,
+			Nombre:  factura.Receptor.Nombre,
+			UsoCFDI: usoCFDIGastos,
+		},
+	}
+	if factura.Receptor.RFC == domain.RFCPublicoGeneral {
+		comprobante.Receptor.UsoCFDI = usoCFDISinEfectos
+	}
+
+	if factura.Tipo == domain.ComprobanteCancelacion {
+		comprobante.TipoDeComprobante = "E"
+		comprobante.MotivoCancelacion = factura.MotivoCancelacion
+		if factura.Relacionado != nil {
+			comprobante.Relacionados = &relacionadosXML{
+				TipoRelacion: relacionNotaCredito,
+				Relacionado: relacionadoXML{
+					Serie: factura.Relacionado.Serie,
+					Folio: factura.Relacionado.Folio,
+					Fecha: factura.Relacionado.FechaEmision.Format(formatoFechaCFDI),
+				},
+			}
+		}
+	}
+
+	for _, concepto := range factura.Conceptos {
+		comprobante.Conceptos = append(comprobante.Conceptos, conceptoXML{
+			ClaveProdServ:    claveProdServGenerica,
+			NoIdentificacion: concepto.SKU,
+			Cantidad:         concepto.Cantidad,
+			ClaveUnidad:      claveUnidadPieza,
+			Descripcion:      concepto.Descripcion,
+			ValorUnitario:    concepto.ValorUnitario.String(),
+			Importe:          concepto.Importe.String(),
+			Descuento:        importeOpcional(concepto.Descuento),
+			ObjetoImp:        objetoImpuestoSi,
+			Traslados: []trasladoXML{
+				traslado(concepto.Importe.Sub(concepto.Descuento), concepto.TasaIVA, concepto.Impuesto),
+			},
+		})
+	}
+
+	if len(factura.Impuestos) > 0 {
+		total := domain.NewMoney(0)
+		impuestos := &impuestosTotalXML{}
+		for _, impuesto := range factura.Impuestos {
+			total = total.Add(impuesto.Importe)
+			impuestos.Traslados = append(impuestos.Traslados, traslado(impuesto.Base, impuesto.TasaIVA, impuesto.Importe))
+		}
+		impuestos.TotalImpuestosTrasladados = total.String()
+		comprobante.Impuestos = impuestos
+	}
+
+	contenido, err := xml.MarshalIndent(comprobante, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), contenido...), nil
+}
+
+// traslado arma el IVA trasladado sobre una base a una tasa
+func traslado(base domain.Money, tasa domain.Porcentaje, importe domain.Money) trasladoXML {
+	return trasladoXML{
+		Base:       base.String(),
+		Impuesto:   impuestoIVA,
+		TipoFactor: "Tasa",
+		TasaOCuota: tasaOCuota(tasa),
+		Importe:    importe.String(),
+	}
+}
+
+// tasaOCuota expresa una tasa como fracción con seis decimales, por ejemplo
+// 16% como 0.160000
+func tasaOCuota(tasa domain.Porcentaje) string {
+	return fmt.Sprintf("%d.%06d", tasa/10000, (tasa%10000)*100)
+}
+
+// importeOpcional retorna el importe como texto, o vacío si es cero para que
+// el atributo se omita
+func importeOpcional(importe domain.Money) string {
+	if importe.IsZero() {
+		return ""
+	}
+	return importe.String()
+}
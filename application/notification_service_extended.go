@@ -53,14 +53,15 @@ func (ns *NotificationServiceExtended) GetOrderCancelWS() *websocket.WebsocketSe
 	return ns.orderCancelWS
 }
 
-// NotifyLowStock envía una notificación cuando un producto tiene poco stock
-func (ns *NotificationServiceExtended) NotifyLowStock(productID int, stockLevel int) {
+// NotifyLowStock envía una notificación cuando la existencia de un producto en
+// un almacén queda en o por debajo de su stock mínimo (threshold)
+func (ns *NotificationServiceExtended) NotifyLowStock(productID int, warehouseID int, stockLevel int, threshold int) {
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
-	if stockLevel <= 5 {
+	if stockLevel <= threshold {
 		productIDStr := strconv.Itoa(productID)
-		notification := domain.NewLowStockNotification(productIDStr, stockLevel)
+		notification := domain.NewLowStockNotification(productIDStr, warehouseID, stockLevel, threshold)
 		payload, err := notification.ToJSON()
 		if err != nil {
 			log.Printf("Error al serializar notificación de stock: %v", err)
@@ -68,13 +69,31 @@ func (ns *NotificationServiceExtended) NotifyLowStock(productID int, stockLevel
 		}
 
 		ns.productStockWS.Broadcast(payload)
-		log.Printf("Notificación de stock bajo para producto %d con nivel de stock %d",
-			productID, stockLevel)
+		log.Printf("Notificación de stock bajo para producto %d en almacén %d con nivel de stock %d (mínimo %d)",
+			productID, warehouseID, stockLevel, threshold)
 	}
 }
 
+// NotifyRestock envía una notificación cuando un producto sale de stock bajo en un almacén
+func (ns *NotificationServiceExtended) NotifyRestock(productID int, warehouseID int, stockLevel int, threshold int) {
+	ns.mutex.RLock()
+	defer ns.mutex.RUnlock()
+
+	productIDStr := strconv.Itoa(productID)
+	notification := domain.NewRestockNotification(productIDStr, warehouseID, stockLevel, threshold)
+	payload, err := notification.ToJSON()
+	if err != nil {
+		log.Printf("Error al serializar notificación de reabastecimiento: %v", err)
+		return
+	}
+
+	ns.productStockWS.Broadcast(payload)
+	log.Printf("Notificación de reabastecimiento para producto %d en almacén %d con nivel de stock %d (mínimo %d)",
+		productID, warehouseID, stockLevel, threshold)
+}
+
 // NotifyNewPedido envía una notificación cuando se crea un nuevo pedido
-func (ns *NotificationServiceExtended) NotifyNewPedido(pedidoID int, amount float64) {
+func (ns *NotificationServiceExtended) NotifyNewPedido(pedidoID int, amount domain.Money) {
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
@@ -88,12 +107,12 @@ func (ns *NotificationServiceExtended) NotifyNewPedido(pedidoID int, amount floa
 	}
 
 	ns.orderCreationWS.Broadcast(payload)
-	log.Printf("Notificación de nuevo pedido para pedido %d con monto %.2f",
+	log.Printf("Notificación de nuevo pedido para pedido %d con monto %s",
 		pedidoID, amount)
 }
 
 // NotifyNewVenta envía una notificación cuando se crea una nueva venta
-func (ns *NotificationServiceExtended) NotifyNewVenta(ventaID int, amount float64) {
+func (ns *NotificationServiceExtended) NotifyNewVenta(ventaID int, amount domain.Money) {
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
@@ -107,12 +126,12 @@ func (ns *NotificationServiceExtended) NotifyNewVenta(ventaID int, amount float6
 	}
 
 	ns.orderCreationWS.Broadcast(payload)
-	log.Printf("Notificación de nueva venta para venta %d con monto %.2f",
+	log.Printf("Notificación de nueva venta para venta %d con monto %s",
 		ventaID, amount)
 }
 
 // NotifyNewOrdenProveedor envía una notificación cuando se crea una nueva orden de proveedor
-func (ns *NotificationServiceExtended) NotifyNewOrdenProveedor(ordenID int, amount float64) {
+func (ns *NotificationServiceExtended) NotifyNewOrdenProveedor(ordenID int, amount domain.Money) {
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
@@ -126,12 +145,12 @@ func (ns *NotificationServiceExtended) NotifyNewOrdenProveedor(ordenID int, amou
 	}
 
 	ns.orderCreationWS.Broadcast(payload)
-	log.Printf("Notificación de nueva orden de proveedor para orden %d con monto %.2f",
+	log.Printf("Notificación de nueva orden de proveedor para orden %d con monto %s",
 		ordenID, amount)
 }
 
 // NotifyCanceledPedido envía una notificación cuando se cancela un pedido
-func (ns *NotificationServiceExtended) NotifyCanceledPedido(pedidoID int, amount float64) {
+func (ns *NotificationServiceExtended) NotifyCanceledPedido(pedidoID int, amount domain.Money) {
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
@@ -144,12 +163,12 @@ func (ns *NotificationServiceExtended) NotifyCanceledPedido(pedidoID int, amount
 	}
 
 	ns.orderCancelWS.Broadcast(payload)
-	log.Printf("Notificación de pedido cancelado para pedido %d con monto %.2f",
+	log.Printf("Notificación de pedido cancelado para pedido %d con monto %s",
 		pedidoID, amount)
 }
 
 // NotifyCanceledVenta envía una notificación cuando se cancela una venta
-func (ns *NotificationServiceExtended) NotifyCanceledVenta(ventaID int, amount float64) {
+func (ns *NotificationServiceExtended) NotifyCanceledVenta(ventaID int, amount domain.Money) {
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
@@ -162,12 +181,32 @@ func (ns *NotificationServiceExtended) NotifyCanceledVenta(ventaID int, amount f
 	}
 
 	ns.orderCancelWS.Broadcast(payload)
-	log.Printf("Notificación de venta cancelada para venta %d con monto %.2f",
+	log.Printf("Notificación de venta cancelada para venta %d con monto %s",
 		ventaID, amount)
 }
 
+// NotifySaleReturn envía una notificación cuando se registra la devolución
+// de una venta, con el monto a reembolsar
+func (ns *NotificationServiceExtended) NotifySaleReturn(devolucionID int, ventaID int, refund domain.Money) {
+	ns.mutex.RLock()
+	defer ns.mutex.RUnlock()
+
+	devolucionIDStr := strconv.Itoa(devolucionID)
+	productsURL := "/api/devoluciones/" + devolucionIDStr
+	notification := domain.NewSaleReturnNotification(devolucionIDStr, ventaID, refund, productsURL)
+	payload, err := notification.ToJSON()
+	if err != nil {
+		log.Printf("Error al serializar notificación de devolución: %v", err)
+		return
+	}
+
+	ns.orderCancelWS.Broadcast(payload)
+	log.Printf("Notificación de devolución %d de la venta %d con reembolso %s",
+		devolucionID, ventaID, refund)
+}
+
 // NotifyCanceledOrdenProveedor envía una notificación cuando se cancela una orden de proveedor
-func (ns *NotificationServiceExtended) NotifyCanceledOrdenProveedor(ordenID int, amount float64, providerID int) {
+func (ns *NotificationServiceExtended) NotifyCanceledOrdenProveedor(ordenID int, amount domain.Money, providerID int) {
 	ns.mutex.RLock()
 	defer ns.mutex.RUnlock()
 
@@ -187,6 +226,56 @@ func (ns *NotificationServiceExtended) NotifyCanceledOrdenProveedor(ordenID int,
 	}
 
 	ns.orderCancelWS.Broadcast(payload)
-	log.Printf("Notificación de orden cancelada para orden %d con monto %.2f y proveedor %s",
+	log.Printf("Notificación de orden cancelada para orden %d con monto %s y proveedor %s",
 		ordenID, amount, providerName)
 }
+
+// NotifyDraftOrdenProveedor envía una notificación cuando el reabastecimiento
+// automático genera o amplía una orden de proveedor en borrador
+func (ns *NotificationServiceExtended) NotifyDraftOrdenProveedor(ordenID int, amount domain.Money, providerID int, created bool) {
+	ns.mutex.RLock()
+	defer ns.mutex.RUnlock()
+
+	provider, err := ns.proveedorRepo.GetByID(providerID)
+	providerName := ""
+	if err == nil && provider != nil {
+		providerName = provider.Nombre
+	}
+
+	ordenIDStr := strconv.Itoa(ordenID)
+	productsURL := "/api/ordenes/" + ordenIDStr + "/productos"
+	notification := domain.NewDraftOrderNotification(ordenIDStr, amount, providerName, productsURL, created)
+	payload, err := notification.ToJSON()
+	if err != nil {
+		log.Printf("Error al serializar notificación de borrador: %v", err)
+		return
+	}
+
+	ns.orderCreationWS.Broadcast(payload)
+	log.Printf("Notificación de borrador de orden %d para proveedor %s con monto %s",
+		ordenID, providerName, amount)
+}
+
+// NotifyExpiringStock envía una notificación cuando un lote con existencia
+// está por caducar (daysToExpire >= 0) o ya caducó (daysToExpire < 0)
+func (ns *NotificationServiceExtended) NotifyExpiringStock(lote *domain.Lote, daysToExpire int) {
+	ns.mutex.RLock()
+	defer ns.mutex.RUnlock()
+
+	if lote.FechaCaducidad == nil {
+		return
+	}
+
+	productIDStr := strconv.Itoa(lote.ProductoID)
+	notification := domain.NewExpiringStockNotification(productIDStr, lote.AlmacenID, lote.ID, lote.NumeroLote,
+		*lote.FechaCaducidad, lote.Existencia, daysToExpire < 0)
+	payload, err := notification.ToJSON()
+	if err != nil {
+		log.Printf("Error al serializar notificación de caducidad: %v", err)
+		return
+	}
+
+	ns.productStockWS.Broadcast(payload)
+	log.Printf("Notificación de caducidad del lote %s (producto %d, almacén %d): %d unidades, %d días para caducar",
+		lote.NumeroLote, lote.ProductoID, lote.AlmacenID, lote.Existencia, daysToExpire)
+}
@@ -0,0 +1,248 @@
+package application
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"log"
+	"sort"
+	"time"
+)
+
+// usuarioReabastecimiento es el autor registrado en el historial de los
+// borradores generados automáticamente
+const usuarioReabastecimiento = "reabastecimiento"
+
+// ReabastecimientoService genera órdenes de proveedor en borrador cuando la
+// existencia de un producto, sumando lo que ya está pedido, cae a su punto de
+// reorden. Mantiene a lo más un borrador abierto por proveedor: si ya existe
+// lo amplía en lugar de crear otro. Cada producto se pide a su proveedor
+// preferido del catálogo o, si no tiene, a su proveedor asignado.
+type ReabastecimientoService struct {
+	unitOfWork          ports.UnitOfWork
+	notificationService ports.NotificationService
+}
+
+// NewReabastecimientoService crea un nuevo servicio de reabastecimiento
+func NewReabastecimientoService(
+	unitOfWork ports.UnitOfWork,
+	notificationService ports.NotificationService,
+) *ReabastecimientoService {
+	return &ReabastecimientoService{
+		unitOfWork:          unitOfWork,
+		notificationService: notificationService,
+	}
+}
+
+// borradorGenerado describe un borrador creado o ampliado por el servicio
+type borradorGenerado struct {
+	orden  *domain.OrdenProveedor
+	creado bool
+}
+
+// EvaluarProductos revisa los productos cuya existencia disminuyó y agrega a
+// los borradores de sus proveedores las cantidades sugeridas. Los errores se
+// registran en el log: el reabastecimiento nunca hace fallar la operación que
+// lo disparó.
+func (s *ReabastecimientoService) EvaluarProductos(productoIDs []int) {
+	var borradores []borradorGenerado
+
+	err := s.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		borradores = nil
+
+		// Agrupar por proveedor los productos que tienen punto de reorden
+		porProveedor := make(map[int][]int)
+		for _, id := range productoIDs {
+			producto, err := repos.Productos.GetByID(id)
+			if err != nil {
+				return err
+			}
+			// Los productos eliminados ya no se reabastecen
+			if producto.PuntoReorden <= 0 || producto.Eliminado() {
+				continue
+			}
+
+			proveedorID := producto.ProveedorID
+			preferido, err := repos.Catalogo.GetPreferido(id)
+			if err != nil {
+				return err
+			}
+			if preferido != nil {
+				proveedorID = preferido.ProveedorID
+			}
+			if proveedorID == 0 {
+				continue
+			}
+			porProveedor[proveedorID] = append(porProveedor[proveedorID], producto.ID)
+		}
+
+		// Bloquear los proveedores siempre en el mismo orden para evitar deadlocks
+		proveedores := make([]int, 0, len(porProveedor))
+		for proveedorID := range porProveedor {
+			proveedores = append(proveedores, proveedorID)
+		}
+		sort.Ints(proveedores)
+
+		for _, proveedorID := range proveedores {
+			borrador, err := s.reabastecerProveedor(repos, proveedorID, porProveedor[proveedorID])
+			if err != nil {
+				return err
+			}
+			if borrador != nil {
+				borradores = append(borradores, *borrador)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error al generar borradores de reabastecimiento para productos %v: %v", productoIDs, err)
+		return
+	}
+
+	for _, b := range borradores {
+		s.notificationService.NotifyDraftOrdenProveedor(b.orden.ID, b.orden.Total, b.orden.ProveedorID, b.creado)
+	}
+}
+
+// reabastecerProveedor crea o amplía el borrador de un proveedor con los
+// productos indicados que estén en o por debajo de su punto de reorden.
+// Retorna nil si no hubo nada que pedir o si el proveedor está eliminado.
+func (s *ReabastecimientoService) reabastecerProveedor(repos *ports.TxRepositories, proveedorID int, productoIDs []int) (*borradorGenerado, error) {
+	// El bloqueo del proveedor evita que dos evaluaciones simultáneas creen
+	// borradores duplicados
+	proveedor, err := repos.Proveedores.GetByIDForUpdate(proveedorID)
+	if err != nil {
+		return nil, err
+	}
+	if proveedor.Eliminado() {
+		return nil, nil
+	}
+
+	orden, err := repos.Ordenes.GetBorradorByProveedor(proveedorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var detalles []*domain.DetallesOrden
+	if orden != nil {
+		detalles, err = repos.DetallesOrden.GetByOrdenID(orden.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	creado := false
+	modificado := false
+	for _, productoID := range productoIDs {
+		producto, err := repos.Productos.GetByID(productoID)
+		if err != nil {
+			return nil, err
+		}
+
+		pendiente, err := repos.DetallesOrden.GetCantidadPendiente(productoID)
+		if err != nil {
+			return nil, err
+		}
+
+		cantidad := cantidadSugerida(producto, pendiente)
+		if cantidad <= 0 {
+			continue
+		}
+
+		if orden == nil {
+			orden = &domain.OrdenProveedor{
+				ProveedorID: proveedorID,
+				FechaOrden:  time.Now().Format("2006-01-02 15:04:05"),
+				Estado:      domain.OrdenBorrador,
+				AlmacenID:   domain.AlmacenPrincipalID,
+				Total:       domain.NewMoney(0),
+			}
+			orden.ID, err = repos.Ordenes.Create(orden)
+			if err != nil {
+				return nil, err
+			}
+
+			historial := domain.NewHistorialEstado(orden.ID, "", string(orden.Estado), usuarioReabastecimiento,
+				"Borrador generado por reabastecimiento automático")
+			if _, err := repos.Historial.Create(domain.DocumentoOrdenProveedor, historial); err != nil {
+				return nil, err
+			}
+			creado = true
+		}
+
+		// Ampliar la línea del producto si ya está en el borrador o agregar una nueva
+		var detalle *domain.DetallesOrden
+		for _, d := range detalles {
+			if d.ProductoID == productoID {
+				detalle = d
+				break
+			}
+		}
+
+		if detalle != nil {
+			detalle.Cantidad += cantidad
+			detalle.Subtotal = detalle.PrecioUnitario.Mul(detalle.Cantidad)
+			if err := repos.DetallesOrden.Update(detalle); err != nil {
+				return nil, err
+			}
+			orden.Total = orden.Total.Add(detalle.PrecioUnitario.Mul(cantidad))
+		} else {
+			// El costo y la cantidad mínima del catálogo tienen prioridad
+			// sobre el último precio pagado
+			entrada, err := repos.Catalogo.Get(proveedorID, productoID)
+			if err != nil {
+				return nil, err
+			}
+
+			var precio domain.Money
+			if entrada != nil {
+				precio = entrada.Costo
+				cantidad = max(cantidad, entrada.CantidadMinima)
+			} else {
+				precio, err = repos.DetallesOrden.GetUltimoPrecio(productoID)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			detalle = &domain.DetallesOrden{
+				OrdenProveedorID: orden.ID,
+				ProductoID:       productoID,
+				Cantidad:         cantidad,
+				PrecioUnitario:   precio,
+				Subtotal:         precio.Mul(cantidad),
+			}
+			detalle.ID, err = repos.DetallesOrden.Create(detalle)
+			if err != nil {
+				return nil, err
+			}
+			detalles = append(detalles, detalle)
+			orden.Total = orden.Total.Add(detalle.Subtotal)
+		}
+		modificado = true
+	}
+
+	if !modificado {
+		return nil, nil
+	}
+
+	if err := repos.Ordenes.Update(orden); err != nil {
+		return nil, err
+	}
+
+	return &borradorGenerado{orden: orden, creado: creado}, nil
+}
+
+// cantidadSugerida calcula cuánto pedir de un producto. La posición del
+// producto es su existencia más lo pendiente de recibir en órdenes abiertas;
+// si sigue por encima del punto de reorden no se pide nada. En otro caso se
+// pide cantidad_reorden, o al menos lo necesario para superar el punto de
+// reorden.
+func cantidadSugerida(producto *domain.Producto, pendiente int) int {
+	posicion := producto.Existencia + pendiente
+	if posicion > producto.PuntoReorden {
+		return 0
+	}
+
+	return max(producto.CantidadReorden, producto.PuntoReorden-posicion+1)
+}
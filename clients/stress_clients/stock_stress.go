@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Producto contiene los campos del producto que necesita la prueba
+type Producto struct {
+	ID         int `json:"id_producto"`
+	Existencia int `json:"existencia"`
+}
+
+// Prueba de concurrencia: lanza cientos de ventas en paralelo contra un mismo
+// producto y verifica que el stock final sea consistente con las ventas
+// aceptadas y que nunca quede negativo.
+//
+// Uso: go run stock_stress.go -producto 1 -ventas 300 -cantidad 1
+func main() {
+	baseURL := flag.String("url", "http://localhost:4000", "URL base de la API")
+	productoID := flag.Int("producto", 1, "ID del producto a vender")
+	ventas := flag.Int("ventas", 300, "Número de ventas en paralelo")
+	cantidad := flag.Int("cantidad", 1, "Unidades por venta")
+	flag.Parse()
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	inicial, err := obtenerProducto(client, *baseURL, *productoID)
+	if err != nil {
+		log.Fatalf("Error al obtener el producto: %v", err)
+	}
+	log.Printf("Existencia inicial del producto %d: %d", *productoID, inicial.Existencia)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"detalles": []map[string]int{
+			{"id_producto": *productoID, "cantidad": *cantidad},
+		},
+	})
+
+	var (
+		wg         sync.WaitGroup
+		mutex      sync.Mutex
+		aceptadas  int
+		rechazadas int
+		errores    int
+	)
+
+	inicio := make(chan struct{})
+	for i := 0; i < *ventas; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-inicio
+
+			resp, err := client.Post(*baseURL+"/api/ventas/", "application/json", bytes.NewReader(body))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				errores++
+				return
+			}
+			resp.Body.Close()
+
+			switch resp.StatusCode {
+			case http.StatusCreated:
+				aceptadas++
+			case http.StatusConflict:
+				rechazadas++
+			default:
+				errores++
+			}
+		}()
+	}
+
+	// Liberar todas las goroutines al mismo tiempo
+	close(inicio)
+	wg.Wait()
+
+	final, err := obtenerProducto(client, *baseURL, *productoID)
+	if err != nil {
+		log.Fatalf("Error al obtener el producto: %v", err)
+	}
+
+	esperado := inicial.Existencia - aceptadas*(*cantidad)
+	fmt.Printf("Ventas aceptadas: %d, rechazadas por stock: %d, errores: %d\n", aceptadas, rechazadas, errores)
+	fmt.Printf("Existencia final: %d (esperada: %d)\n", final.Existencia, esperado)
+
+	if final.Existencia != esperado || final.Existencia < 0 || errores > 0 {
+		fmt.Println("FALLO: el stock final no es consistente con las ventas aceptadas")
+		os.Exit(1)
+	}
+
+	fmt.Println("OK: el stock es consistente")
+}
+
+// obtenerProducto consulta un producto por su ID
+func obtenerProducto(client *http.Client, baseURL string, id int) (*Producto, error) {
+	resp, err := client.Get(fmt.Sprintf("%s/api/productos/%d", baseURL, id))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("respuesta inesperada: %s", resp.Status)
+	}
+
+	producto := &Producto{}
+	if err := json.NewDecoder(resp.Body).Decode(producto); err != nil {
+		return nil, err
+	}
+
+	return producto, nil
+}
@@ -16,9 +16,13 @@ import (
 type NotificationType string
 
 const (
-	LowStockNotification    NotificationType = "low_stock"
-	NewOrderNotification    NotificationType = "new_order"
-	CancelOrderNotification NotificationType = "cancel_order"
+	LowStockNotification      NotificationType = "low_stock"
+	NewOrderNotification      NotificationType = "new_order"
+	CancelOrderNotification   NotificationType = "cancel_order"
+	RestockNotification       NotificationType = "restock"
+	DraftOrderNotification    NotificationType = "draft_order"
+	ExpiringStockNotification NotificationType = "expiring_stock"
+	SaleReturnNotification    NotificationType = "sale_return"
 )
 
 // Notification representa una notificación del sistema
@@ -29,7 +33,12 @@ type Notification struct {
 	EntityID    string           `json:"entity_id"`
 	Amount      float64          `json:"amount,omitempty"`
 	StockLevel  int              `json:"stock_level,omitempty"`
+	WarehouseID int              `json:"warehouse_id,omitempty"`
+	LotNumber   string           `json:"lot_number,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	Threshold   int              `json:"threshold,omitempty"`
 	Provider    string           `json:"provider,omitempty"`
+	SaleID      int              `json:"sale_id,omitempty"`
 	ProductsURL string           `json:"products_url,omitempty"`
 }
 
@@ -99,9 +108,36 @@ func main() {
 			case LowStockNotification:
 				fmt.Printf("\n⚠️ ALERTA DE STOCK BAJO ⚠️\n")
 				fmt.Printf("ID del Producto: %s\n", notification.EntityID)
+				if notification.WarehouseID != 0 {
+					fmt.Printf("Almacén: %d\n", notification.WarehouseID)
+				}
+				fmt.Printf("Stock Actual: %d unidades\n", notification.StockLevel)
+				fmt.Printf("Stock Mínimo: %d unidades\n", notification.Threshold)
+				fmt.Printf("Hora: %s\n\n", notification.Timestamp.Format(time.RFC1123))
+
+			case RestockNotification:
+				fmt.Printf("\n📦 PRODUCTO REABASTECIDO 📦\n")
+				fmt.Printf("ID del Producto: %s\n", notification.EntityID)
+				if notification.WarehouseID != 0 {
+					fmt.Printf("Almacén: %d\n", notification.WarehouseID)
+				}
 				fmt.Printf("Stock Actual: %d unidades\n", notification.StockLevel)
 				fmt.Printf("Hora: %s\n\n", notification.Timestamp.Format(time.RFC1123))
 
+			case ExpiringStockNotification:
+				fmt.Printf("\n⏳ LOTE POR CADUCAR ⏳\n")
+				fmt.Printf("%s\n", notification.Message)
+				fmt.Printf("ID del Producto: %s\n", notification.EntityID)
+				fmt.Printf("Lote: %s\n", notification.LotNumber)
+				if notification.WarehouseID != 0 {
+					fmt.Printf("Almacén: %d\n", notification.WarehouseID)
+				}
+				fmt.Printf("Existencia del lote: %d unidades\n", notification.StockLevel)
+				if notification.ExpiresAt != nil {
+					fmt.Printf("Caduca: %s\n", notification.ExpiresAt.Format("2006-01-02"))
+				}
+				fmt.Printf("Hora: %s\n\n", notification.Timestamp.Format(time.RFC1123))
+
 			case NewOrderNotification:
 				fmt.Printf("\n🛒 NUEVA ORDEN CREADA 🛒\n")
 				fmt.Printf("ID de la Orden: %s\n", notification.EntityID)
@@ -118,6 +154,25 @@ func main() {
 				}
 				fmt.Printf("Hora: %s\n\n", notification.Timestamp.Format(time.RFC1123))
 
+			case SaleReturnNotification:
+				fmt.Printf("\n↩️ DEVOLUCIÓN DE VENTA ↩️\n")
+				fmt.Printf("ID de la Devolución: %s\n", notification.EntityID)
+				fmt.Printf("ID de la Venta: %d\n", notification.SaleID)
+				fmt.Printf("Reembolso: $%.2f\n", notification.Amount)
+				fmt.Printf("Detalle: %s\n", notification.ProductsURL)
+				fmt.Printf("Hora: %s\n\n", notification.Timestamp.Format(time.RFC1123))
+
+			case DraftOrderNotification:
+				fmt.Printf("\n📝 BORRADOR DE ORDEN DE COMPRA 📝\n")
+				fmt.Printf("%s\n", notification.Message)
+				fmt.Printf("ID de la Orden: %s\n", notification.EntityID)
+				fmt.Printf("Monto: $%.2f\n", notification.Amount)
+				if notification.Provider != "" {
+					fmt.Printf("Proveedor: %s\n", notification.Provider)
+				}
+				fmt.Printf("Productos: %s\n", notification.ProductsURL)
+				fmt.Printf("Hora: %s\n\n", notification.Timestamp.Format(time.RFC1123))
+
 			default:
 				fmt.Printf("Tipo de notificación desconocido: %s\n", notification.Type)
 			}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// AlmacenPrincipalID es el almacén que se usa cuando un documento no indica uno.
+// Las existencias anteriores a la separación por almacén quedaron en él.
+const AlmacenPrincipalID = 1
+
+// Almacen representa una ubicación física donde se guarda inventario
+type Almacen struct {
+	ID            int    `json:"id_almacen"`
+	Nombre        string `json:"nombre"`
+	Direccion     string `json:"direccion"`
+	FechaRegistro string `json:"fecha_registro"`
+}
+
+// ExistenciaAlmacen es la existencia de un producto en un almacén. La
+// existencia de un producto es la suma de sus existencias por almacén.
+// ExistenciaDanada son las unidades devueltas dañadas, que no se pueden
+// vender ni cuentan en la existencia.
+type ExistenciaAlmacen struct {
+	ProductoID       int `json:"id_producto"`
+	AlmacenID        int `json:"id_almacen"`
+	Existencia       int `json:"existencia"`
+	ExistenciaDanada int `json:"existencia_danada"`
+}
+
+// Transferencia es el documento que mueve inventario de un almacén a otro
+type Transferencia struct {
+	ID               int       `json:"id_transferencia"`
+	AlmacenOrigenID  int       `json:"id_almacen_origen"`
+	AlmacenDestinoID int       `json:"id_almacen_destino"`
+	Usuario          string    `json:"usuario"`
+	Comentario       string    `json:"comentario,omitempty"`
+	Fecha            time.Time `json:"fecha"`
+}
+
+// DetallesTransferencia es una línea de una transferencia entre almacenes
+type DetallesTransferencia struct {
+	ID              int `json:"id_detalle_transferencia"`
+	TransferenciaID int `json:"id_transferencia"`
+	ProductoID      int `json:"id_producto"`
+	Cantidad        int `json:"cantidad"`
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EntidadAuditoria identifica el tipo de registro que modificó una operación auditada
+type EntidadAuditoria string
+
+const (
+	EntidadProducto       EntidadAuditoria = "producto"
+	EntidadProveedor      EntidadAuditoria = "proveedor"
+	EntidadPedido         EntidadAuditoria = "pedido"
+	EntidadVenta          EntidadAuditoria = "venta"
+	EntidadOrdenProveedor EntidadAuditoria = "orden_proveedor"
+)
+
+// AccionAuditoria indica qué hizo una operación auditada sobre el registro
+type AccionAuditoria string
+
+const (
+	AccionCrear      AccionAuditoria = "crear"
+	AccionActualizar AccionAuditoria = "actualizar"
+	AccionCancelar   AccionAuditoria = "cancelar"
+	AccionEliminar   AccionAuditoria = "eliminar"
+	AccionRestaurar  AccionAuditoria = "restaurar"
+)
+
+// RegistroAuditoria registra quién modificó un registro, desde qué ruta y cómo
+// quedó. Antes es nil en las altas y Despues es nil en las eliminaciones
+// definitivas.
+type RegistroAuditoria struct {
+	ID        int              `json:"id_auditoria"`
+	Actor     string           `json:"actor"`
+	Metodo    string           `json:"metodo"`
+	Ruta      string           `json:"ruta"`
+	Accion    AccionAuditoria  `json:"accion"`
+	Entidad   EntidadAuditoria `json:"entidad"`
+	EntidadID int              `json:"id_entidad"`
+	Antes     json.RawMessage  `json:"antes,omitempty"`
+	Despues   json.RawMessage  `json:"despues,omitempty"`
+	Fecha     time.Time        `json:"fecha"`
+}
+
+// NewRegistroAuditoria crea un registro de auditoría con la fecha actual. antes
+// y despues se guardan como JSON; un valor nil no se guarda.
+func NewRegistroAuditoria(actor, metodo, ruta string, accion AccionAuditoria, entidad EntidadAuditoria,
+	entidadID int, antes, despues interface{}) (*RegistroAuditoria, error) {
+	registro := &RegistroAuditoria{
+		Actor:     actor,
+		Metodo:    metodo,
+		Ruta:      ruta,
+		Accion:    accion,
+		Entidad:   entidad,
+		EntidadID: entidadID,
+		Fecha:     time.Now(),
+	}
+
+	var err error
+	if registro.Antes, err = snapshot(antes); err != nil {
+		return nil, err
+	}
+	if registro.Despues, err = snapshot(despues); err != nil {
+		return nil, err
+	}
+	return registro, nil
+}
+
+// snapshot serializa el estado de un registro, o retorna nil si no hay estado
+func snapshot(valor interface{}) (json.RawMessage, error) {
+	if valor == nil {
+		return nil, nil
+	}
+	contenido, err := json.Marshal(valor)
+	if err != nil {
+		return nil, err
+	}
+	if string(contenido) == "null" {
+		return nil, nil
+	}
+	return contenido, nil
+}
+
+// FiltroAuditoria limita la consulta de la bitácora de auditoría. Los campos
+// vacíos no filtran.
+type FiltroAuditoria struct {
+	Entidad   EntidadAuditoria
+	EntidadID int
+	Actor     string
+}
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Límites de la búsqueda de productos
+const (
+	MaxTerminosBusqueda          = 10
+	LimiteBusquedaPredeterminado = 20
+	LimiteBusquedaMaximo         = 100
+)
+
+// Pesos de cada coincidencia en la relevancia calculada por PuntuarProducto
+const (
+	pesoNombreExacto       = 3
+	pesoNombrePrefijo      = 2
+	pesoDescripcionExacta  = 1
+	pesoDescripcionPrefijo = 0.5
+)
+
+// ResultadoBusqueda es un producto encontrado por la búsqueda, con su
+// relevancia y los campos en los que coincidió resaltados. La relevancia sólo
+// es comparable entre los resultados de una misma búsqueda.
+type ResultadoBusqueda struct {
+	Producto   *Producto         `json:"producto"`
+	Relevancia float64           `json:"relevancia"`
+	Resaltado  ResaltadoBusqueda `json:"resaltado"`
+}
+
+// ResaltadoBusqueda contiene el nombre y la descripción de un producto con
+// las coincidencias encerradas en <mark>. El texto va escapado como HTML.
+type ResaltadoBusqueda struct {
+	Nombre      string `json:"nombre"`
+	Descripcion string `json:"descripcion,omitempty"`
+}
+
+// NewResultadoBusqueda arma el resultado de un producto con las coincidencias
+// de los términos resaltadas
+func NewResultadoBusqueda(producto *Producto, terminos []string, relevancia float64) *ResultadoBusqueda {
+	return &ResultadoBusqueda{
+		Producto:   producto,
+		Relevancia: relevancia,
+		Resaltado: ResaltadoBusqueda{
+			Nombre:      Resaltar(producto.Nombre, terminos),
+			Descripcion: Resaltar(producto.Descripcion, terminos),
+		},
+	}
+}
+
+// sinAcentos asocia las letras acentuadas del español, y otras comunes, con
+// su letra base
+var sinAcentos = map[rune]rune{
+	'á': 'a', 'à': 'a', 'ä': 'a', 'â': 'a', 'ã': 'a',
+	'é': 'e', 'è': 'e', 'ë': 'e', 'ê': 'e',
+	'í': 'i', 'ì': 'i', 'ï': 'i', 'î': 'i',
+	'ó': 'o', 'ò': 'o', 'ö': 'o', 'ô': 'o', 'õ': 'o',
+	'ú': 'u', 'ù': 'u', 'ü': 'u', 'û': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
+
+// normalizarRuna pasa una letra a minúscula y sin acento
+func normalizarRuna(r rune) rune {
+	r = unicode.ToLower(r)
+	if base, ok := sinAcentos[r]; ok {
+		return base
+	}
+	return r
+}
+
+// NormalizarBusqueda pasa un texto a minúsculas y sin acentos, de modo que
+// "Electrónica" y "electronica" coincidan. Conserva una letra por cada letra
+// del texto original.
+func NormalizarBusqueda(texto string) string {
+	return strings.Map(normalizarRuna, texto)
+}
+
+// esSeparador indica si un carácter separa las palabras de un texto
+func esSeparador(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// TerminosBusqueda separa una consulta en sus palabras normalizadas, sin
+// repetir y hasta MaxTerminosBusqueda. Los signos de puntuación se descartan.
+func TerminosBusqueda(consulta string) []string {
+	var terminos []string
+	vistos := make(map[string]bool)
+	for _, termino := range strings.FieldsFunc(NormalizarBusqueda(consulta), esSeparador) {
+		if vistos[termino] {
+			continue
+		}
+		vistos[termino] = true
+		terminos = append(terminos, termino)
+		if len(terminos) == MaxTerminosBusqueda {
+			break
+		}
+	}
+	return terminos
+}
+
+// PuntuarProducto calcula la relevancia de un producto para los términos de
+// una búsqueda. Cada término debe aparecer en el nombre o la descripción como
+// palabra completa o como inicio de una palabra, para permitir el
+// autocompletado; las coincidencias en el nombre y las palabras completas
+// pesan más. Retorna cero si algún término no aparece.
+func PuntuarProducto(producto *Producto, terminos []string) float64 {
+	nombre := strings.FieldsFunc(NormalizarBusqueda(producto.Nombre), esSeparador)
+	descripcion := strings.FieldsFunc(NormalizarBusqueda(producto.Descripcion), esSeparador)
+
+	var total float64
+	for _, termino := range terminos {
+		puntos := puntuarPalabras(nombre, termino, pesoNombreExacto, pesoNombrePrefijo)
+		if puntos == 0 {
+			puntos = puntuarPalabras(descripcion, termino, pesoDescripcionExacta, pesoDescripcionPrefijo)
+		}
+		if puntos == 0 {
+			return 0
+		}
+		total += puntos
+	}
+	return total
+}
+
+// puntuarPalabras retorna el peso de la mejor coincidencia de un término en
+// una lista de palabras, o cero si no coincide con ninguna
+func puntuarPalabras(palabras []string, termino string, exacto, prefijo float64) float64 {
+	var mejor float64
+	for _, palabra := range palabras {
+		switch {
+		case palabra == termino:
+			return exacto
+		case strings.HasPrefix(palabra, termino):
+			mejor = prefijo
+		}
+	}
+	return mejor
+}
+
+// Resaltar encierra en <mark> el inicio de cada palabra del texto que coincide
+// con alguno de los términos, sin importar mayúsculas ni acentos. El resto
+// del texto se escapa como HTML.
+func Resaltar(texto string, terminos []string) string {
+	runas := []rune(texto)
+	var resultado strings.Builder
+
+	for i := 0; i < len(runas); {
+		if esSeparador(runas[i]) {
+			resultado.WriteString(html.EscapeString(string(runas[i])))
+			i++
+			continue
+		}
+
+		fin := i
+		for fin < len(runas) && !esSeparador(runas[fin]) {
+			fin++
+		}
+		palabra := runas[i:fin]
+
+		coincidencia := 0
+		normalizada := NormalizarBusqueda(string(palabra))
+		for _, termino := range terminos {
+			if strings.HasPrefix(normalizada, termino) {
+				coincidencia = max(coincidencia, len([]rune(termino)))
+			}
+		}
+
+		if coincidencia > 0 {
+			resultado.WriteString("<mark>")
+			resultado.WriteString(html.EscapeString(string(palabra[:coincidencia])))
+			resultado.WriteString("</mark>")
+		}
+		resultado.WriteString(html.EscapeString(string(palabra[coincidencia:])))
+		i = fin
+	}
+
+	return resultado.String()
+}
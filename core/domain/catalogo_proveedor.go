@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// ProveedorProducto es la entrada del catálogo de un proveedor para un
+// producto: el código con el que el proveedor lo identifica, su costo, la
+// cantidad mínima que acepta por pedido y sus días de entrega. Un producto
+// puede estar en el catálogo de varios proveedores; a lo más uno es el
+// preferido.
+type ProveedorProducto struct {
+	ProveedorID        int    `json:"id_proveedor"`
+	ProductoID         int    `json:"id_producto"`
+	SKUProveedor       string `json:"sku_proveedor"`
+	Costo              Money  `json:"costo"`
+	CantidadMinima     int    `json:"cantidad_minima"`
+	TiempoEntregaDias  int    `json:"tiempo_entrega_dias"`
+	Preferido          bool   `json:"preferido"`
+	FechaActualizacion string `json:"fecha_actualizacion"`
+	NombreProveedor    string `json:"nombre_proveedor,omitempty"`
+	NombreProducto     string `json:"nombre_producto,omitempty"`
+}
+
+// TipoAdvertenciaOrden clasifica las advertencias de una línea de orden
+type TipoAdvertenciaOrden string
+
+const (
+	// AdvertenciaSinCatalogo indica que el proveedor no tiene el producto en su catálogo
+	AdvertenciaSinCatalogo TipoAdvertenciaOrden = "sin_catalogo"
+	// AdvertenciaPrecioDistinto indica que el precio difiere del costo de catálogo
+	AdvertenciaPrecioDistinto TipoAdvertenciaOrden = "precio_distinto"
+	// AdvertenciaCantidadMinima indica que se pide menos de la cantidad mínima del proveedor
+	AdvertenciaCantidadMinima TipoAdvertenciaOrden = "cantidad_minima"
+)
+
+// AdvertenciaOrden señala una línea de orden de proveedor que no coincide con
+// el catálogo. No impide crear la orden.
+type AdvertenciaOrden struct {
+	ProductoID    int                  `json:"id_producto"`
+	Tipo          TipoAdvertenciaOrden `json:"tipo"`
+	Mensaje       string               `json:"mensaje"`
+	CostoCatalogo *Money               `json:"costo_catalogo,omitempty"`
+	Desviacion    *float64             `json:"desviacion_porcentaje,omitempty"`
+}
+
+// RevisarLineaOrden compara una línea de orden contra la entrada de catálogo
+// del proveedor, que puede ser nil, y retorna las advertencias encontradas
+func RevisarLineaOrden(entrada *ProveedorProducto, productoID, cantidad int, precio Money) []AdvertenciaOrden {
+	if entrada == nil {
+		return []AdvertenciaOrden{{
+			ProductoID: productoID,
+			Tipo:       AdvertenciaSinCatalogo,
+			Mensaje:    fmt.Sprintf("El producto %d no está en el catálogo del proveedor", productoID),
+		}}
+	}
+
+	var advertencias []AdvertenciaOrden
+	if precio.Cmp(entrada.Costo) != 0 {
+		costo := entrada.Costo
+		advertencia := AdvertenciaOrden{
+			ProductoID:    productoID,
+			Tipo:          AdvertenciaPrecioDistinto,
+			Mensaje:       fmt.Sprintf("El precio %s difiere del costo de catálogo %s", precio, entrada.Costo),
+			CostoCatalogo: &costo,
+		}
+		if !entrada.Costo.IsZero() {
+			desviacion := float64(precio.Sub(entrada.Costo).Centavos) * 100 / float64(entrada.Costo.Centavos)
+			desviacion = math.Round(desviacion*100) / 100
+			advertencia.Desviacion = &desviacion
+		}
+		advertencias = append(advertencias, advertencia)
+	}
+
+	if cantidad < entrada.CantidadMinima {
+		advertencias = append(advertencias, AdvertenciaOrden{
+			ProductoID: productoID,
+			Tipo:       AdvertenciaCantidadMinima,
+			Mensaje: fmt.Sprintf("La cantidad %d es menor que la mínima del proveedor (%d)",
+				cantidad, entrada.CantidadMinima),
+		})
+	}
+
+	return advertencias
+}
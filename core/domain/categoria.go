@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCodigoBarrasInvalido indica que un código de barras no es un EAN-8,
+// UPC-A o EAN-13 válido
+var ErrCodigoBarrasInvalido = errors.New("código de barras inválido")
+
+// Categoria clasifica productos. Las categorías forman un árbol: una
+// categoría sin padre es raíz y filtrar por una categoría incluye a todas sus
+// subcategorías. Una categoría sin tasa de IVA hereda la de su padre.
+type Categoria struct {
+	ID               int          `json:"id_categoria"`
+	Nombre           string       `json:"nombre"`
+	Descripcion      string       `json:"descripcion"`
+	CategoriaPadreID *int         `json:"id_categoria_padre,omitempty"`
+	TasaIVA          *Porcentaje  `json:"tasa_iva,omitempty"`
+	Subcategorias    []*Categoria `json:"subcategorias,omitempty"`
+}
+
+// DescendientesDe retorna el ID de la categoría indicada y los de todas sus
+// subcategorías, a cualquier profundidad
+func DescendientesDe(categorias []*Categoria, categoriaID int) []int {
+	hijos := make(map[int][]int)
+	for _, c := range categorias {
+		if c.CategoriaPadreID != nil {
+			hijos[*c.CategoriaPadreID] = append(hijos[*c.CategoriaPadreID], c.ID)
+		}
+	}
+
+	ids := []int{categoriaID}
+	visitados := map[int]bool{categoriaID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, hijo := range hijos[ids[i]] {
+			if !visitados[hijo] {
+				visitados[hijo] = true
+				ids = append(ids, hijo)
+			}
+		}
+	}
+	return ids
+}
+
+// AncestrosDe retorna el ID de la categoría indicada seguido de los de sus
+// ancestros, del padre a la raíz
+func AncestrosDe(categorias map[int]*Categoria, categoriaID int) []int {
+	ids := []int{}
+	visitados := make(map[int]bool)
+	for id := &categoriaID; id != nil && !visitados[*id]; {
+		visitados[*id] = true
+		ids = append(ids, *id)
+		categoria, ok := categorias[*id]
+		if !ok {
+			break
+		}
+		id = categoria.CategoriaPadreID
+	}
+	return ids
+}
+
+// ResolverTasaIVA retorna la tasa de IVA de un producto: la suya si la tiene,
+// si no la de la categoría más cercana que tenga una y, en último caso,
+// TasaIVAPredeterminada
+func ResolverTasaIVA(producto *Producto, categorias map[int]*Categoria) Porcentaje {
+	if producto.TasaIVA != nil {
+		return *producto.TasaIVA
+	}
+	if producto.CategoriaID != nil {
+		for _, id := range AncestrosDe(categorias, *producto.CategoriaID) {
+			if categoria, ok := categorias[id]; ok && categoria.TasaIVA != nil {
+				return *categoria.TasaIVA
+			}
+		}
+	}
+	return TasaIVAPredeterminada
+}
+
+// ArbolCategorias arma el árbol de categorías a partir de la lista plana y
+// retorna sus raíces. Las categorías cuyo padre no está en la lista se tratan
+// como raíces.
+func ArbolCategorias(categorias []*Categoria) []*Categoria {
+	porID := make(map[int]*Categoria, len(categorias))
+	for _, c := range categorias {
+		copia := *c
+		copia.Subcategorias = nil
+		porID[c.ID] = &copia
+	}
+
+	raices := []*Categoria{}
+	for _, c := range categorias {
+		nodo := porID[c.ID]
+		if c.CategoriaPadreID != nil {
+			if padre, ok := porID[*c.CategoriaPadreID]; ok {
+				padre.Subcategorias = append(padre.Subcategorias, nodo)
+				continue
+			}
+		}
+		raices = append(raices, nodo)
+	}
+	return raices
+}
+
+// ValidarCodigoBarras verifica que un código sea un EAN-8, UPC-A (12 dígitos)
+// o EAN-13 con dígito verificador correcto
+func ValidarCodigoBarras(codigo string) error {
+	switch len(codigo) {
+	case 8, 12, 13:
+	default:
+		return fmt.Errorf("%w: %q debe tener 8, 12 o 13 dígitos", ErrCodigoBarrasInvalido, codigo)
+	}
+
+	suma := 0
+	for i := len(codigo) - 2; i >= 0; i-- {
+		d := codigo[i]
+		if d < '0' || d > '9' {
+			return fmt.Errorf("%w: %q sólo puede contener dígitos", ErrCodigoBarrasInvalido, codigo)
+		}
+		// De derecha a izquierda, sin contar el verificador, los pesos alternan 3 y 1
+		peso := 1
+		if (len(codigo)-2-i)%2 == 0 {
+			peso = 3
+		}
+		suma += int(d-'0') * peso
+	}
+
+	verificador := codigo[len(codigo)-1]
+	if verificador < '0' || verificador > '9' {
+		return fmt.Errorf("%w: %q sólo puede contener dígitos", ErrCodigoBarrasInvalido, codigo)
+	}
+	if int(verificador-'0') != (10-suma%10)%10 {
+		return fmt.Errorf("%w: %q tiene un dígito verificador incorrecto", ErrCodigoBarrasInvalido, codigo)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package domain
+
+// Cliente es quien realiza pedidos y compras. Su dirección es la de entrega
+// predeterminada de sus documentos.
+type Cliente struct {
+	ID            int    `json:"id_cliente"`
+	Nombre        string `json:"nombre"`
+	RFC           string `json:"rfc"`
+	Email         string `json:"email"`
+	Telefono      string `json:"telefono"`
+	Direccion     string `json:"direccion"`
+	FechaRegistro string `json:"fecha_registro"`
+}
+
+// ResumenCliente acumula los documentos de un cliente. Los totales excluyen
+// los documentos cancelados.
+type ResumenCliente struct {
+	NumeroPedidos int   `json:"numero_pedidos"`
+	TotalPedidos  Money `json:"total_pedidos"`
+	NumeroVentas  int   `json:"numero_ventas"`
+	TotalVentas   Money `json:"total_ventas"`
+}
+
+// NewResumenCliente calcula el resumen de los pedidos y ventas de un cliente
+func NewResumenCliente(pedidos []*Pedido, ventas []*Venta) ResumenCliente {
+	resumen := ResumenCliente{TotalPedidos: NewMoney(0), TotalVentas: NewMoney(0)}
+	for _, pedido := range pedidos {
+		if pedido.Estado != PedidoCancelado {
+			resumen.NumeroPedidos++
+			resumen.TotalPedidos = resumen.TotalPedidos.Add(pedido.Total)
+		}
+	}
+	for _, venta := range ventas {
+		if venta.Estado != VentaCancelada {
+			resumen.NumeroVentas++
+			resumen.TotalVentas = resumen.TotalVentas.Add(venta.Total)
+		}
+	}
+	return resumen
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// CostoPromedioPonderado calcula el nuevo costo promedio de un producto al
+// recibir cantidad unidades a un costo unitario. Si no había existencia
+// positiva el costo anterior se descarta y el nuevo costo es el de la
+// recepción. El resultado se redondea al centavo, con los medios centavos
+// alejándose de cero.
+func CostoPromedioPonderado(existenciaAnterior int, costoAnterior Money, cantidad int, costo Money) Money {
+	if cantidad <= 0 {
+		return costoAnterior
+	}
+	if existenciaAnterior <= 0 {
+		return costo
+	}
+
+	valor := costoAnterior.Centavos*int64(existenciaAnterior) + costo.Centavos*int64(cantidad)
+	unidades := int64(existenciaAnterior + cantidad)
+
+	return Money{Centavos: dividirRedondeando(valor, unidades), Moneda: costo.mismaMoneda(costoAnterior)}
+}
+
+// dividirRedondeando divide dos enteros redondeando los medios alejándose de
+// cero. El divisor debe ser positivo.
+func dividirRedondeando(dividendo, divisor int64) int64 {
+	if dividendo < 0 {
+		return -((-dividendo + divisor/2) / divisor)
+	}
+	return (dividendo + divisor/2) / divisor
+}
+
+// AgrupacionMargen es el criterio con el que se desglosa el reporte de margen
+type AgrupacionMargen string
+
+const (
+	MargenPorProducto  AgrupacionMargen = "producto"
+	MargenPorProveedor AgrupacionMargen = "proveedor"
+	MargenPorDia       AgrupacionMargen = "dia"
+	MargenPorSemana    AgrupacionMargen = "semana"
+	MargenPorMes       AgrupacionMargen = "mes"
+)
+
+// ValidarAgrupacionMargen verifica que la agrupación sea una de las conocidas
+func ValidarAgrupacionMargen(agrupacion AgrupacionMargen) error {
+	switch agrupacion {
+	case MargenPorProducto, MargenPorProveedor, MargenPorDia, MargenPorSemana, MargenPorMes:
+		return nil
+	}
+	return fmt.Errorf("agrupación inválida: %q (use producto, proveedor, dia, semana o mes)", agrupacion)
+}
+
+// LineaMargen es una fila del reporte de margen: lo vendido de un producto,
+// de un proveedor o en un periodo, con su costo y su margen bruto
+type LineaMargen struct {
+	Clave            string  `json:"clave"`
+	Nombre           string  `json:"nombre"`
+	Cantidad         int     `json:"cantidad"`
+	Ingresos         Money   `json:"ingresos"`
+	Costo            Money   `json:"costo"`
+	Margen           Money   `json:"margen"`
+	MargenPorcentaje float64 `json:"margen_porcentaje"`
+}
+
+// CalcularMargen completa el margen bruto y su porcentaje sobre los ingresos
+// a partir de los ingresos y el costo de la línea
+func (l *LineaMargen) CalcularMargen() {
+	l.Margen = l.Ingresos.Sub(l.Costo)
+	l.MargenPorcentaje = 0
+	if !l.Ingresos.IsZero() {
+		porcentaje := float64(l.Margen.Centavos) * 100 / float64(l.Ingresos.Centavos)
+		l.MargenPorcentaje = math.Round(porcentaje*100) / 100
+	}
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// DestinoDevolucion indica a qué existencia regresan las unidades devueltas
+type DestinoDevolucion string
+
+const (
+	// DevolucionVendible regresa las unidades a la existencia disponible para venta
+	DevolucionVendible DestinoDevolucion = "vendible"
+	// DevolucionDanado aparta las unidades como mercancía dañada, fuera de la venta
+	DevolucionDanado DestinoDevolucion = "danado"
+)
+
+// EsValido indica si el destino es uno de los destinos conocidos
+func (d DestinoDevolucion) EsValido() bool {
+	return d == DevolucionVendible || d == DevolucionDanado
+}
+
+// Devolucion es el documento que registra la devolución de parte de una
+// venta. Sus líneas hacen referencia a las líneas de la venta devueltas.
+type Devolucion struct {
+	ID        int       `json:"id_devolucion"`
+	VentaID   int       `json:"id_venta"`
+	AlmacenID int       `json:"id_almacen"`
+	Motivo    string    `json:"motivo,omitempty"`
+	Usuario   string    `json:"usuario"`
+	Fecha     time.Time `json:"fecha"`
+	Reembolso Money     `json:"reembolso"`
+}
+
+// DetallesDevolucion es una línea de una devolución: cuántas unidades de una
+// línea de la venta se devuelven, a qué existencia regresan y cuánto se
+// reembolsa por ellas
+type DetallesDevolucion struct {
+	ID             int               `json:"id_detalle_devolucion"`
+	DevolucionID   int               `json:"id_devolucion"`
+	DetalleVentaID int               `json:"id_detalle_venta"`
+	ProductoID     int               `json:"id_producto"`
+	Cantidad       int               `json:"cantidad"`
+	Destino        DestinoDevolucion `json:"destino"`
+	PrecioUnitario Money             `json:"precio_unitario"`
+	Reembolso      Money             `json:"reembolso"`
+}
+
+// NewDetallesDevolucion crea la línea que devuelve cantidad unidades de una
+// línea de venta; devuelto son las unidades de esa línea que ya se habían
+// devuelto antes. El reembolso es la parte que le toca del total cobrado por
+// la línea, con sus descuentos e impuesto; al devolver toda la línea se
+// reembolsa exactamente su total. Retorna
+// ErrDevolucionInvalida si en total se devolvería más de lo vendido.
+func NewDetallesDevolucion(detalle *DetallesVenta, devuelto, cantidad int, destino DestinoDevolucion) (*DetallesDevolucion, error) {
+	if !destino.EsValido() {
+		return nil, fmt.Errorf("%w: destino inválido %q (use vendible o danado)", ErrDevolucionInvalida, destino)
+	}
+	if disponible := detalle.Cantidad - devuelto; cantidad > disponible {
+		return nil, fmt.Errorf("%w: el detalle %d vendió %d unidades, ya se devolvieron %d y se intentó devolver %d",
+			ErrDevolucionInvalida, detalle.ID, detalle.Cantidad, devuelto, cantidad)
+	}
+
+	return &DetallesDevolucion{
+		DetalleVentaID: detalle.ID,
+		ProductoID:     detalle.ProductoID,
+		Cantidad:       cantidad,
+		Destino:        destino,
+		PrecioUnitario: detalle.PrecioUnitario,
+		Reembolso: detalle.ImportesLinea.ParteDe(detalle.PrecioUnitario.Mul(cantidad),
+			devuelto, cantidad, detalle.Cantidad).Total,
+	}, nil
+}
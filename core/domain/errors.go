@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errores de negocio que los controladores traducen a respuestas HTTP
+var (
+	ErrStockInsuficiente   = errors.New("stock insuficiente")
+	ErrTransicionInvalida  = errors.New("transición de estado no permitida")
+	ErrRecepcionInvalida   = errors.New("recepción inválida")
+	ErrFacturacionInvalida = errors.New("facturación inválida")
+	ErrDevolucionInvalida  = errors.New("devolución inválida")
+	ErrDuplicado           = errors.New("registro duplicado")
+	ErrTieneDependencias   = errors.New("el registro tiene documentos dependientes")
+	ErrConsultaInvalida    = errors.New("consulta inválida")
+)
+
+// FaltanteStock describe un producto cuya existencia no alcanza para lo solicitado
+type FaltanteStock struct {
+	ProductoID int `json:"id_producto"`
+	AlmacenID  int `json:"id_almacen,omitempty"`
+	Solicitado int `json:"cantidad_solicitada"`
+	Disponible int `json:"existencia_disponible"`
+}
+
+// StockInsuficienteError agrupa todos los productos faltantes de un documento
+type StockInsuficienteError struct {
+	Faltantes []FaltanteStock
+}
+
+// Error implementa la interfaz error
+func (e *StockInsuficienteError) Error() string {
+	return fmt.Sprintf("stock insuficiente para %d producto(s)", len(e.Faltantes))
+}
+
+// Is permite comparar con errors.Is(err, ErrStockInsuficiente)
+func (e *StockInsuficienteError) Is(target error) bool {
+	return target == ErrStockInsuficiente
+}
+
+// Dependencia lista los documentos de un tipo que hacen referencia a un
+// registro que se quiere eliminar definitivamente
+type Dependencia struct {
+	Tipo string `json:"tipo"`
+	IDs  []int  `json:"ids"`
+}
+
+// DependenciasError agrupa los documentos que impiden eliminar un registro
+type DependenciasError struct {
+	Dependencias []Dependencia
+}
+
+// Error implementa la interfaz error
+func (e *DependenciasError) Error() string {
+	tipos := make([]string, len(e.Dependencias))
+	for i, dependencia := range e.Dependencias {
+		tipos[i] = fmt.Sprintf("%s (%d)", dependencia.Tipo, len(dependencia.IDs))
+	}
+	return fmt.Sprintf("%v: %s", ErrTieneDependencias, strings.Join(tipos, ", "))
+}
+
+// Is permite comparar con errors.Is(err, ErrTieneDependencias)
+func (e *DependenciasError) Is(target error) bool {
+	return target == ErrTieneDependencias
+}
@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// EstadoPedido representa el estado de un pedido
+type EstadoPedido string
+
+const (
+	PedidoPendiente             EstadoPedido = "pendiente"
+	PedidoAprobado              EstadoPedido = "aprobado"
+	PedidoEnviado               EstadoPedido = "enviado"
+	PedidoParcialmenteFacturado EstadoPedido = "parcialmente_facturado"
+	PedidoCompletado            EstadoPedido = "completado"
+	PedidoCancelado             EstadoPedido = "cancelado"
+)
+
+// transicionesPedido declara los cambios de estado permitidos para un pedido.
+// Un pedido aprobado o enviado puede facturarse completo o en partes; uno
+// parcialmente facturado se completa al facturar lo que le falta o se cancela
+// liberando lo no facturado.
+var transicionesPedido = map[EstadoPedido][]EstadoPedido{
+	PedidoPendiente:             {PedidoAprobado, PedidoCancelado},
+	PedidoAprobado:              {PedidoEnviado, PedidoParcialmenteFacturado, PedidoCompletado, PedidoCancelado},
+	PedidoEnviado:               {PedidoParcialmenteFacturado, PedidoCompletado},
+	PedidoParcialmenteFacturado: {PedidoCompletado, PedidoCancelado},
+}
+
+// EsValido indica si el estado es uno de los estados conocidos de un pedido
+func (e EstadoPedido) EsValido() bool {
+	switch e {
+	case PedidoPendiente, PedidoAprobado, PedidoEnviado, PedidoParcialmenteFacturado, PedidoCompletado, PedidoCancelado:
+		return true
+	}
+	return false
+}
+
+// ValidarTransicion verifica que el pedido pueda pasar al estado destino
+func (e EstadoPedido) ValidarTransicion(destino EstadoPedido) error {
+	return validarTransicion(string(e), string(destino), destino.EsValido(), contiene(transicionesPedido[e], destino))
+}
+
+// PuedeFacturar indica si en este estado el pedido puede convertirse en venta
+func (e EstadoPedido) PuedeFacturar() bool {
+	switch e {
+	case PedidoAprobado, PedidoEnviado, PedidoParcialmenteFacturado:
+		return true
+	}
+	return false
+}
+
+// AdmiteDetalles indica si en este estado se pueden agregar líneas al pedido.
+// Una vez enviado, facturado o cancelado el pedido ya no aparta más stock.
+func (e EstadoPedido) AdmiteDetalles() bool {
+	return e == PedidoPendiente || e == PedidoAprobado
+}
+
+// EstadoVenta representa el estado de una venta
+type EstadoVenta string
+
+const (
+	VentaPendiente  EstadoVenta = "pendiente"
+	VentaCompletada EstadoVenta = "completada"
+	VentaCancelada  EstadoVenta = "cancelada"
+)
+
+// transicionesVenta declara los cambios de estado permitidos para una venta
+var transicionesVenta = map[EstadoVenta][]EstadoVenta{
+	VentaPendiente:  {VentaCompletada, VentaCancelada},
+	VentaCompletada: {VentaCancelada},
+}
+
+// EsValido indica si el estado es uno de los estados conocidos de una venta
+func (e EstadoVenta) EsValido() bool {
+	switch e {
+	case VentaPendiente, VentaCompletada, VentaCancelada:
+		return true
+	}
+	return false
+}
+
+// ValidarTransicion verifica que la venta pueda pasar al estado destino
+func (e EstadoVenta) ValidarTransicion(destino EstadoVenta) error {
+	return validarTransicion(string(e), string(destino), destino.EsValido(), contiene(transicionesVenta[e], destino))
+}
+
+// AdmiteDetalles indica si en este estado se pueden agregar líneas a la venta
+func (e EstadoVenta) AdmiteDetalles() bool {
+	return e == VentaPendiente
+}
+
+// EstadoOrden representa el estado de una orden de proveedor
+type EstadoOrden string
+
+const (
+	OrdenBorrador             EstadoOrden = "borrador"
+	OrdenPendiente            EstadoOrden = "pendiente"
+	OrdenAprobada             EstadoOrden = "aprobada"
+	OrdenEnviada              EstadoOrden = "enviada"
+	OrdenParcialmenteRecibida EstadoOrden = "parcialmente_recibida"
+	OrdenRecibida             EstadoOrden = "recibida"
+	OrdenCancelada            EstadoOrden = "cancelada"
+)
+
+// transicionesOrden declara los cambios de estado permitidos para una orden
+// de proveedor. Los borradores generados por el reabastecimiento automático
+// deben confirmarse (pendiente) antes de recibirse. Una orden puede recibirse
+// sin haber pasado por aprobada o enviada, y una orden recibida puede
+// cancelarse revirtiendo su inventario.
+var transicionesOrden = map[EstadoOrden][]EstadoOrden{
+	OrdenBorrador:             {OrdenPendiente, OrdenCancelada},
+	OrdenPendiente:            {OrdenAprobada, OrdenParcialmenteRecibida, OrdenRecibida, OrdenCancelada},
+	OrdenAprobada:             {OrdenEnviada, OrdenParcialmenteRecibida, OrdenRecibida, OrdenCancelada},
+	OrdenEnviada:              {OrdenParcialmenteRecibida, OrdenRecibida, OrdenCancelada},
+	OrdenParcialmenteRecibida: {OrdenRecibida, OrdenCancelada},
+	OrdenRecibida:             {OrdenCancelada},
+}
+
+// EsValido indica si el estado es uno de los estados conocidos de una orden
+func (e EstadoOrden) EsValido() bool {
+	switch e {
+	case OrdenBorrador, OrdenPendiente, OrdenAprobada, OrdenEnviada, OrdenParcialmenteRecibida, OrdenRecibida, OrdenCancelada:
+		return true
+	}
+	return false
+}
+
+// ValidarTransicion verifica que la orden pueda pasar al estado destino
+func (e EstadoOrden) ValidarTransicion(destino EstadoOrden) error {
+	return validarTransicion(string(e), string(destino), destino.EsValido(), contiene(transicionesOrden[e], destino))
+}
+
+// PuedeRecibir indica si en este estado la orden acepta recepciones de mercancía
+func (e EstadoOrden) PuedeRecibir() bool {
+	switch e {
+	case OrdenPendiente, OrdenAprobada, OrdenEnviada, OrdenParcialmenteRecibida:
+		return true
+	}
+	return false
+}
+
+// AdmiteDetalles indica si en este estado se pueden agregar líneas a la orden
+func (e EstadoOrden) AdmiteDetalles() bool {
+	return e == OrdenBorrador || e.PuedeRecibir()
+}
+
+// HistorialEstado registra un cambio de estado de un documento
+type HistorialEstado struct {
+	ID             int       `json:"id_historial"`
+	DocumentoID    int       `json:"id_documento"`
+	EstadoAnterior string    `json:"estado_anterior,omitempty"`
+	EstadoNuevo    string    `json:"estado_nuevo"`
+	Usuario        string    `json:"usuario"`
+	Comentario     string    `json:"comentario,omitempty"`
+	Fecha          time.Time `json:"fecha"`
+}
+
+// NewHistorialEstado crea un registro de cambio de estado con la fecha actual
+func NewHistorialEstado(documentoID int, estadoAnterior, estadoNuevo, usuario, comentario string) *HistorialEstado {
+	return &HistorialEstado{
+		DocumentoID:    documentoID,
+		EstadoAnterior: estadoAnterior,
+		EstadoNuevo:    estadoNuevo,
+		Usuario:        usuario,
+		Comentario:     comentario,
+		Fecha:          time.Now(),
+	}
+}
+
+// validarTransicion construye el error de una transición no permitida
+func validarTransicion(origen, destino string, destinoValido, permitida bool) error {
+	if !destinoValido {
+		return fmt.Errorf("%w: estado desconocido %q", ErrTransicionInvalida, destino)
+	}
+	if !permitida {
+		return fmt.Errorf("%w: de %s a %s", ErrTransicionInvalida, origen, destino)
+	}
+	return nil
+}
+
+// contiene indica si estado está en la lista de estados permitidos
+func contiene[E ~string](permitidos []E, estado E) bool {
+	for _, e := range permitidos {
+		if e == estado {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TipoComprobante distingue la factura de una venta del documento que la cancela
+type TipoComprobante string
+
+const (
+	// ComprobanteFactura ampara una venta
+	ComprobanteFactura TipoComprobante = "factura"
+	// ComprobanteCancelacion deja sin efecto la factura de una venta cancelada
+	ComprobanteCancelacion TipoComprobante = "cancelacion"
+)
+
+// Serie retorna la serie de folios del tipo de comprobante. Cada serie lleva
+// su propia numeración consecutiva.
+func (t TipoComprobante) Serie() string {
+	if t == ComprobanteCancelacion {
+		return "C"
+	}
+	return "F"
+}
+
+// MotivoCancelacionOperacionNoRealizada es la clave del motivo con el que se
+// cancelan las facturas de ventas canceladas: la operación no se llevó a cabo
+const MotivoCancelacionOperacionNoRealizada = "03"
+
+// DocumentoFiscal es el registro de un comprobante emitido para una venta. El
+// folio se asigna al emitirlo y no cambia: consultar de nuevo la factura de
+// una venta retorna el mismo folio.
+type DocumentoFiscal struct {
+	ID                     int             `json:"id_documento_fiscal"`
+	VentaID                int             `json:"id_venta"`
+	Tipo                   TipoComprobante `json:"tipo"`
+	Serie                  string          `json:"serie"`
+	Folio                  int             `json:"folio"`
+	FechaEmision           time.Time       `json:"fecha_emision"`
+	DocumentoRelacionadoID *int            `json:"id_documento_relacionado,omitempty"`
+}
+
+// FolioCompleto retorna la serie y el folio con formato, por ejemplo F-000042
+func (d *DocumentoFiscal) FolioCompleto() string {
+	return fmt.Sprintf("%s-%06d", d.Serie, d.Folio)
+}
+
+// Emisor son los datos fiscales de quien emite las facturas
+type Emisor struct {
+	RFC           string `json:"rfc"`
+	Nombre        string `json:"nombre"`
+	RegimenFiscal string `json:"regimen_fiscal"`
+	CodigoPostal  string `json:"codigo_postal"`
+}
+
+// ConPredeterminados retorna el emisor con los datos que falten completados
+// con valores de prueba
+func (e Emisor) ConPredeterminados() Emisor {
+	if e.RFC == "" {
+		e.RFC = "EKU9003173C9"
+	}
+	if e.Nombre == "" {
+		e.Nombre = "VENTAS ONLINE"
+	}
+	if e.RegimenFiscal == "" {
+		e.RegimenFiscal = "601"
+	}
+	if e.CodigoPostal == "" {
+		e.CodigoPostal = "00000"
+	}
+	return e
+}
+
+// RFCPublicoGeneral es el RFC genérico de las ventas sin cliente identificado
+const RFCPublicoGeneral = "XAXX010101000"
+
+// Receptor son los datos fiscales del cliente de la factura
+type Receptor struct {
+	ClienteID *int   `json:"id_cliente,omitempty"`
+	RFC       string `json:"rfc"`
+	Nombre    string `json:"nombre"`
+	Direccion string `json:"direccion,omitempty"`
+}
+
+// NewReceptor crea el receptor de la factura de una venta. Las ventas sin
+// cliente, o cuyo cliente no tiene RFC, se facturan al público en general.
+func NewReceptor(venta *Venta, cliente *Cliente) Receptor {
+	receptor := Receptor{RFC: RFCPublicoGeneral, Nombre: "PUBLICO EN GENERAL", Direccion: venta.DireccionEntrega}
+	if cliente != nil {
+		receptor.ClienteID = &cliente.ID
+		if cliente.RFC != "" {
+			receptor.RFC = cliente.RFC
+			receptor.Nombre = cliente.Nombre
+		}
+	}
+	return receptor
+}
+
+// ConceptoFactura es una línea de la factura. CantidadDevuelta informa las
+// unidades de la línea devueltas después de la venta; no modifica la cantidad
+// ni los importes facturados.
+type ConceptoFactura struct {
+	ProductoID       int        `json:"id_producto"`
+	SKU              string     `json:"sku,omitempty"`
+	Descripcion      string     `json:"descripcion"`
+	Cantidad         int        `json:"cantidad"`
+	CantidadDevuelta int        `json:"cantidad_devuelta,omitempty"`
+	ValorUnitario    Money      `json:"valor_unitario"`
+	Importe          Money      `json:"importe"`
+	Descuento        Money      `json:"descuento"`
+	TasaIVA          Porcentaje `json:"tasa_iva"`
+	Impuesto         Money      `json:"impuesto"`
+	Total            Money      `json:"total"`
+}
+
+// ImpuestoFactura es el IVA trasladado de la factura a una misma tasa
+type ImpuestoFactura struct {
+	TasaIVA Porcentaje `json:"tasa_iva"`
+	Base    Money      `json:"base"`
+	Importe Money      `json:"importe"`
+}
+
+// FolioRelacionado identifica la factura que deja sin efecto una cancelación
+type FolioRelacionado struct {
+	Serie        string    `json:"serie"`
+	Folio        int       `json:"folio"`
+	FechaEmision time.Time `json:"fecha_emision"`
+}
+
+// Factura es el comprobante de una venta listo para presentarse al cliente.
+// Una venta cancelada no se factura: su comprobante es una cancelación que
+// relaciona la factura original, si se llegó a emitir. La factura ampara la
+// venta como se realizó; las devoluciones posteriores se informan en cada
+// concepto pero no cambian sus importes, que requerirían una nota de crédito.
+type Factura struct {
+	Tipo              TipoComprobante   `json:"tipo"`
+	Serie             string            `json:"serie"`
+	Folio             int               `json:"folio"`
+	FolioCompleto     string            `json:"folio_completo"`
+	FechaEmision      time.Time         `json:"fecha_emision"`
+	VentaID           int               `json:"id_venta"`
+	PedidoID          *int              `json:"id_pedido,omitempty"`
+	FechaVenta        time.Time         `json:"fecha_venta"`
+	Emisor            Emisor            `json:"emisor"`
+	Receptor          Receptor          `json:"receptor"`
+	Conceptos         []ConceptoFactura `json:"conceptos"`
+	Moneda            Moneda            `json:"moneda"`
+	Subtotal          Money             `json:"subtotal"`
+	Descuento         Money             `json:"descuento"`
+	Impuestos         []ImpuestoFactura `json:"impuestos"`
+	Total             Money             `json:"total"`
+	Relacionado       *FolioRelacionado `json:"documento_relacionado,omitempty"`
+	MotivoCancelacion string            `json:"motivo_cancelacion,omitempty"`
+}
+
+// NewFactura arma el comprobante de una venta a partir del documento fiscal
+// emitido, las líneas de la venta y sus productos. devuelto son las unidades
+// devueltas por id de detalle de venta. relacionado es la factura que deja sin
+// efecto una cancelación; puede ser nil.
+func NewFactura(documento *DocumentoFiscal, venta *Venta, detalles []*DetallesVenta, productos map[int]*Producto,
+	devuelto map[int]int, emisor Emisor, receptor Receptor, relacionado *DocumentoFiscal) *Factura {
+	factura := &Factura{
+		Tipo:          documento.Tipo,
+		Serie:         documento.Serie,
+		Folio:         documento.Folio,
+		FolioCompleto: documento.FolioCompleto(),
+		FechaEmision:  documento.FechaEmision,
+		VentaID:       venta.ID,
+		PedidoID:      venta.PedidoID,
+		FechaVenta:    venta.FechaVenta,
+		Emisor:        emisor,
+		Receptor:      receptor,
+		Conceptos:     make([]ConceptoFactura, 0, len(detalles)),
+		Moneda:        venta.Total.moneda(),
+		Subtotal:      venta.Subtotal,
+		Descuento:     venta.Descuento,
+		Total:         venta.Total,
+	}
+
+	impuestos := make(map[Porcentaje]*ImpuestoFactura)
+	for _, detalle := range detalles {
+		concepto := ConceptoFactura{
+			ProductoID:       detalle.ProductoID,
+			Cantidad:         detalle.Cantidad,
+			CantidadDevuelta: devuelto[detalle.ID],
+			ValorUnitario:    detalle.PrecioUnitario,
+			Importe:          detalle.Subtotal,
+			Descuento:        detalle.Descuento,
+			TasaIVA:          detalle.TasaIVA,
+			Impuesto:         detalle.Impuesto,
+			Total:            detalle.Total,
+		}
+		if producto, ok := productos[detalle.ProductoID]; ok {
+			concepto.SKU = producto.SKU
+			concepto.Descripcion = producto.Nombre
+		}
+		factura.Conceptos = append(factura.Conceptos, concepto)
+
+		impuesto, ok := impuestos[detalle.TasaIVA]
+		if !ok {
+			impuesto = &ImpuestoFactura{TasaIVA: detalle.TasaIVA, Base: NewMoney(0), Importe: NewMoney(0)}
+			impuestos[detalle.TasaIVA] = impuesto
+		}
+		impuesto.Base = impuesto.Base.Add(detalle.Subtotal.Sub(detalle.Descuento))
+		impuesto.Importe = impuesto.Importe.Add(detalle.Impuesto)
+	}
+
+	factura.Impuestos = make([]ImpuestoFactura, 0, len(impuestos))
+	for _, impuesto := range impuestos {
+		factura.Impuestos = append(factura.Impuestos, *impuesto)
+	}
+	sort.Slice(factura.Impuestos, func(i, j int) bool {
+		return factura.Impuestos[i].TasaIVA > factura.Impuestos[j].TasaIVA
+	})
+
+	if documento.Tipo == ComprobanteCancelacion {
+		factura.MotivoCancelacion = MotivoCancelacionOperacionNoRealizada
+		if relacionado != nil {
+			factura.Relacionado = &FolioRelacionado{
+				Serie:        relacionado.Serie,
+				Folio:        relacionado.Folio,
+				FechaEmision: relacionado.FechaEmision,
+			}
+		}
+	}
+
+	return factura
+}
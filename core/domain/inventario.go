@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// TipoDocumento identifica el tipo de documento que origina una operación
+type TipoDocumento string
+
+const (
+	DocumentoVenta          TipoDocumento = "venta"
+	DocumentoPedido         TipoDocumento = "pedido"
+	DocumentoOrdenProveedor TipoDocumento = "orden_proveedor"
+	DocumentoTransferencia  TipoDocumento = "transferencia"
+)
+
+// MotivoMovimiento indica la causa de un movimiento de inventario
+type MotivoMovimiento string
+
+const (
+	MotivoVenta           MotivoMovimiento = "venta"
+	MotivoPedido          MotivoMovimiento = "pedido"
+	MotivoRecepcionCompra MotivoMovimiento = "recepcion_compra"
+	MotivoAjusteManual    MotivoMovimiento = "ajuste_manual"
+	MotivoCancelacion     MotivoMovimiento = "cancelacion"
+	MotivoDevolucion      MotivoMovimiento = "devolucion"
+	MotivoTransferencia   MotivoMovimiento = "transferencia"
+	MotivoFacturacion     MotivoMovimiento = "facturacion"
+)
+
+// MovimientoInventario representa una entrada del kardex de un producto.
+// SaldoResultante es la existencia total del producto después del movimiento
+// y SaldoAlmacen la existencia en el almacén afectado.
+type MovimientoInventario struct {
+	ID              int              `json:"id_movimiento"`
+	ProductoID      int              `json:"id_producto"`
+	AlmacenID       int              `json:"id_almacen"`
+	Cantidad        int              `json:"cantidad"`
+	SaldoResultante int              `json:"saldo_resultante"`
+	SaldoAlmacen    int              `json:"saldo_almacen"`
+	Motivo          MotivoMovimiento `json:"motivo"`
+	TipoDocumento   TipoDocumento    `json:"tipo_documento,omitempty"`
+	DocumentoID     *int             `json:"id_documento,omitempty"`
+	Fecha           time.Time        `json:"fecha"`
+
+	// Lotes es el detalle por lote del movimiento una vez aplicado
+	Lotes []*MovimientoLote `json:"lotes,omitempty"`
+	// LotesEntrada son los lotes que crea una entrada, por ejemplo la
+	// recepción de una orden. Su cantidad no puede exceder la del movimiento.
+	LotesEntrada []*Lote `json:"-"`
+}
+
+// NewMovimientoInventario crea un movimiento en un almacén originado por un
+// documento. Un documentoID igual a cero indica que el movimiento no tiene
+// documento origen.
+func NewMovimientoInventario(productoID int, almacenID int, cantidad int, motivo MotivoMovimiento, tipoDocumento TipoDocumento, documentoID int) *MovimientoInventario {
+	movimiento := &MovimientoInventario{
+		ProductoID:    productoID,
+		AlmacenID:     almacenID,
+		Cantidad:      cantidad,
+		Motivo:        motivo,
+		TipoDocumento: tipoDocumento,
+		Fecha:         time.Now(),
+	}
+	if documentoID != 0 {
+		movimiento.DocumentoID = &documentoID
+	}
+	return movimiento
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// DiasAvisoCaducidadPredeterminado es la anticipación con la que se reportan
+// y notifican los lotes por caducar
+const DiasAvisoCaducidadPredeterminado = 30
+
+// Lote representa un lote de un producto en un almacén, con su fecha de
+// caducidad opcional. Existencia es lo que queda del lote; la parte de la
+// existencia del almacén que no pertenece a ningún lote se considera sin lote.
+type Lote struct {
+	ID               int        `json:"id_lote"`
+	ProductoID       int        `json:"id_producto"`
+	AlmacenID        int        `json:"id_almacen"`
+	NumeroLote       string     `json:"numero_lote"`
+	FechaCaducidad   *time.Time `json:"fecha_caducidad,omitempty"`
+	CantidadInicial  int        `json:"cantidad_inicial"`
+	Existencia       int        `json:"existencia"`
+	OrdenProveedorID *int       `json:"id_orden_proveedor,omitempty"`
+	FechaRecepcion   time.Time  `json:"fecha_recepcion"`
+}
+
+// DiasParaCaducar retorna los días naturales que faltan para que el lote
+// caduque respecto de ahora; es negativo si ya caducó. Retorna nil si el lote
+// no tiene fecha de caducidad.
+func (l *Lote) DiasParaCaducar(ahora time.Time) *int {
+	if l.FechaCaducidad == nil {
+		return nil
+	}
+
+	hoy := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, time.UTC)
+	caducidad := time.Date(l.FechaCaducidad.Year(), l.FechaCaducidad.Month(), l.FechaCaducidad.Day(), 0, 0, 0, 0, time.UTC)
+	dias := int(caducidad.Sub(hoy).Hours() / 24)
+	return &dias
+}
+
+// MovimientoLote es la parte de un movimiento de inventario que afectó a un
+// lote: positiva si entró al lote y negativa si salió de él
+type MovimientoLote struct {
+	ID           int    `json:"id_movimiento_lote"`
+	LoteID       int    `json:"id_lote"`
+	MovimientoID int    `json:"id_movimiento"`
+	NumeroLote   string `json:"numero_lote"`
+	Cantidad     int    `json:"cantidad"`
+}
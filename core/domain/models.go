@@ -12,63 +12,124 @@ type Proveedor struct {
 	Telefono      string `json:"telefono"`
 	Email         string `json:"email"`
 	FechaRegistro string `json:"fecha_registro"`
+	// DeletedAt es la fecha en que se eliminó el proveedor; nil si está activo
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
+// Eliminado indica si el proveedor fue eliminado. Un proveedor eliminado
+// conserva sus órdenes pero no admite órdenes nuevas.
+func (p *Proveedor) Eliminado() bool {
+	return p.DeletedAt != nil
+}
+
+// StockMinimoPredeterminado es el stock mínimo de los productos que no definen uno propio
+const StockMinimoPredeterminado = 5
+
 type Producto struct {
-	ID            int    `json:"id_producto"`
-	Nombre        string `json:"nombre"`
-	Descripcion   string `json:"descripcion"`
-	Precio        int    `json:"precio"`
-	Existencia    int    `json:"existencia"`
-	ProveedorID   int    `json:"id_proveedor"`
-	FechaCreacion string `json:"fecha_creacion"`
+	ID              int               `json:"id_producto"`
+	SKU             string            `json:"sku,omitempty"`
+	CodigoBarras    string            `json:"codigo_barras,omitempty"`
+	Nombre          string            `json:"nombre"`
+	Descripcion     string            `json:"descripcion"`
+	Precio          Money             `json:"precio"`
+	CostoPromedio   Money             `json:"costo_promedio"`
+	Existencia      int               `json:"existencia"`
+	StockMinimo     int               `json:"stock_minimo"`
+	PuntoReorden    int               `json:"punto_reorden"`
+	CantidadReorden int               `json:"cantidad_reorden"`
+	ProveedorID     int               `json:"id_proveedor"`
+	CategoriaID     *int              `json:"id_categoria,omitempty"`
+	TasaIVA         *Porcentaje       `json:"tasa_iva,omitempty"`
+	Atributos       map[string]string `json:"atributos,omitempty"`
+	FechaCreacion   string            `json:"fecha_creacion"`
+	// DeletedAt es la fecha en que se eliminó el producto; nil si está activo
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Eliminado indica si el producto fue eliminado. Un producto eliminado sigue
+// apareciendo en los documentos que ya lo incluyen pero no admite documentos
+// nuevos.
+func (p *Producto) Eliminado() bool {
+	return p.DeletedAt != nil
+}
+
+// StockBajo indica si una existencia está en o por debajo del stock mínimo del producto
+func (p *Producto) StockBajo(existencia int) bool {
+	return existencia <= p.StockMinimo
 }
 
 type Pedido struct {
-	ID          int     `json:"id_pedido"`
-	FechaPedido string  `json:"fecha_pedido"`
-	Estado      string  `json:"estado"`
-	Total       float64 `json:"total"`
+	ID               int          `json:"id_pedido"`
+	FechaPedido      string       `json:"fecha_pedido"`
+	Estado           EstadoPedido `json:"estado"`
+	AlmacenID        int          `json:"id_almacen"`
+	ClienteID        *int         `json:"id_cliente,omitempty"`
+	DireccionEntrega string       `json:"direccion_entrega,omitempty"`
+	ImportesDocumento
+	Total Money `json:"total"`
 }
 
 type DetallesPedido struct {
-	ID             int     `json:"id_detalle_pedido"`
-	PedidoID       int     `json:"id_pedido"`
-	ProductoID     int     `json:"id_producto"`
-	Cantidad       int     `json:"cantidad"`
-	PrecioUnitario float64 `json:"precio_unitario"`
-	Subtotal       float64 `json:"subtotal"`
+	ID                int   `json:"id_detalle_pedido"`
+	PedidoID          int   `json:"id_pedido"`
+	ProductoID        int   `json:"id_producto"`
+	Cantidad          int   `json:"cantidad"`
+	CantidadFacturada int   `json:"cantidad_facturada"`
+	PrecioUnitario    Money `json:"precio_unitario"`
+	Subtotal          Money `json:"subtotal"`
+	ImportesLinea
+}
+
+// Pendiente retorna las unidades de la línea que aún no se facturan
+func (d *DetallesPedido) Pendiente() int {
+	return d.Cantidad - d.CantidadFacturada
 }
 
 type Venta struct {
-	ID         int       `json:"id_venta"`
-	FechaVenta time.Time `json:"fecha_venta"`
-	Estado     string    `json:"estado"`
-	Total      float64   `json:"total"`
+	ID               int         `json:"id_venta"`
+	FechaVenta       time.Time   `json:"fecha_venta"`
+	Estado           EstadoVenta `json:"estado"`
+	AlmacenID        int         `json:"id_almacen"`
+	ClienteID        *int        `json:"id_cliente,omitempty"`
+	DireccionEntrega string      `json:"direccion_entrega,omitempty"`
+	PedidoID         *int        `json:"id_pedido,omitempty"`
+	ImportesDocumento
+	Total Money `json:"total"`
 }
 
 type DetallesVenta struct {
-	ID             int     `json:"id_detalle_venta"`
-	VentaID        int     `json:"id_venta"`
-	ProductoID     int     `json:"id_producto"`
-	Cantidad       int     `json:"cantidad"`
-	PrecioUnitario float64 `json:"precio_unitario"`
-	Subtotal       float64 `json:"subtotal"`
+	ID             int   `json:"id_detalle_venta"`
+	VentaID        int   `json:"id_venta"`
+	ProductoID     int   `json:"id_producto"`
+	Cantidad       int   `json:"cantidad"`
+	PrecioUnitario Money `json:"precio_unitario"`
+	// CostoUnitario es el costo promedio del producto al momento de la venta
+	CostoUnitario Money `json:"costo_unitario"`
+	Subtotal      Money `json:"subtotal"`
+	ImportesLinea
 }
 
 type OrdenProveedor struct {
-	ID          int    `json:"id_orden_proveedor"`
-	ProveedorID int    `json:"id_proveedor"`
-	FechaOrden  string `json:"fecha_orden"`
-	Estado      string `json:"estado"`
-	Total       int    `json:"total"`
+	ID          int         `json:"id_orden_proveedor"`
+	ProveedorID int         `json:"id_proveedor"`
+	FechaOrden  string      `json:"fecha_orden"`
+	Estado      EstadoOrden `json:"estado"`
+	AlmacenID   int         `json:"id_almacen"`
+	Total       Money       `json:"total"`
 }
 
 type DetallesOrden struct {
-	ID               int     `json:"id_detalle_orden"`
-	OrdenProveedorID int     `json:"id_orden_proveedor"`
-	ProductoID       int     `json:"id_producto"`
-	Cantidad         int     `json:"cantidad"`
-	PrecioUnitario   float64 `json:"precio_unitario"`
-	Subtotal         float64 `json:"subtotal"`
+	ID                int   `json:"id_detalle_orden"`
+	OrdenProveedorID  int   `json:"id_orden_proveedor"`
+	ProductoID        int   `json:"id_producto"`
+	Cantidad          int   `json:"cantidad"`
+	CantidadRecibida  int   `json:"cantidad_recibida"`
+	CantidadCancelada int   `json:"cantidad_cancelada"`
+	PrecioUnitario    Money `json:"precio_unitario"`
+	Subtotal          Money `json:"subtotal"`
+}
+
+// Pendiente retorna las unidades de la línea que aún no se reciben ni se cancelan
+func (d *DetallesOrden) Pendiente() int {
+	return d.Cantidad - d.CantidadRecibida - d.CantidadCancelada
 }
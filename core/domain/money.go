@@ -0,0 +1,253 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Moneda es el código ISO 4217 de una moneda
+type Moneda string
+
+// MonedaPredeterminada es la moneda en la que opera el sistema
+const MonedaPredeterminada Moneda = "MXN"
+
+// decimalesMoneda es el número de decimales de las unidades menores (centavos)
+const decimalesMoneda = 2
+
+// escalaMoneda es el número de unidades menores en una unidad de la moneda
+const escalaMoneda = 100
+
+// ErrMontoInvalido indica que un texto no representa un monto válido
+var ErrMontoInvalido = errors.New("monto inválido")
+
+// Money representa un monto exacto como un entero de unidades menores
+// (centavos) más su moneda. Evita los errores de redondeo de float64 en
+// precios, subtotales y totales.
+//
+// En JSON se codifica como número decimal con dos decimales (por ejemplo
+// 1234.50) y se acepta como número, como texto ("1234.50") o como objeto
+// {"monto": "1234.50", "moneda": "MXN"}. En MySQL se guarda en columnas
+// DECIMAL(12,2).
+type Money struct {
+	Centavos int64
+	Moneda   Moneda
+}
+
+// NewMoney crea un monto en la moneda predeterminada a partir de centavos
+func NewMoney(centavos int64) Money {
+	return Money{Centavos: centavos, Moneda: MonedaPredeterminada}
+}
+
+// ParseMoney interpreta un texto decimal como "1234.5" o "-10.25" en la
+// moneda predeterminada. Rechaza montos con más de dos decimales.
+func ParseMoney(texto string) (Money, error) {
+	s := strings.TrimSpace(texto)
+	negativo := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negativo = s[0] == '-'
+		s = s[1:]
+	}
+
+	entero, fraccion, _ := strings.Cut(s, ".")
+	if entero == "" && fraccion == "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrMontoInvalido, texto)
+	}
+	if len(fraccion) > decimalesMoneda {
+		return Money{}, fmt.Errorf("%w: %q tiene más de %d decimales", ErrMontoInvalido, texto, decimalesMoneda)
+	}
+	if !soloDigitos(entero) || !soloDigitos(fraccion) {
+		return Money{}, fmt.Errorf("%w: %q", ErrMontoInvalido, texto)
+	}
+
+	var centavos int64
+	if fraccion != "" {
+		fraccion += strings.Repeat("0", decimalesMoneda-len(fraccion))
+		centavos, _ = strconv.ParseInt(fraccion, 10, 64)
+	}
+
+	var unidades int64
+	if entero != "" {
+		var err error
+		unidades, err = strconv.ParseInt(entero, 10, 64)
+		if err != nil || unidades > (math.MaxInt64-centavos)/escalaMoneda {
+			return Money{}, fmt.Errorf("%w: %q fuera de rango", ErrMontoInvalido, texto)
+		}
+	}
+
+	total := unidades*escalaMoneda + centavos
+	if negativo {
+		total = -total
+	}
+	return NewMoney(total), nil
+}
+
+// moneda retorna la moneda del monto; el valor cero usa la predeterminada
+func (m Money) moneda() Moneda {
+	if m.Moneda == "" {
+		return MonedaPredeterminada
+	}
+	return m.Moneda
+}
+
+// mismaMoneda verifica que dos montos se puedan operar entre sí. Mezclar
+// monedas es un error de programación: la API sólo acepta la predeterminada.
+func (m Money) mismaMoneda(otro Money) Moneda {
+	if m.moneda() != otro.moneda() {
+		panic(fmt.Sprintf("operación entre monedas distintas: %s y %s", m.moneda(), otro.moneda()))
+	}
+	return m.moneda()
+}
+
+// Add retorna la suma de dos montos de la misma moneda
+func (m Money) Add(otro Money) Money {
+	return Money{Centavos: m.Centavos + otro.Centavos, Moneda: m.mismaMoneda(otro)}
+}
+
+// Sub retorna la diferencia de dos montos de la misma moneda
+func (m Money) Sub(otro Money) Money {
+	return Money{Centavos: m.Centavos - otro.Centavos, Moneda: m.mismaMoneda(otro)}
+}
+
+// Mul retorna el monto multiplicado por una cantidad entera, por ejemplo el
+// subtotal de una línea a partir de su precio unitario
+func (m Money) Mul(cantidad int) Money {
+	return Money{Centavos: m.Centavos * int64(cantidad), Moneda: m.moneda()}
+}
+
+// Proporcion retorna la parte de un monto que corresponde a parte de total
+// unidades, redondeada al centavo. Sirve para prorratear los importes de una
+// línea entre facturaciones o devoluciones parciales.
+func (m Money) Proporcion(parte, total int) Money {
+	if total == 0 {
+		return Money{Moneda: m.moneda()}
+	}
+	return Money{Centavos: dividirRedondeando(m.Centavos*int64(parte), int64(total)), Moneda: m.moneda()}
+}
+
+// Cmp compara dos montos de la misma moneda: -1 si m < otro, 0 si son
+// iguales y 1 si m > otro
+func (m Money) Cmp(otro Money) int {
+	m.mismaMoneda(otro)
+	switch {
+	case m.Centavos < otro.Centavos:
+		return -1
+	case m.Centavos > otro.Centavos:
+		return 1
+	}
+	return 0
+}
+
+// IsZero indica si el monto es cero
+func (m Money) IsZero() bool {
+	return m.Centavos == 0
+}
+
+// IsNegative indica si el monto es menor que cero
+func (m Money) IsNegative() bool {
+	return m.Centavos < 0
+}
+
+// String retorna el monto como decimal con dos decimales, sin la moneda
+func (m Money) String() string {
+	centavos := m.Centavos
+	signo := ""
+	if centavos < 0 {
+		signo = "-"
+		centavos = -centavos
+	}
+	return fmt.Sprintf("%s%d.%02d", signo, centavos/escalaMoneda, centavos%escalaMoneda)
+}
+
+// MarshalJSON codifica el monto como número decimal exacto
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON acepta un número, un texto decimal o un objeto con monto y
+// moneda. Sólo se admite la moneda predeterminada.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var texto string
+	switch {
+	case len(data) > 0 && data[0] == '"':
+		if err := json.Unmarshal(data, &texto); err != nil {
+			return err
+		}
+	case len(data) > 0 && data[0] == '{':
+		var objeto struct {
+			Monto  json.RawMessage `json:"monto"`
+			Moneda Moneda          `json:"moneda"`
+		}
+		if err := json.Unmarshal(data, &objeto); err != nil {
+			return err
+		}
+		if objeto.Moneda != "" && objeto.Moneda != MonedaPredeterminada {
+			return fmt.Errorf("%w: moneda %q no soportada", ErrMontoInvalido, objeto.Moneda)
+		}
+		return m.UnmarshalJSON(objeto.Monto)
+	default:
+		texto = string(data)
+	}
+
+	monto, err := ParseMoney(texto)
+	if err != nil {
+		return err
+	}
+	*m = monto
+	return nil
+}
+
+// Scan implementa sql.Scanner para leer columnas DECIMAL
+func (m *Money) Scan(valor interface{}) error {
+	switch v := valor.(type) {
+	case nil:
+		*m = NewMoney(0)
+		return nil
+	case []byte:
+		monto, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = monto
+		return nil
+	case string:
+		monto, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = monto
+		return nil
+	case int64:
+		*m = NewMoney(v * escalaMoneda)
+		return nil
+	case float64:
+		*m = NewMoney(int64(math.Round(v * escalaMoneda)))
+		return nil
+	}
+	return fmt.Errorf("%w: no se puede leer %T como monto", ErrMontoInvalido, valor)
+}
+
+// Value implementa driver.Valuer; el texto decimal se guarda sin pérdida en DECIMAL
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// soloDigitos indica si el texto contiene únicamente dígitos decimales
+func soloDigitos(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	casos := []struct {
+		texto    string
+		centavos int64
+		invalido bool
+	}{
+		{texto: "0", centavos: 0},
+		{texto: "1234.5", centavos: 123450},
+		{texto: "1234.50", centavos: 123450},
+		{texto: "0.05", centavos: 5},
+		{texto: ".5", centavos: 50},
+		{texto: "7.", centavos: 700},
+		{texto: "-10.25", centavos: -1025},
+		{texto: "+3.1", centavos: 310},
+		{texto: " 19.99 ", centavos: 1999},
+		{texto: "0.1", centavos: 10},
+		{texto: "1.005", invalido: true},
+		{texto: "", invalido: true},
+		{texto: ".", invalido: true},
+		{texto: "-", invalido: true},
+		{texto: "1e3", invalido: true},
+		{texto: "1,50", invalido: true},
+		{texto: "--1", invalido: true},
+		{texto: "1.-5", invalido: true},
+		{texto: "92233720368547758.08", invalido: true},
+		{texto: "92233720368547758.07", centavos: math.MaxInt64},
+	}
+
+	for _, caso := range casos {
+		monto, err := ParseMoney(caso.texto)
+		if caso.invalido {
+			if !errors.Is(err, ErrMontoInvalido) {
+				t.Errorf("ParseMoney(%q) = %v, %v; se esperaba ErrMontoInvalido", caso.texto, monto, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) error inesperado: %v", caso.texto, err)
+			continue
+		}
+		if monto.Centavos != caso.centavos || monto.Moneda != MonedaPredeterminada {
+			t.Errorf("ParseMoney(%q) = %d %s, se esperaba %d %s",
+				caso.texto, monto.Centavos, monto.Moneda, caso.centavos, MonedaPredeterminada)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	casos := []struct {
+		centavos int64
+		texto    string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{123450, "1234.50"},
+		{-1025, "-10.25"},
+	}
+
+	for _, caso := range casos {
+		if texto := NewMoney(caso.centavos).String(); texto != caso.texto {
+			t.Errorf("NewMoney(%d).String() = %q, se esperaba %q", caso.centavos, texto, caso.texto)
+		}
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	casos := []struct {
+		precio   string
+		cantidad int
+		subtotal string
+	}{
+		{"19.99", 3, "59.97"},
+		{"0.10", 3, "0.30"},
+		{"0.01", 0, "0.00"},
+		{"-2.50", 4, "-10.00"},
+		{"1234.56", 1000, "1234560.00"},
+	}
+
+	for _, caso := range casos {
+		precio, _ := ParseMoney(caso.precio)
+		if subtotal := precio.Mul(caso.cantidad).String(); subtotal != caso.subtotal {
+			t.Errorf("%s x %d = %s, se esperaba %s", caso.precio, caso.cantidad, subtotal, caso.subtotal)
+		}
+	}
+}
+
+func TestMoneyProporcion(t *testing.T) {
+	casos := []struct {
+		monto    int64
+		parte    int
+		total    int
+		centavos int64
+	}{
+		{monto: 1000, parte: 1, total: 3, centavos: 333},
+		{monto: 1000, parte: 2, total: 3, centavos: 667},
+		{monto: 1000, parte: 3, total: 3, centavos: 1000},
+		{monto: 1, parte: 1, total: 2, centavos: 1},   // medio centavo se aleja de cero
+		{monto: -1, parte: 1, total: 2, centavos: -1}, // también en negativos
+		{monto: 999, parte: 0, total: 4, centavos: 0},
+		{monto: 999, parte: 1, total: 0, centavos: 0},  // total cero no divide
+		{monto: 250, parte: 1, total: 4, centavos: 63}, // 62.5 sube a 63
+		{monto: -250, parte: 1, total: 4, centavos: -63},
+	}
+
+	for _, caso := range casos {
+		resultado := NewMoney(caso.monto).Proporcion(caso.parte, caso.total)
+		if resultado.Centavos != caso.centavos {
+			t.Errorf("Proporcion(%d, %d/%d) = %d, se esperaba %d",
+				caso.monto, caso.parte, caso.total, resultado.Centavos, caso.centavos)
+		}
+	}
+}
+
+func TestMoneyProporcionAcumuladaSumaElTotal(t *testing.T) {
+	// Las partes calculadas de forma acumulada, como en ImportesLinea.ParteDe,
+	// deben sumar exactamente el monto aunque cada una se redondee
+	monto := NewMoney(1001)
+	cortes := []int{1, 2, 2, 1, 1}
+	total := 7
+
+	suma := NewMoney(0)
+	previas := 0
+	for _, cantidad := range cortes {
+		parte := monto.Proporcion(previas+cantidad, total).Sub(monto.Proporcion(previas, total))
+		suma = suma.Add(parte)
+		previas += cantidad
+	}
+
+	if suma.Cmp(monto) != 0 {
+		t.Errorf("las partes suman %s, se esperaba %s", suma, monto)
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	casos := []struct {
+		json     string
+		centavos int64
+		invalido bool
+	}{
+		{json: `12.5`, centavos: 1250},
+		{json: `"12.50"`, centavos: 1250},
+		{json: `{"monto": "12.50", "moneda": "MXN"}`, centavos: 1250},
+		{json: `{"monto": 3}`, centavos: 300},
+		{json: `{"monto": "12.50", "moneda": "USD"}`, invalido: true},
+		{json: `0.001`, invalido: true},
+		{json: `"abc"`, invalido: true},
+	}
+
+	for _, caso := range casos {
+		var monto Money
+		err := json.Unmarshal([]byte(caso.json), &monto)
+		if caso.invalido {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %s, se esperaba un error", caso.json, monto)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) error inesperado: %v", caso.json, err)
+			continue
+		}
+		if monto.Centavos != caso.centavos {
+			t.Errorf("Unmarshal(%s) = %d, se esperaba %d", caso.json, monto.Centavos, caso.centavos)
+		}
+	}
+}
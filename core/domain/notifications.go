@@ -9,9 +9,13 @@ import (
 type NotificationType string
 
 const (
-	LowStockNotification    NotificationType = "low_stock"
-	NewOrderNotification    NotificationType = "new_order"
-	CancelOrderNotification NotificationType = "cancel_order"
+	LowStockNotification      NotificationType = "low_stock"
+	NewOrderNotification      NotificationType = "new_order"
+	CancelOrderNotification   NotificationType = "cancel_order"
+	RestockNotification       NotificationType = "restock"
+	DraftOrderNotification    NotificationType = "draft_order"
+	ExpiringStockNotification NotificationType = "expiring_stock"
+	SaleReturnNotification    NotificationType = "sale_return"
 )
 
 // Notification representa una notificación del sistema
@@ -20,47 +24,123 @@ type Notification struct {
 	Message     string           `json:"message"`
 	Timestamp   time.Time        `json:"timestamp"`
 	EntityID    string           `json:"entity_id"`
-	Amount      float64          `json:"amount,omitempty"`
+	Amount      *Money           `json:"amount,omitempty"`
 	StockLevel  int              `json:"stock_level,omitempty"`
+	Threshold   *int             `json:"threshold,omitempty"`
+	WarehouseID int              `json:"warehouse_id,omitempty"`
+	LotID       int              `json:"lot_id,omitempty"`
+	LotNumber   string           `json:"lot_number,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
 	Provider    string           `json:"provider,omitempty"`
+	SaleID      int              `json:"sale_id,omitempty"`
 	ProductsURL string           `json:"products_url,omitempty"`
 }
 
-// NewLowStockNotification crea una nueva notificación de stock bajo
-func NewLowStockNotification(productID string, stockLevel int) *Notification {
+// NewLowStockNotification crea una nueva notificación de stock bajo de un
+// producto en un almacén, con el stock mínimo del producto como umbral
+func NewLowStockNotification(productID string, warehouseID int, stockLevel int, threshold int) *Notification {
 	return &Notification{
-		Type:       LowStockNotification,
-		Message:    "Alerta: Stock bajo",
-		Timestamp:  time.Now(),
-		EntityID:   productID,
-		StockLevel: stockLevel,
+		Type:        LowStockNotification,
+		Message:     "Alerta: Stock bajo",
+		Timestamp:   time.Now(),
+		EntityID:    productID,
+		StockLevel:  stockLevel,
+		Threshold:   &threshold,
+		WarehouseID: warehouseID,
+	}
+}
+
+// NewRestockNotification crea una nueva notificación de producto reabastecido en un almacén
+func NewRestockNotification(productID string, warehouseID int, stockLevel int, threshold int) *Notification {
+	return &Notification{
+		Type:        RestockNotification,
+		Message:     "Producto reabastecido",
+		Timestamp:   time.Now(),
+		EntityID:    productID,
+		StockLevel:  stockLevel,
+		Threshold:   &threshold,
+		WarehouseID: warehouseID,
 	}
 }
 
 // OrderNotification crea una nueva notificación de creación de orden
-func OrderNotification(orderID string, amount float64, productsURL string) *Notification {
+func OrderNotification(orderID string, amount Money, productsURL string) *Notification {
 	return &Notification{
 		Type:        NewOrderNotification,
 		Message:     "Nueva orden creada",
 		Timestamp:   time.Now(),
 		EntityID:    orderID,
-		Amount:      amount,
+		Amount:      &amount,
 		ProductsURL: productsURL,
 	}
 }
 
 // NewCancelOrderNotification crea una nueva notificación de cancelación de orden
-func NewCancelOrderNotification(orderID string, amount float64, provider string) *Notification {
+func NewCancelOrderNotification(orderID string, amount Money, provider string) *Notification {
 	return &Notification{
 		Type:      CancelOrderNotification,
 		Message:   "Orden cancelada",
 		Timestamp: time.Now(),
 		EntityID:  orderID,
-		Amount:    amount,
+		Amount:    &amount,
 		Provider:  provider,
 	}
 }
 
+// NewDraftOrderNotification crea una notificación de orden de compra en
+// borrador generada o ampliada por el reabastecimiento automático
+func NewDraftOrderNotification(orderID string, amount Money, provider string, productsURL string, created bool) *Notification {
+	message := "Borrador de orden de compra actualizado"
+	if created {
+		message = "Borrador de orden de compra generado"
+	}
+
+	return &Notification{
+		Type:        DraftOrderNotification,
+		Message:     message,
+		Timestamp:   time.Now(),
+		EntityID:    orderID,
+		Amount:      &amount,
+		Provider:    provider,
+		ProductsURL: productsURL,
+	}
+}
+
+// NewExpiringStockNotification crea una notificación de un lote con
+// existencia que está por caducar o ya caducó
+func NewExpiringStockNotification(productID string, warehouseID int, lotID int, lotNumber string, expiresAt time.Time, quantity int, expired bool) *Notification {
+	message := "Alerta: Lote por caducar"
+	if expired {
+		message = "Alerta: Lote caducado"
+	}
+
+	return &Notification{
+		Type:        ExpiringStockNotification,
+		Message:     message,
+		Timestamp:   time.Now(),
+		EntityID:    productID,
+		StockLevel:  quantity,
+		WarehouseID: warehouseID,
+		LotID:       lotID,
+		LotNumber:   lotNumber,
+		ExpiresAt:   &expiresAt,
+	}
+}
+
+// NewSaleReturnNotification crea una notificación de devolución de una venta
+// con el monto a reembolsar
+func NewSaleReturnNotification(returnID string, saleID int, refund Money, productsURL string) *Notification {
+	return &Notification{
+		Type:        SaleReturnNotification,
+		Message:     "Devolución de venta registrada",
+		Timestamp:   time.Now(),
+		EntityID:    returnID,
+		Amount:      &refund,
+		SaleID:      saleID,
+		ProductsURL: productsURL,
+	}
+}
+
 // ToJSON convierte la notificación a JSON
 func (n *Notification) ToJSON() ([]byte, error) {
 	return json.Marshal(n)
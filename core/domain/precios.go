@@ -0,0 +1,323 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDescuentoInvalido indica que un descuento o una tasa no son válidos
+var ErrDescuentoInvalido = errors.New("descuento inválido")
+
+// Porcentaje es un porcentaje exacto con dos decimales, guardado como
+// centésimas de punto porcentual (16.00% = 1600). En JSON se codifica como
+// número decimal (16.00) y en MySQL se guarda en columnas DECIMAL(5,2).
+type Porcentaje int64
+
+// escalaPorcentaje es el número de centésimas de punto en el cien por ciento
+const escalaPorcentaje = 100 * escalaMoneda
+
+// TasaIVAPredeterminada es la tasa de IVA de los productos que no tienen una
+// tasa propia ni la heredan de su categoría
+const TasaIVAPredeterminada Porcentaje = 1600
+
+// ParsePorcentaje interpreta un texto decimal como "16" o "7.5"
+func ParsePorcentaje(texto string) (Porcentaje, error) {
+	monto, err := ParseMoney(texto)
+	if err != nil {
+		return 0, fmt.Errorf("%w: porcentaje %q", ErrDescuentoInvalido, strings.TrimSpace(texto))
+	}
+	return Porcentaje(monto.Centavos), nil
+}
+
+// Validar verifica que el porcentaje esté entre 0 y 100
+func (p Porcentaje) Validar() error {
+	if p < 0 || p > escalaPorcentaje {
+		return fmt.Errorf("%w: el porcentaje %s debe estar entre 0 y 100", ErrDescuentoInvalido, p)
+	}
+	return nil
+}
+
+// Aplicar retorna el porcentaje de un monto redondeado al centavo, con los
+// medios centavos alejándose de cero
+func (p Porcentaje) Aplicar(monto Money) Money {
+	return Money{Centavos: dividirRedondeando(monto.Centavos*int64(p), escalaPorcentaje), Moneda: monto.moneda()}
+}
+
+// String retorna el porcentaje como decimal con dos decimales, sin el signo %
+func (p Porcentaje) String() string {
+	return NewMoney(int64(p)).String()
+}
+
+// MarshalJSON codifica el porcentaje como número decimal exacto
+func (p Porcentaje) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalJSON acepta un número o un texto decimal
+func (p *Porcentaje) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	texto := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &texto); err != nil {
+			return err
+		}
+	}
+
+	porcentaje, err := ParsePorcentaje(texto)
+	if err != nil {
+		return err
+	}
+	*p = porcentaje
+	return nil
+}
+
+// Scan implementa sql.Scanner para leer columnas DECIMAL
+func (p *Porcentaje) Scan(valor interface{}) error {
+	var monto Money
+	if err := monto.Scan(valor); err != nil {
+		return err
+	}
+	*p = Porcentaje(monto.Centavos)
+	return nil
+}
+
+// Value implementa driver.Valuer; el texto decimal se guarda sin pérdida en DECIMAL
+func (p Porcentaje) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// Descuento es un descuento sobre una línea o sobre un documento: un
+// porcentaje o un monto fijo, no ambos
+type Descuento struct {
+	Porcentaje Porcentaje `json:"porcentaje,omitempty"`
+	Monto      Money      `json:"monto,omitempty"`
+}
+
+// Validar verifica que el descuento tenga un porcentaje válido o un monto no
+// negativo, pero no ambos
+func (d *Descuento) Validar() error {
+	if d == nil {
+		return nil
+	}
+	if d.Porcentaje != 0 && !d.Monto.IsZero() {
+		return fmt.Errorf("%w: indique porcentaje o monto, no ambos", ErrDescuentoInvalido)
+	}
+	if d.Monto.IsNegative() {
+		return fmt.Errorf("%w: el monto no puede ser negativo", ErrDescuentoInvalido)
+	}
+	return d.Porcentaje.Validar()
+}
+
+// Vacio indica si el descuento no descuenta nada; un descuento vacío se trata
+// como si no se hubiera indicado
+func (d *Descuento) Vacio() bool {
+	return d == nil || (d.Porcentaje == 0 && d.Monto.IsZero())
+}
+
+// Igual indica si dos descuentos descuentan lo mismo; dos descuentos vacíos
+// son iguales
+func (d *Descuento) Igual(otro *Descuento) bool {
+	if d.Vacio() || otro.Vacio() {
+		return d.Vacio() && otro.Vacio()
+	}
+	return d.Porcentaje == otro.Porcentaje && d.Monto.Cmp(otro.Monto) == 0
+}
+
+// Aplicar retorna el descuento sobre una base, sin exceder la base
+func (d *Descuento) Aplicar(base Money) Money {
+	if d == nil || base.Centavos <= 0 {
+		return NewMoney(0)
+	}
+	descuento := d.Monto
+	if d.Porcentaje != 0 {
+		descuento = d.Porcentaje.Aplicar(base)
+	}
+	if descuento.Cmp(base) > 0 {
+		return base
+	}
+	return Money{Centavos: descuento.Centavos, Moneda: base.moneda()}
+}
+
+// ImportesLinea son los importes calculados de una línea de venta o de
+// pedido. Descuento incluye el descuento de la línea, el de la promoción
+// aplicada y la parte que le toca del descuento del documento. Los precios no
+// incluyen IVA: el impuesto se calcula sobre el subtotal menos el descuento.
+type ImportesLinea struct {
+	DescuentoLinea *Descuento `json:"descuento_linea,omitempty"`
+	PromocionID    *int       `json:"id_promocion,omitempty"`
+	Descuento      Money      `json:"descuento"`
+	TasaIVA        Porcentaje `json:"tasa_iva"`
+	Impuesto       Money      `json:"impuesto"`
+	Total          Money      `json:"total"`
+}
+
+// ParteDe retorna los importes de cantidad unidades de una línea de total
+// unidades, cuando previas unidades ya se tomaron en partes anteriores (por
+// ejemplo, facturaciones o devoluciones parciales). subtotal es el subtotal
+// de la parte. Los descuentos y el impuesto se prorratean de forma acumulada,
+// de modo que las partes de una línea suman exactamente sus importes.
+func (i ImportesLinea) ParteDe(subtotal Money, previas, cantidad, total int) ImportesLinea {
+	acumulado := func(monto Money) Money {
+		return monto.Proporcion(previas+cantidad, total).Sub(monto.Proporcion(previas, total))
+	}
+
+	parte := ImportesLinea{
+		DescuentoLinea: i.DescuentoLinea,
+		PromocionID:    i.PromocionID,
+		Descuento:      acumulado(i.Descuento),
+		TasaIVA:        i.TasaIVA,
+		Impuesto:       acumulado(i.Impuesto),
+	}
+	if i.DescuentoLinea != nil && !i.DescuentoLinea.Monto.IsZero() {
+		parte.DescuentoLinea = &Descuento{Monto: acumulado(i.DescuentoLinea.Monto)}
+	}
+	parte.Total = subtotal.Sub(parte.Descuento).Add(parte.Impuesto)
+	return parte
+}
+
+// ImportesDocumento son los importes calculados de una venta o de un pedido.
+// El total del documento es Subtotal - Descuento + Impuesto.
+type ImportesDocumento struct {
+	Subtotal           Money      `json:"subtotal"`
+	DescuentoDocumento *Descuento `json:"descuento_documento,omitempty"`
+	Descuento          Money      `json:"descuento"`
+	Impuesto           Money      `json:"impuesto"`
+}
+
+// LineaCalculo es una línea de documento como entrada del cálculo de importes
+type LineaCalculo struct {
+	ProductoID     int
+	CategoriaIDs   []int // categoría del producto y sus ancestros
+	Cantidad       int
+	PrecioUnitario Money
+	TasaIVA        Porcentaje
+	Descuento      *Descuento
+}
+
+// CalcularImportes calcula los importes de las líneas de un documento y los
+// del documento completo. El orden es: descuento de cada línea; la promoción
+// vigente que más descuente a cada línea (las promociones no se acumulan);
+// el descuento del documento, repartido entre las líneas en proporción a lo
+// que queda de cada una; y el IVA de cada línea sobre su importe neto.
+// Retorna los importes por línea en el orden recibido y el total del documento.
+func CalcularImportes(lineas []LineaCalculo, promociones []*Promocion, descuentoDocumento *Descuento) ([]ImportesLinea, ImportesDocumento, Money) {
+	importes := make([]ImportesLinea, len(lineas))
+	subtotales := make([]Money, len(lineas))
+	netos := make([]Money, len(lineas))
+
+	for i, linea := range lineas {
+		subtotales[i] = linea.PrecioUnitario.Mul(linea.Cantidad)
+		importes[i].DescuentoLinea = linea.Descuento
+		importes[i].TasaIVA = linea.TasaIVA
+		importes[i].Descuento = linea.Descuento.Aplicar(subtotales[i])
+		netos[i] = subtotales[i].Sub(importes[i].Descuento)
+	}
+
+	// Cada línea toma la promoción que más le descuente
+	mejores := make([]Money, len(lineas))
+	for _, promocion := range promociones {
+		for i, descuento := range promocion.DescuentosPorLinea(lineas, netos) {
+			if descuento.Centavos > mejores[i].Centavos {
+				id := promocion.ID
+				importes[i].PromocionID = &id
+				mejores[i] = descuento
+			}
+		}
+	}
+	for i := range lineas {
+		if importes[i].PromocionID != nil {
+			importes[i].Descuento = importes[i].Descuento.Add(mejores[i])
+			netos[i] = netos[i].Sub(mejores[i])
+		}
+	}
+
+	// Repartir el descuento del documento en proporción al neto de cada línea
+	base := NewMoney(0)
+	for _, neto := range netos {
+		base = base.Add(neto)
+	}
+	for i, parte := range repartir(descuentoDocumento.Aplicar(base), netos) {
+		importes[i].Descuento = importes[i].Descuento.Add(parte)
+		netos[i] = netos[i].Sub(parte)
+	}
+
+	for i := range lineas {
+		importes[i].Impuesto = importes[i].TasaIVA.Aplicar(netos[i])
+		importes[i].Total = netos[i].Add(importes[i].Impuesto)
+	}
+
+	documento, total := TotalizarImportes(subtotales, importes, descuentoDocumento)
+	return importes, documento, total
+}
+
+// TotalizarImportes suma los importes ya calculados de las líneas de un
+// documento y retorna los importes del documento y su total
+func TotalizarImportes(subtotales []Money, importes []ImportesLinea, descuentoDocumento *Descuento) (ImportesDocumento, Money) {
+	documento := ImportesDocumento{
+		DescuentoDocumento: descuentoDocumento,
+		Subtotal:           NewMoney(0),
+		Descuento:          NewMoney(0),
+		Impuesto:           NewMoney(0),
+	}
+	for i := range importes {
+		documento.Subtotal = documento.Subtotal.Add(subtotales[i])
+		documento.Descuento = documento.Descuento.Add(importes[i].Descuento)
+		documento.Impuesto = documento.Impuesto.Add(importes[i].Impuesto)
+	}
+
+	total := documento.Subtotal.Sub(documento.Descuento).Add(documento.Impuesto)
+	return documento, total
+}
+
+// repartir divide un monto entre varias bases en proporción a cada una. Los
+// centavos que sobran del redondeo hacia abajo se asignan a las bases con
+// mayor residuo, de modo que las partes suman exactamente el monto.
+func repartir(monto Money, bases []Money) []Money {
+	partes := make([]Money, len(bases))
+	for i := range partes {
+		partes[i] = NewMoney(0)
+	}
+
+	var total int64
+	for _, base := range bases {
+		if base.Centavos > 0 {
+			total += base.Centavos
+		}
+	}
+	if total == 0 || monto.Centavos <= 0 {
+		return partes
+	}
+
+	residuos := make([]int, 0, len(bases))
+	asignado := int64(0)
+	resto := make([]int64, len(bases))
+	for i, base := range bases {
+		if base.Centavos <= 0 {
+			continue
+		}
+		producto := monto.Centavos * base.Centavos
+		partes[i] = NewMoney(producto / total)
+		resto[i] = producto % total
+		asignado += partes[i].Centavos
+		residuos = append(residuos, i)
+	}
+
+	sort.SliceStable(residuos, func(a, b int) bool {
+		return resto[residuos[a]] > resto[residuos[b]]
+	})
+	for k := int64(0); k < monto.Centavos-asignado; k++ {
+		i := residuos[k%int64(len(residuos))]
+		partes[i] = partes[i].Add(NewMoney(1))
+	}
+
+	return partes
+}
@@ -0,0 +1,321 @@
+package domain
+
+import (
+	"testing"
+)
+
+// pesos interpreta un monto fijo de una prueba
+func pesos(texto string) Money {
+	monto, err := ParseMoney(texto)
+	if err != nil {
+		panic(err)
+	}
+	return monto
+}
+
+func TestPorcentajeAplicar(t *testing.T) {
+	casos := []struct {
+		porcentaje Porcentaje
+		monto      string
+		resultado  string
+	}{
+		{porcentaje: 1600, monto: "100.00", resultado: "16.00"},
+		{porcentaje: 1600, monto: "0.03", resultado: "0.00"},   // 0.0048
+		{porcentaje: 1600, monto: "0.04", resultado: "0.01"},   // 0.0064
+		{porcentaje: 5000, monto: "0.01", resultado: "0.01"},   // medio centavo se aleja de cero
+		{porcentaje: 5000, monto: "-0.01", resultado: "-0.01"}, // también en negativos
+		{porcentaje: 1250, monto: "0.99", resultado: "0.12"},   // 0.12375
+		{porcentaje: 750, monto: "19.99", resultado: "1.50"},   // 1.49925
+		{porcentaje: 0, monto: "19.99", resultado: "0.00"},
+		{porcentaje: 10000, monto: "19.99", resultado: "19.99"},
+	}
+
+	for _, caso := range casos {
+		if resultado := caso.porcentaje.Aplicar(pesos(caso.monto)).String(); resultado != caso.resultado {
+			t.Errorf("%s%% de %s = %s, se esperaba %s", caso.porcentaje, caso.monto, resultado, caso.resultado)
+		}
+	}
+}
+
+func TestDescuentoAplicar(t *testing.T) {
+	casos := []struct {
+		nombre    string
+		descuento *Descuento
+		base      string
+		resultado string
+	}{
+		{nombre: "sin descuento", descuento: nil, base: "100.00", resultado: "0.00"},
+		{nombre: "porcentaje", descuento: &Descuento{Porcentaje: 1000}, base: "27.35", resultado: "2.74"},
+		{nombre: "monto fijo", descuento: &Descuento{Monto: pesos("5.00")}, base: "27.35", resultado: "5.00"},
+		{nombre: "monto mayor que la base", descuento: &Descuento{Monto: pesos("50.00")}, base: "27.35", resultado: "27.35"},
+		{nombre: "base cero", descuento: &Descuento{Monto: pesos("5.00")}, base: "0.00", resultado: "0.00"},
+		{nombre: "base negativa", descuento: &Descuento{Porcentaje: 1000}, base: "-10.00", resultado: "0.00"},
+	}
+
+	for _, caso := range casos {
+		if resultado := caso.descuento.Aplicar(pesos(caso.base)).String(); resultado != caso.resultado {
+			t.Errorf("%s: descuento sobre %s = %s, se esperaba %s", caso.nombre, caso.base, resultado, caso.resultado)
+		}
+	}
+}
+
+func TestDescuentoValidar(t *testing.T) {
+	casos := []struct {
+		nombre    string
+		descuento *Descuento
+		valido    bool
+	}{
+		{nombre: "nil", descuento: nil, valido: true},
+		{nombre: "porcentaje", descuento: &Descuento{Porcentaje: 1500}, valido: true},
+		{nombre: "cien por ciento", descuento: &Descuento{Porcentaje: 10000}, valido: true},
+		{nombre: "monto", descuento: &Descuento{Monto: pesos("10.00")}, valido: true},
+		{nombre: "porcentaje y monto", descuento: &Descuento{Porcentaje: 1000, Monto: pesos("1.00")}},
+		{nombre: "monto negativo", descuento: &Descuento{Monto: pesos("-1.00")}},
+		{nombre: "más de cien por ciento", descuento: &Descuento{Porcentaje: 10001}},
+		{nombre: "porcentaje negativo", descuento: &Descuento{Porcentaje: -1}},
+	}
+
+	for _, caso := range casos {
+		err := caso.descuento.Validar()
+		if caso.valido && err != nil {
+			t.Errorf("%s: error inesperado: %v", caso.nombre, err)
+		}
+		if !caso.valido && err == nil {
+			t.Errorf("%s: se esperaba un error", caso.nombre)
+		}
+	}
+}
+
+func TestRepartir(t *testing.T) {
+	casos := []struct {
+		nombre string
+		monto  string
+		bases  []string
+		partes []string
+	}{
+		{
+			nombre: "proporcional exacto",
+			monto:  "3.00",
+			bases:  []string{"10.00", "20.00"},
+			partes: []string{"1.00", "2.00"},
+		},
+		{
+			nombre: "el centavo sobrante va al mayor residuo",
+			monto:  "1.00",
+			bases:  []string{"10.00", "20.00"},
+			partes: []string{"0.33", "0.67"},
+		},
+		{
+			nombre: "empates de residuo en orden",
+			monto:  "1.00",
+			bases:  []string{"1.00", "1.00", "1.00"},
+			partes: []string{"0.34", "0.33", "0.33"},
+		},
+		{
+			nombre: "varios centavos sobrantes",
+			monto:  "0.05",
+			bases:  []string{"1.00", "1.00", "1.00"},
+			partes: []string{"0.02", "0.02", "0.01"},
+		},
+		{
+			nombre: "bases sin saldo no reciben nada",
+			monto:  "1.00",
+			bases:  []string{"0.00", "3.00", "-2.00"},
+			partes: []string{"0.00", "1.00", "0.00"},
+		},
+		{
+			nombre: "monto cero",
+			monto:  "0.00",
+			bases:  []string{"1.00", "2.00"},
+			partes: []string{"0.00", "0.00"},
+		},
+		{
+			nombre: "sin bases",
+			monto:  "1.00",
+			bases:  []string{"0.00"},
+			partes: []string{"0.00"},
+		},
+	}
+
+	for _, caso := range casos {
+		bases := make([]Money, len(caso.bases))
+		for i, base := range caso.bases {
+			bases[i] = pesos(base)
+		}
+
+		partes := repartir(pesos(caso.monto), bases)
+		for i, parte := range partes {
+			if parte.String() != caso.partes[i] {
+				t.Errorf("%s: parte %d = %s, se esperaba %s", caso.nombre, i, parte, caso.partes[i])
+			}
+		}
+	}
+}
+
+func TestCalcularImportes(t *testing.T) {
+	ptr := func(id int) *int { return &id }
+
+	casos := []struct {
+		nombre             string
+		lineas             []LineaCalculo
+		promociones        []*Promocion
+		descuentoDocumento *Descuento
+		descuentos         []string
+		impuestos          []string
+		promocionIDs       []int // 0 si la línea no tiene promoción
+		documento          ImportesDocumento
+		total              string
+	}{
+		{
+			nombre: "sin descuentos",
+			lineas: []LineaCalculo{
+				{ProductoID: 1, Cantidad: 2, PrecioUnitario: pesos("100.00"), TasaIVA: 1600},
+				{ProductoID: 2, Cantidad: 1, PrecioUnitario: pesos("50.00"), TasaIVA: 0},
+			},
+			descuentos:   []string{"0.00", "0.00"},
+			impuestos:    []string{"32.00", "0.00"},
+			promocionIDs: []int{0, 0},
+			documento:    ImportesDocumento{Subtotal: pesos("250.00"), Descuento: pesos("0.00"), Impuesto: pesos("32.00")},
+			total:        "282.00",
+		},
+		{
+			nombre: "la promoción se aplica sobre el neto del descuento de línea",
+			lineas: []LineaCalculo{
+				{ProductoID: 1, CategoriaIDs: []int{10}, Cantidad: 1, PrecioUnitario: pesos("100.00"), TasaIVA: 1600,
+					Descuento: &Descuento{Porcentaje: 1000}},
+			},
+			promociones: []*Promocion{
+				{ID: 7, Tipo: PromocionPorcentajeCategoria, CategoriaID: ptr(10), Porcentaje: 2000},
+			},
+			// 10.00 de línea + 20% de 90.00
+			descuentos:   []string{"28.00"},
+			impuestos:    []string{"11.52"},
+			promocionIDs: []int{7},
+			documento:    ImportesDocumento{Subtotal: pesos("100.00"), Descuento: pesos("28.00"), Impuesto: pesos("11.52")},
+			total:        "83.52",
+		},
+		{
+			nombre: "gana la promoción que más descuenta y no se acumulan",
+			lineas: []LineaCalculo{
+				{ProductoID: 1, CategoriaIDs: []int{10}, Cantidad: 3, PrecioUnitario: pesos("10.00"), TasaIVA: 1600,
+					Descuento: &Descuento{Porcentaje: 1000}},
+			},
+			promociones: []*Promocion{
+				{ID: 1, Tipo: PromocionPorcentajeCategoria, CategoriaID: ptr(10), Porcentaje: 2000},
+				{ID: 2, Tipo: PromocionNxM, ProductoID: ptr(1), Lleva: 3, Paga: 2},
+			},
+			// 3.00 de línea + una unidad gratis (10.00) en lugar del 20% de 27.00 (5.40)
+			descuentos:   []string{"13.00"},
+			impuestos:    []string{"2.72"},
+			promocionIDs: []int{2},
+			documento:    ImportesDocumento{Subtotal: pesos("30.00"), Descuento: pesos("13.00"), Impuesto: pesos("2.72")},
+			total:        "19.72",
+		},
+		{
+			nombre: "la unidad gratis no excede el neto de la línea",
+			lineas: []LineaCalculo{
+				{ProductoID: 1, Cantidad: 3, PrecioUnitario: pesos("10.00"), TasaIVA: 0,
+					Descuento: &Descuento{Monto: pesos("25.00")}},
+			},
+			promociones: []*Promocion{
+				{ID: 2, Tipo: PromocionNxM, ProductoID: ptr(1), Lleva: 3, Paga: 2},
+			},
+			descuentos:   []string{"30.00"},
+			impuestos:    []string{"0.00"},
+			promocionIDs: []int{2},
+			documento:    ImportesDocumento{Subtotal: pesos("30.00"), Descuento: pesos("30.00"), Impuesto: pesos("0.00")},
+			total:        "0.00",
+		},
+		{
+			nombre: "el descuento del documento se reparte y el IVA se calcula después",
+			lineas: []LineaCalculo{
+				{ProductoID: 1, Cantidad: 1, PrecioUnitario: pesos("10.00"), TasaIVA: 1600},
+				{ProductoID: 2, Cantidad: 1, PrecioUnitario: pesos("20.00"), TasaIVA: 0},
+			},
+			descuentoDocumento: &Descuento{Monto: pesos("1.00")},
+			// 0.33 y 0.67 del descuento; IVA sobre 9.67
+			descuentos:   []string{"0.33", "0.67"},
+			impuestos:    []string{"1.55", "0.00"},
+			promocionIDs: []int{0, 0},
+			documento:    ImportesDocumento{Subtotal: pesos("30.00"), Descuento: pesos("1.00"), Impuesto: pesos("1.55")},
+			total:        "30.55",
+		},
+		{
+			nombre: "descuento porcentual del documento después de la promoción",
+			lineas: []LineaCalculo{
+				{ProductoID: 1, CategoriaIDs: []int{10}, Cantidad: 2, PrecioUnitario: pesos("50.00"), TasaIVA: 1600},
+			},
+			promociones: []*Promocion{
+				{ID: 3, Tipo: PromocionPorcentajeCategoria, CategoriaID: ptr(10), Porcentaje: 1000},
+			},
+			descuentoDocumento: &Descuento{Porcentaje: 500},
+			// 10.00 de promoción + 5% de 90.00
+			descuentos:   []string{"14.50"},
+			impuestos:    []string{"13.68"},
+			promocionIDs: []int{3},
+			documento:    ImportesDocumento{Subtotal: pesos("100.00"), Descuento: pesos("14.50"), Impuesto: pesos("13.68")},
+			total:        "99.18",
+		},
+	}
+
+	for _, caso := range casos {
+		importes, documento, total := CalcularImportes(caso.lineas, caso.promociones, caso.descuentoDocumento)
+
+		for i, importe := range importes {
+			if importe.Descuento.String() != caso.descuentos[i] {
+				t.Errorf("%s: descuento de la línea %d = %s, se esperaba %s", caso.nombre, i, importe.Descuento, caso.descuentos[i])
+			}
+			if importe.Impuesto.String() != caso.impuestos[i] {
+				t.Errorf("%s: impuesto de la línea %d = %s, se esperaba %s", caso.nombre, i, importe.Impuesto, caso.impuestos[i])
+			}
+
+			promocionID := 0
+			if importe.PromocionID != nil {
+				promocionID = *importe.PromocionID
+			}
+			if promocionID != caso.promocionIDs[i] {
+				t.Errorf("%s: promoción de la línea %d = %d, se esperaba %d", caso.nombre, i, promocionID, caso.promocionIDs[i])
+			}
+		}
+
+		if documento.Subtotal.Cmp(caso.documento.Subtotal) != 0 || documento.Descuento.Cmp(caso.documento.Descuento) != 0 ||
+			documento.Impuesto.Cmp(caso.documento.Impuesto) != 0 {
+			t.Errorf("%s: documento = %s - %s + %s, se esperaba %s - %s + %s", caso.nombre,
+				documento.Subtotal, documento.Descuento, documento.Impuesto,
+				caso.documento.Subtotal, caso.documento.Descuento, caso.documento.Impuesto)
+		}
+		if total.String() != caso.total {
+			t.Errorf("%s: total = %s, se esperaba %s", caso.nombre, total, caso.total)
+		}
+	}
+}
+
+func TestImportesLineaParteDe(t *testing.T) {
+	// Una línea de 3 unidades de 10.00 con 1.00 de descuento e IVA de 4.64
+	// facturada de una unidad en una unidad
+	linea := ImportesLinea{
+		DescuentoLinea: &Descuento{Monto: pesos("1.00")},
+		Descuento:      pesos("1.00"),
+		TasaIVA:        1600,
+		Impuesto:       pesos("4.64"),
+		Total:          pesos("33.64"),
+	}
+	precio := pesos("10.00")
+
+	descuento, impuesto, total := NewMoney(0), NewMoney(0), NewMoney(0)
+	for previas := 0; previas < 3; previas++ {
+		parte := linea.ParteDe(precio.Mul(1), previas, 1, 3)
+		descuento = descuento.Add(parte.Descuento)
+		impuesto = impuesto.Add(parte.Impuesto)
+		total = total.Add(parte.Total)
+
+		if parte.Total.Cmp(precio.Sub(parte.Descuento).Add(parte.Impuesto)) != 0 {
+			t.Errorf("parte %d: total %s no cuadra con sus importes", previas, parte.Total)
+		}
+	}
+
+	if descuento.Cmp(linea.Descuento) != 0 || impuesto.Cmp(linea.Impuesto) != 0 || total.Cmp(linea.Total) != 0 {
+		t.Errorf("las partes suman descuento %s, impuesto %s y total %s; se esperaba %s, %s y %s",
+			descuento, impuesto, total, linea.Descuento, linea.Impuesto, linea.Total)
+	}
+}
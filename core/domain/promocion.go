@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// TipoPromocion es la regla con la que una promoción descuenta
+type TipoPromocion string
+
+const (
+	// PromocionNxM regala unidades: por cada Lleva unidades de un producto se
+	// pagan Paga (por ejemplo 3x2)
+	PromocionNxM TipoPromocion = "nxm"
+	// PromocionPorcentajeCategoria descuenta un porcentaje a todos los
+	// productos de una categoría y sus subcategorías
+	PromocionPorcentajeCategoria TipoPromocion = "porcentaje_categoria"
+)
+
+// Promocion es una regla de descuento automática que se aplica a las líneas
+// de ventas y pedidos mientras está activa y dentro de su vigencia. Una
+// promoción NxM aplica a un producto o a los productos de una categoría.
+type Promocion struct {
+	ID          int           `json:"id_promocion"`
+	Nombre      string        `json:"nombre"`
+	Tipo        TipoPromocion `json:"tipo"`
+	ProductoID  *int          `json:"id_producto,omitempty"`
+	CategoriaID *int          `json:"id_categoria,omitempty"`
+	Lleva       int           `json:"lleva,omitempty"`
+	Paga        int           `json:"paga,omitempty"`
+	Porcentaje  Porcentaje    `json:"porcentaje,omitempty"`
+	Activa      bool          `json:"activa"`
+	FechaInicio *time.Time    `json:"fecha_inicio,omitempty"`
+	FechaFin    *time.Time    `json:"fecha_fin,omitempty"`
+}
+
+// Validar verifica que la promoción tenga los datos que exige su tipo
+func (p *Promocion) Validar() error {
+	switch p.Tipo {
+	case PromocionNxM:
+		if p.ProductoID == nil && p.CategoriaID == nil {
+			return fmt.Errorf("%w: una promoción nxm requiere id_producto o id_categoria", ErrDescuentoInvalido)
+		}
+		if p.Paga < 1 || p.Lleva <= p.Paga {
+			return fmt.Errorf("%w: una promoción nxm requiere lleva > paga >= 1", ErrDescuentoInvalido)
+		}
+	case PromocionPorcentajeCategoria:
+		if p.CategoriaID == nil {
+			return fmt.Errorf("%w: una promoción porcentaje_categoria requiere id_categoria", ErrDescuentoInvalido)
+		}
+		if p.Porcentaje <= 0 {
+			return fmt.Errorf("%w: el porcentaje debe ser mayor a cero", ErrDescuentoInvalido)
+		}
+		if err := p.Porcentaje.Validar(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: tipo de promoción inválido %q (use nxm o porcentaje_categoria)", ErrDescuentoInvalido, p.Tipo)
+	}
+
+	if p.FechaInicio != nil && p.FechaFin != nil && p.FechaFin.Before(*p.FechaInicio) {
+		return fmt.Errorf("%w: la fecha de fin es anterior a la de inicio", ErrDescuentoInvalido)
+	}
+	return nil
+}
+
+// VigenteEn indica si la promoción está activa en la fecha indicada
+func (p *Promocion) VigenteEn(fecha time.Time) bool {
+	if !p.Activa {
+		return false
+	}
+	if p.FechaInicio != nil && fecha.Before(*p.FechaInicio) {
+		return false
+	}
+	return p.FechaFin == nil || fecha.Before(*p.FechaFin)
+}
+
+// aplicaA indica si la promoción cubre la línea
+func (p *Promocion) aplicaA(linea LineaCalculo) bool {
+	if p.ProductoID != nil {
+		return *p.ProductoID == linea.ProductoID
+	}
+	if p.CategoriaID != nil {
+		return slices.Contains(linea.CategoriaIDs, *p.CategoriaID)
+	}
+	return false
+}
+
+// DescuentosPorLinea calcula el descuento que la promoción daría a cada
+// línea, dada la base que le queda a cada una. Las líneas que no cubre
+// reciben cero. En una promoción NxM las unidades regaladas se cuentan por
+// producto sumando todas sus líneas y se reparten entre ellas en orden.
+func (p *Promocion) DescuentosPorLinea(lineas []LineaCalculo, bases []Money) []Money {
+	descuentos := make([]Money, len(lineas))
+	for i := range descuentos {
+		descuentos[i] = NewMoney(0)
+	}
+
+	switch p.Tipo {
+	case PromocionPorcentajeCategoria:
+		for i, linea := range lineas {
+			if p.aplicaA(linea) && bases[i].Centavos > 0 {
+				descuentos[i] = p.Porcentaje.Aplicar(bases[i])
+			}
+		}
+
+	case PromocionNxM:
+		cantidades := make(map[int]int)
+		for _, linea := range lineas {
+			if p.aplicaA(linea) {
+				cantidades[linea.ProductoID] += linea.Cantidad
+			}
+		}
+
+		gratis := make(map[int]int, len(cantidades))
+		for productoID, cantidad := range cantidades {
+			gratis[productoID] = cantidad / p.Lleva * (p.Lleva - p.Paga)
+		}
+
+		for i, linea := range lineas {
+			if !p.aplicaA(linea) || gratis[linea.ProductoID] == 0 || bases[i].Centavos <= 0 {
+				continue
+			}
+			unidades := gratis[linea.ProductoID]
+			if unidades > linea.Cantidad {
+				unidades = linea.Cantidad
+			}
+			gratis[linea.ProductoID] -= unidades
+
+			descuento := linea.PrecioUnitario.Mul(unidades)
+			if descuento.Cmp(bases[i]) > 0 {
+				descuento = bases[i]
+			}
+			descuentos[i] = descuento
+		}
+	}
+
+	return descuentos
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromocionNxMDescuentosPorLinea(t *testing.T) {
+	producto1, categoria := 1, 10
+
+	casos := []struct {
+		nombre     string
+		promocion  Promocion
+		lineas     []LineaCalculo
+		descuentos []string
+	}{
+		{
+			nombre:    "3x2 con tres unidades",
+			promocion: Promocion{Tipo: PromocionNxM, ProductoID: &producto1, Lleva: 3, Paga: 2},
+			lineas: []LineaCalculo{
+				{ProductoID: 1, Cantidad: 3, PrecioUnitario: pesos("10.00")},
+			},
+			descuentos: []string{"10.00"},
+		},
+		{
+			nombre:    "3x2 con unidades insuficientes",
+			promocion: Promocion{Tipo: PromocionNxM, ProductoID: &producto1, Lleva: 3, Paga: 2},
+			lineas: []LineaCalculo{
+				{ProductoID: 1, Cantidad: 2, PrecioUnitario: pesos("10.00")},
+			},
+			descuentos: []string{"0.00"},
+		},
+		{
+			nombre:    "3x2 con siete unidades regala dos",
+			promocion: Promocion{Tipo: PromocionNxM, ProductoID: &producto1, Lleva: 3, Paga: 2},
+			lineas: []LineaCalculo{
+				{ProductoID: 1, Cantidad: 7, PrecioUnitario: pesos("10.00")},
+			},
+			descuentos: []string{"20.00"},
+		},
+		{
+			nombre:    "las unidades de varias líneas del producto se suman y se regalan en orden",
+			promocion: Promocion{Tipo: PromocionNxM, ProductoID: &producto1, Lleva: 2, Paga: 1},
+			lineas: []LineaCalculo{
+				{ProductoID: 1, Cantidad: 1, PrecioUnitario: pesos("10.00")},
+				{ProductoID: 2, Cantidad: 5, PrecioUnitario: pesos("8.00")},
+				{ProductoID: 1, Cantidad: 3, PrecioUnitario: pesos("10.00")},
+			},
+			descuentos: []string{"10.00", "0.00", "10.00"},
+		},
+		{
+			nombre:    "por categoría cuenta cada producto por separado",
+			promocion: Promocion{Tipo: PromocionNxM, CategoriaID: &categoria, Lleva: 2, Paga: 1},
+			lineas: []LineaCalculo{
+				{ProductoID: 1, CategoriaIDs: []int{10}, Cantidad: 1, PrecioUnitario: pesos("10.00")},
+				{ProductoID: 2, CategoriaIDs: []int{20, 10}, Cantidad: 1, PrecioUnitario: pesos("8.00")},
+				{ProductoID: 3, CategoriaIDs: []int{10}, Cantidad: 2, PrecioUnitario: pesos("5.00")},
+			},
+			descuentos: []string{"0.00", "0.00", "5.00"},
+		},
+	}
+
+	for _, caso := range casos {
+		bases := make([]Money, len(caso.lineas))
+		for i, linea := range caso.lineas {
+			bases[i] = linea.PrecioUnitario.Mul(linea.Cantidad)
+		}
+
+		descuentos := caso.promocion.DescuentosPorLinea(caso.lineas, bases)
+		for i, descuento := range descuentos {
+			if descuento.String() != caso.descuentos[i] {
+				t.Errorf("%s: descuento de la línea %d = %s, se esperaba %s", caso.nombre, i, descuento, caso.descuentos[i])
+			}
+		}
+	}
+}
+
+func TestPromocionPorcentajeCategoriaDescuentosPorLinea(t *testing.T) {
+	categoria := 10
+	promocion := Promocion{Tipo: PromocionPorcentajeCategoria, CategoriaID: &categoria, Porcentaje: 1500}
+
+	lineas := []LineaCalculo{
+		{ProductoID: 1, CategoriaIDs: []int{10}},
+		{ProductoID: 2, CategoriaIDs: []int{11, 10}}, // subcategoría
+		{ProductoID: 3, CategoriaIDs: []int{20}},
+		{ProductoID: 4, CategoriaIDs: []int{10}},
+	}
+	bases := []Money{pesos("19.99"), pesos("100.00"), pesos("100.00"), pesos("0.00")}
+	esperados := []string{"3.00", "15.00", "0.00", "0.00"}
+
+	for i, descuento := range promocion.DescuentosPorLinea(lineas, bases) {
+		if descuento.String() != esperados[i] {
+			t.Errorf("descuento de la línea %d = %s, se esperaba %s", i, descuento, esperados[i])
+		}
+	}
+}
+
+func TestPromocionVigenteEn(t *testing.T) {
+	inicio := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fin := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	casos := []struct {
+		nombre    string
+		promocion Promocion
+		fecha     time.Time
+		vigente   bool
+	}{
+		{nombre: "activa sin fechas", promocion: Promocion{Activa: true}, fecha: inicio, vigente: true},
+		{nombre: "inactiva", promocion: Promocion{Activa: false}, fecha: inicio},
+		{nombre: "en el inicio", promocion: Promocion{Activa: true, FechaInicio: &inicio, FechaFin: &fin}, fecha: inicio, vigente: true},
+		{nombre: "antes del inicio", promocion: Promocion{Activa: true, FechaInicio: &inicio}, fecha: inicio.Add(-time.Second)},
+		{nombre: "antes del fin", promocion: Promocion{Activa: true, FechaFin: &fin}, fecha: fin.Add(-time.Second), vigente: true},
+		{nombre: "en el fin", promocion: Promocion{Activa: true, FechaInicio: &inicio, FechaFin: &fin}, fecha: fin},
+	}
+
+	for _, caso := range casos {
+		if vigente := caso.promocion.VigenteEn(caso.fecha); vigente != caso.vigente {
+			t.Errorf("%s: VigenteEn = %v, se esperaba %v", caso.nombre, vigente, caso.vigente)
+		}
+	}
+}
+
+func TestPromocionValidar(t *testing.T) {
+	producto, categoria := 1, 10
+	inicio := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fin := inicio.Add(-time.Hour)
+
+	casos := []struct {
+		nombre    string
+		promocion Promocion
+		valida    bool
+	}{
+		{nombre: "nxm por producto", promocion: Promocion{Tipo: PromocionNxM, ProductoID: &producto, Lleva: 3, Paga: 2}, valida: true},
+		{nombre: "nxm sin producto ni categoría", promocion: Promocion{Tipo: PromocionNxM, Lleva: 3, Paga: 2}},
+		{nombre: "nxm lleva igual a paga", promocion: Promocion{Tipo: PromocionNxM, ProductoID: &producto, Lleva: 2, Paga: 2}},
+		{nombre: "nxm paga cero", promocion: Promocion{Tipo: PromocionNxM, ProductoID: &producto, Lleva: 2, Paga: 0}},
+		{nombre: "porcentaje", promocion: Promocion{Tipo: PromocionPorcentajeCategoria, CategoriaID: &categoria, Porcentaje: 1000}, valida: true},
+		{nombre: "porcentaje sin categoría", promocion: Promocion{Tipo: PromocionPorcentajeCategoria, Porcentaje: 1000}},
+		{nombre: "porcentaje cero", promocion: Promocion{Tipo: PromocionPorcentajeCategoria, CategoriaID: &categoria}},
+		{nombre: "porcentaje mayor a cien", promocion: Promocion{Tipo: PromocionPorcentajeCategoria, CategoriaID: &categoria, Porcentaje: 10001}},
+		{nombre: "tipo desconocido", promocion: Promocion{Tipo: "2x1"}},
+		{nombre: "fin antes del inicio", promocion: Promocion{Tipo: PromocionNxM, ProductoID: &producto, Lleva: 3, Paga: 2,
+			FechaInicio: &inicio, FechaFin: &fin}},
+	}
+
+	for _, caso := range casos {
+		err := caso.promocion.Validar()
+		if caso.valida && err != nil {
+			t.Errorf("%s: error inesperado: %v", caso.nombre, err)
+		}
+		if !caso.valida && err == nil {
+			t.Errorf("%s: se esperaba un error", caso.nombre)
+		}
+	}
+}
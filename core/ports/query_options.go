@@ -0,0 +1,49 @@
+package ports
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"time"
+)
+
+// Límites de las páginas de los listados
+const (
+	LimitePredeterminado = 50
+	LimiteMaximo         = 500
+)
+
+// DireccionOrden indica si un listado se ordena de forma ascendente o descendente
+type DireccionOrden string
+
+const (
+	OrdenAscendente  DireccionOrden = "asc"
+	OrdenDescendente DireccionOrden = "desc"
+)
+
+// FiltrosConsulta son los filtros tipados de los listados. Cada repositorio
+// aplica los que tienen sentido para su entidad e ignora el resto; los campos
+// vacíos no filtran.
+type FiltrosConsulta struct {
+	Estado       string
+	Desde        *time.Time
+	Hasta        *time.Time
+	ProveedorID  int
+	ClienteID    int
+	AlmacenID    int
+	CategoriaIDs []int
+	PrecioMin    *domain.Money
+	PrecioMax    *domain.Money
+	StockMenorA  *int
+	Eliminados   bool
+}
+
+// QueryOptions indica qué página de un listado obtener, en qué orden y con
+// qué filtros. Un Limite de cero obtiene todos los registros desde Offset.
+// OrdenarPor es un campo de la API, por ejemplo "fecha" o "precio"; vacío usa
+// el orden predeterminado del listado.
+type QueryOptions struct {
+	Limite     int
+	Offset     int
+	OrdenarPor string
+	Direccion  DireccionOrden
+	Filtros    FiltrosConsulta
+}
@@ -2,32 +2,83 @@ package ports
 
 import (
 	"ActividadDesempenioAPIz/core/domain"
+	"time"
 )
 
 // Interfaces para repositorios
 type ProductoRepository interface {
 	GetByID(id int) (*domain.Producto, error)
-	GetAll() ([]*domain.Producto, error)
+	GetBySKU(sku string) (*domain.Producto, error)
+	GetByCodigoBarras(codigo string) (*domain.Producto, error)
+	GetAll(opciones QueryOptions) ([]*domain.Producto, int, error)
+	GetByCategorias(categoriaIDs []int) ([]*domain.Producto, error)
 	Create(producto *domain.Producto) (int, error)
 	Update(producto *domain.Producto) error
-	UpdateStock(id int, cantidad int) error
+	UpdateCampos(producto *domain.Producto, campos []string) error
+	SetAtributos(id int, atributos map[string]string) error
+	SetCostoPromedio(id int, costo domain.Money) error
+	GetStockBajo(almacenID int, categoriaIDs []int) ([]*domain.Producto, error)
+	GetStockForUpdate(id int, almacenID int) (int, error)
+	IncrementStock(id int, almacenID int, cantidad int) (saldoAlmacen int, saldoTotal int, err error)
+	DecrementStock(id int, almacenID int, cantidad int) (saldoAlmacen int, saldoTotal int, err error)
+	IncrementDanado(id int, almacenID int, cantidad int) error
+	SoftDelete(id int) error
+	Restore(id int) error
+	GetDependencias(id int) ([]domain.Dependencia, error)
+	Delete(id int) error
+}
+
+type CategoriaRepository interface {
+	GetByID(id int) (*domain.Categoria, error)
+	GetAll() ([]*domain.Categoria, error)
+	Create(categoria *domain.Categoria) (int, error)
+	Update(categoria *domain.Categoria) error
+	CountProductos(id int) (int, error)
+	Delete(id int) error
+}
+
+type ClienteRepository interface {
+	GetByID(id int) (*domain.Cliente, error)
+	GetAll(opciones QueryOptions) ([]*domain.Cliente, int, error)
+	Create(cliente *domain.Cliente) (int, error)
+	Update(cliente *domain.Cliente) error
 	Delete(id int) error
 }
 
 type ProveedorRepository interface {
 	GetByID(id int) (*domain.Proveedor, error)
-	GetAll() ([]*domain.Proveedor, error)
+	GetByIDForUpdate(id int) (*domain.Proveedor, error)
+	GetAll(opciones QueryOptions) ([]*domain.Proveedor, int, error)
 	Create(proveedor *domain.Proveedor) (int, error)
 	Update(proveedor *domain.Proveedor) error
+	UpdateCampos(proveedor *domain.Proveedor, campos []string) error
+	SoftDelete(id int) error
+	Restore(id int) error
+	GetDependencias(id int) ([]domain.Dependencia, error)
 	Delete(id int) error
 }
 
+// CatalogoProveedorRepository administra el catálogo de productos de cada proveedor
+type CatalogoProveedorRepository interface {
+	Get(proveedorID, productoID int) (*domain.ProveedorProducto, error)
+	GetByProveedor(proveedorID int) ([]*domain.ProveedorProducto, error)
+	GetByProducto(productoID int) ([]*domain.ProveedorProducto, error)
+	GetPreferido(productoID int) (*domain.ProveedorProducto, error)
+	Upsert(entrada *domain.ProveedorProducto) error
+	QuitarPreferido(productoID, exceptoProveedorID int) error
+	Delete(proveedorID, productoID int) error
+}
+
 type PedidoRepository interface {
 	GetByID(id int) (*domain.Pedido, error)
-	GetAll() ([]*domain.Pedido, error)
+	GetByIDForUpdate(id int) (*domain.Pedido, error)
+	GetAll(opciones QueryOptions) ([]*domain.Pedido, int, error)
+	GetByCliente(clienteID int) ([]*domain.Pedido, error)
 	Create(pedido *domain.Pedido) (int, error)
 	Update(pedido *domain.Pedido) error
-	UpdateEstado(id int, estado string) error
+	UpdateCampos(pedido *domain.Pedido, campos []string) error
+	UpdateEstado(id int, estado domain.EstadoPedido) error
+	UpdateEstadoDesde(id int, estadoActual domain.EstadoPedido, nuevoEstado domain.EstadoPedido) (bool, error)
 	Delete(id int) error
 }
 
@@ -40,10 +91,15 @@ type DetallesPedidoRepository interface {
 
 type VentaRepository interface {
 	GetByID(id int) (*domain.Venta, error)
-	GetAll() ([]*domain.Venta, error)
+	GetByIDForUpdate(id int) (*domain.Venta, error)
+	GetAll(opciones QueryOptions) ([]*domain.Venta, int, error)
+	GetByCliente(clienteID int) ([]*domain.Venta, error)
+	GetByPedido(pedidoID int) ([]*domain.Venta, error)
 	Create(venta *domain.Venta) (int, error)
 	Update(venta *domain.Venta) error
-	UpdateEstado(id int, estado string) error
+	UpdateCampos(venta *domain.Venta, campos []string) error
+	UpdateEstado(id int, estado domain.EstadoVenta) error
+	UpdateEstadoDesde(id int, estadoActual domain.EstadoVenta, nuevoEstado domain.EstadoVenta) (bool, error)
 	Delete(id int) error
 }
 
@@ -56,16 +112,100 @@ type DetallesVentaRepository interface {
 
 type OrdenProveedorRepository interface {
 	GetByID(id int) (*domain.OrdenProveedor, error)
-	GetAll() ([]*domain.OrdenProveedor, error)
+	GetByIDForUpdate(id int) (*domain.OrdenProveedor, error)
+	GetBorradorByProveedor(proveedorID int) (*domain.OrdenProveedor, error)
+	GetAll(opciones QueryOptions) ([]*domain.OrdenProveedor, int, error)
 	Create(orden *domain.OrdenProveedor) (int, error)
 	Update(orden *domain.OrdenProveedor) error
-	UpdateEstado(id int, estado string) error
+	UpdateCampos(orden *domain.OrdenProveedor, campos []string) error
+	UpdateEstado(id int, estado domain.EstadoOrden) error
+	UpdateEstadoDesde(id int, estadoActual domain.EstadoOrden, nuevoEstado domain.EstadoOrden) (bool, error)
 	Delete(id int) error
 }
 
 type DetallesOrdenRepository interface {
 	GetByOrdenID(ordenID int) ([]*domain.DetallesOrden, error)
+	GetCantidadPendiente(productoID int) (int, error)
+	GetUltimoPrecio(productoID int) (domain.Money, error)
 	Create(detalle *domain.DetallesOrden) (int, error)
 	Update(detalle *domain.DetallesOrden) error
 	Delete(id int) error
 }
+
+type MovimientoInventarioRepository interface {
+	Create(movimiento *domain.MovimientoInventario) (int, error)
+	GetByProductoID(productoID int, almacenID int, desde, hasta *time.Time) ([]*domain.MovimientoInventario, error)
+}
+
+type AlmacenRepository interface {
+	GetByID(id int) (*domain.Almacen, error)
+	GetAll(opciones QueryOptions) ([]*domain.Almacen, int, error)
+	Create(almacen *domain.Almacen) (int, error)
+	Update(almacen *domain.Almacen) error
+	GetExistenciasByAlmacen(almacenID int) ([]*domain.ExistenciaAlmacen, error)
+	GetExistenciasByProducto(productoID int) ([]*domain.ExistenciaAlmacen, error)
+}
+
+type TransferenciaRepository interface {
+	GetByID(id int) (*domain.Transferencia, error)
+	GetAll(opciones QueryOptions) ([]*domain.Transferencia, int, error)
+	Create(transferencia *domain.Transferencia) (int, error)
+	CreateDetalle(detalle *domain.DetallesTransferencia) (int, error)
+	GetDetalles(transferenciaID int) ([]*domain.DetallesTransferencia, error)
+}
+
+// DevolucionRepository define las operaciones para las devoluciones de ventas
+type DevolucionRepository interface {
+	GetByID(id int) (*domain.Devolucion, error)
+	GetAll(opciones QueryOptions) ([]*domain.Devolucion, int, error)
+	GetByVenta(ventaID int) ([]*domain.Devolucion, error)
+	Create(devolucion *domain.Devolucion) (int, error)
+	CreateDetalle(detalle *domain.DetallesDevolucion) (int, error)
+	GetDetalles(devolucionID int) ([]*domain.DetallesDevolucion, error)
+	GetDevueltoPorDetalle(ventaID int) (map[int]int, error)
+}
+
+// PromocionRepository define las operaciones para las promociones automáticas
+type PromocionRepository interface {
+	GetByID(id int) (*domain.Promocion, error)
+	GetAll(opciones QueryOptions) ([]*domain.Promocion, int, error)
+	GetVigentes(fecha time.Time) ([]*domain.Promocion, error)
+	Create(promocion *domain.Promocion) (int, error)
+	Update(promocion *domain.Promocion) error
+	Delete(id int) error
+}
+
+// FacturaRepository registra los comprobantes fiscales emitidos para las ventas
+type FacturaRepository interface {
+	GetByVenta(ventaID int, tipo domain.TipoComprobante) (*domain.DocumentoFiscal, error)
+	SiguienteFolio(serie string) (int, error)
+	Create(documento *domain.DocumentoFiscal) (int, error)
+}
+
+type LoteRepository interface {
+	GetByID(id int) (*domain.Lote, error)
+	GetByProducto(productoID int, almacenID int) ([]*domain.Lote, error)
+	GetDisponiblesForUpdate(productoID int, almacenID int) ([]*domain.Lote, error)
+	GetPorCaducar(hasta time.Time, almacenID int) ([]*domain.Lote, error)
+	Create(lote *domain.Lote) (int, error)
+	AjustarExistencia(id int, cantidad int) error
+	CreateMovimiento(movimiento *domain.MovimientoLote) (int, error)
+	GetNetoPorDocumento(tipo domain.TipoDocumento, documentoID int, productoID int, almacenID int) (map[int]int, error)
+}
+
+type HistorialEstadoRepository interface {
+	Create(tipo domain.TipoDocumento, historial *domain.HistorialEstado) (int, error)
+	GetByDocumento(tipo domain.TipoDocumento, documentoID int) ([]*domain.HistorialEstado, error)
+}
+
+// AuditoriaRepository guarda y consulta la bitácora de operaciones que
+// modifican registros
+type AuditoriaRepository interface {
+	Create(registro *domain.RegistroAuditoria) (int, error)
+	GetAll(filtro domain.FiltroAuditoria, opciones QueryOptions) ([]*domain.RegistroAuditoria, int, error)
+}
+
+// ReporteRepository obtiene reportes agregados sobre las ventas
+type ReporteRepository interface {
+	GetMargen(agrupacion domain.AgrupacionMargen, desde, hasta *time.Time) ([]*domain.LineaMargen, error)
+}
@@ -1,14 +1,42 @@
 package ports
 
+import "ActividadDesempenioAPIz/core/domain"
+
 // Interfaces para servicios
 
 // NotificationService define el servicio básico de notificaciones
 type NotificationService interface {
-	NotifyLowStock(productID int, stockLevel int)
-	NotifyNewPedido(pedidoID int, amount float64)
-	NotifyNewVenta(ventaID int, amount float64)
-	NotifyNewOrdenProveedor(ordenID int, amount float64)
-	NotifyCanceledPedido(pedidoID int, amount float64)
-	NotifyCanceledVenta(ventaID int, amount float64)
-	NotifyCanceledOrdenProveedor(ordenID int, amount float64, providerID int)
+	NotifyLowStock(productID int, warehouseID int, stockLevel int, threshold int)
+	NotifyRestock(productID int, warehouseID int, stockLevel int, threshold int)
+	NotifyNewPedido(pedidoID int, amount domain.Money)
+	NotifyNewVenta(ventaID int, amount domain.Money)
+	NotifyNewOrdenProveedor(ordenID int, amount domain.Money)
+	NotifyCanceledPedido(pedidoID int, amount domain.Money)
+	NotifyCanceledVenta(ventaID int, amount domain.Money)
+	NotifySaleReturn(devolucionID int, ventaID int, refund domain.Money)
+	NotifyCanceledOrdenProveedor(ordenID int, amount domain.Money, providerID int)
+	NotifyDraftOrdenProveedor(ordenID int, amount domain.Money, providerID int, created bool)
+	NotifyExpiringStock(lote *domain.Lote, daysToExpire int)
+}
+
+// ReabastecimientoService genera órdenes de proveedor en borrador para los
+// productos que alcanzan su punto de reorden
+type ReabastecimientoService interface {
+	EvaluarProductos(productoIDs []int)
+}
+
+// GeneradorFactura presenta los comprobantes de venta en los formatos que se
+// entregan al cliente
+type GeneradorFactura interface {
+	Emisor() domain.Emisor
+	XML(factura *domain.Factura) ([]byte, error)
+	PDF(factura *domain.Factura) ([]byte, error)
+}
+
+// BuscadorProductos busca productos no eliminados por nombre y descripción.
+// Los términos ya vienen normalizados con domain.TerminosBusqueda; cada uno
+// debe coincidir con una palabra completa o con su inicio. Los resultados van
+// del más relevante al menos relevante.
+type BuscadorProductos interface {
+	Buscar(terminos []string, limite int) ([]*domain.ResultadoBusqueda, error)
 }
@@ -0,0 +1,31 @@
+package ports
+
+// TxRepositories agrupa los repositorios que comparten una misma transacción
+type TxRepositories struct {
+	Productos      ProductoRepository
+	Proveedores    ProveedorRepository
+	Pedidos        PedidoRepository
+	DetallesPedido DetallesPedidoRepository
+	Ventas         VentaRepository
+	DetallesVenta  DetallesVentaRepository
+	Ordenes        OrdenProveedorRepository
+	DetallesOrden  DetallesOrdenRepository
+	Movimientos    MovimientoInventarioRepository
+	Historial      HistorialEstadoRepository
+	Almacenes      AlmacenRepository
+	Transferencias TransferenciaRepository
+	Lotes          LoteRepository
+	Catalogo       CatalogoProveedorRepository
+	Clientes       ClienteRepository
+	Devoluciones   DevolucionRepository
+	Categorias     CategoriaRepository
+	Promociones    PromocionRepository
+	Facturas       FacturaRepository
+	Auditoria      AuditoriaRepository
+}
+
+// UnitOfWork ejecuta un conjunto de operaciones sobre los repositorios de
+// forma atómica: o se confirman todas o no se confirma ninguna
+type UnitOfWork interface {
+	Execute(fn func(repos *TxRepositories) error) error
+}
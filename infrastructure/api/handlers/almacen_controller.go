@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlmacenController controla las solicitudes relacionadas con almacenes
+type AlmacenController struct {
+	repository ports.AlmacenRepository
+}
+
+// NewAlmacenController crea un nuevo controlador de almacenes
+func NewAlmacenController(repository ports.AlmacenRepository) *AlmacenController {
+	return &AlmacenController{
+		repository: repository,
+	}
+}
+
+// GetAll obtiene una página de los almacenes. Se ordena por id, nombre o
+// fecha_registro y se filtra con desde y hasta.
+func (ac *AlmacenController) GetAll(c *gin.Context) {
+	opciones, err := parseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	almacenes, total, err := ac.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
+		return
+	}
+
+	responderListado(c, opciones, total, almacenes)
+}
+
+// GetByID obtiene un almacén por su ID
+func (ac *AlmacenController) GetByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	almacen, err := ac.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Almacén no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, almacen)
+}
+
+// Create crea un nuevo almacén
+func (ac *AlmacenController) Create(c *gin.Context) {
+	var almacen domain.Almacen
+	if err := c.ShouldBindJSON(&almacen); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.TrimSpace(almacen.Nombre) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre del almacén es obligatorio"})
+		return
+	}
+
+	// Establecer fecha de registro
+	almacen.FechaRegistro = time.Now().Format("2006-01-02 15:04:05")
+
+	id, err := ac.repository.Create(&almacen)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	almacen.ID = id
+	c.JSON(http.StatusCreated, almacen)
+}
+
+// Update actualiza el nombre y la dirección de un almacén existente
+func (ac *AlmacenController) Update(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	actual, err := ac.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Almacén no encontrado"})
+		return
+	}
+
+	var almacen domain.Almacen
+	if err := c.ShouldBindJSON(&almacen); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if strings.TrimSpace(almacen.Nombre) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre del almacén es obligatorio"})
+		return
+	}
+
+	almacen.ID = id
+	almacen.FechaRegistro = actual.FechaRegistro
+	if err := ac.repository.Update(&almacen); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, almacen)
+}
+
+// GetExistencias obtiene la existencia de cada producto en un almacén
+func (ac *AlmacenController) GetExistencias(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := ac.repository.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Almacén no encontrado"})
+		return
+	}
+
+	existencias, err := ac.repository.GetExistenciasByAlmacen(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, existencias)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditoriaController controla las consultas a la bitácora de auditoría
+type AuditoriaController struct {
+	repository ports.AuditoriaRepository
+}
+
+// NewAuditoriaController crea un nuevo controlador de auditoría
+func NewAuditoriaController(repository ports.AuditoriaRepository) *AuditoriaController {
+	return &AuditoriaController{
+		repository: repository,
+	}
+}
+
+// GetAll obtiene una página de la bitácora de auditoría, del registro más
+// reciente al más antiguo. Acepta los parámetros opcionales entidad,
+// id_entidad, actor, desde y hasta (AAAA-MM-DD o RFC3339), y se ordena por id
+// o fecha.
+func (ac *AuditoriaController) GetAll(c *gin.Context) {
+	filtro, err := parseFiltroAuditoria(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opciones, err := parseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	registros, total, err := ac.repository.GetAll(filtro, opciones)
+	if err != nil {
+		responderErrorListado(c, err)
+		return
+	}
+
+	responderListado(c, opciones, total, registros)
+}
+
+// parseFiltroAuditoria lee los parámetros de consulta de la bitácora
+func parseFiltroAuditoria(c *gin.Context) (domain.FiltroAuditoria, error) {
+	filtro := domain.FiltroAuditoria{
+		Entidad: domain.EntidadAuditoria(c.Query("entidad")),
+		Actor:   c.Query("actor"),
+	}
+
+	switch filtro.Entidad {
+	case "", domain.EntidadProducto, domain.EntidadProveedor, domain.EntidadPedido,
+		domain.EntidadVenta, domain.EntidadOrdenProveedor:
+	default:
+		return filtro, fmt.Errorf("entidad inválida: %s", filtro.Entidad)
+	}
+
+	if valor := c.Query("id_entidad"); valor != "" {
+		id, err := strconv.Atoi(valor)
+		if err != nil || id <= 0 {
+			return filtro, fmt.Errorf("id_entidad inválido: %s", valor)
+		}
+		filtro.EntidadID = id
+	}
+
+	return filtro, nil
+}
+
+// auditar registra en la bitácora una operación con el estado del registro
+// antes y después de ella. antes es nil en las altas y despues en las
+// eliminaciones definitivas. Se llama dentro de la misma transacción que la
+// operación para que ambas se confirmen o se reviertan juntas.
+func auditar(repos *ports.TxRepositories, c *gin.Context, accion domain.AccionAuditoria,
+	entidad domain.EntidadAuditoria, entidadID int, antes, despues interface{}) error {
+	registro, err := domain.NewRegistroAuditoria(usuarioActual(c), c.Request.Method, c.Request.URL.Path,
+		accion, entidad, entidadID, antes, despues)
+	if err != nil {
+		return err
+	}
+	_, err = repos.Auditoria.Create(registro)
+	return err
+}
+
+// conLineaAgregada arma el estado auditado de un documento después de
+// agregarle una línea, para que la bitácora registre también la línea nueva
+func conLineaAgregada(documento, linea interface{}) interface{} {
+	return struct {
+		Documento     interface{} `json:"documento"`
+		LineaAgregada interface{} `json:"linea_agregada"`
+	}{documento, linea}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogoProveedorController controla las solicitudes relacionadas con el
+// catálogo de productos de los proveedores
+type CatalogoProveedorController struct {
+	repository    ports.CatalogoProveedorRepository
+	proveedorRepo ports.ProveedorRepository
+	productoRepo  ports.ProductoRepository
+	unitOfWork    ports.UnitOfWork
+}
+
+// NewCatalogoProveedorController crea un nuevo controlador del catálogo de proveedores
+func NewCatalogoProveedorController(
+	repository ports.CatalogoProveedorRepository,
+	proveedorRepo ports.ProveedorRepository,
+	productoRepo ports.ProductoRepository,
+	unitOfWork ports.UnitOfWork,
+) *CatalogoProveedorController {
+	return &CatalogoProveedorController{
+		repository:    repository,
+		proveedorRepo: proveedorRepo,
+		productoRepo:  productoRepo,
+		unitOfWork:    unitOfWork,
+	}
+}
+
+// comparacionCosto es la entrada de un proveedor en la comparación de costos
+// de un producto, con su diferencia respecto al menor costo
+type comparacionCosto struct {
+	*domain.ProveedorProducto
+	DiferenciaMejorCosto domain.Money `json:"diferencia_mejor_costo"`
+}
+
+// GetByProveedor obtiene el catálogo de un proveedor
+func (cc *CatalogoProveedorController) GetByProveedor(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := cc.proveedorRepo.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+
+	entradas, err := cc.repository.GetByProveedor(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entradas)
+}
+
+// Upsert crea o reemplaza la entrada de un producto en el catálogo de un
+// proveedor. Marcarla como preferida desmarca a los demás proveedores del
+// producto.
+func (cc *CatalogoProveedorController) Upsert(c *gin.Context) {
+	proveedorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de proveedor inválido"})
+		return
+	}
+
+	productoID, err := strconv.Atoi(c.Param("id_producto"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de producto inválido"})
+		return
+	}
+
+	entrada := domain.ProveedorProducto{CantidadMinima: 1}
+	if err := c.ShouldBindJSON(&entrada); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case entrada.Costo.IsNegative():
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El costo no puede ser negativo"})
+		return
+	case entrada.CantidadMinima < 1:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La cantidad mínima debe ser al menos 1"})
+		return
+	case entrada.TiempoEntregaDias < 0:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El tiempo de entrega no puede ser negativo"})
+		return
+	case len(entrada.SKUProveedor) > 50:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El SKU del proveedor no puede exceder 50 caracteres"})
+		return
+	}
+
+	proveedor, err := cc.proveedorRepo.GetByID(proveedorID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+
+	producto, err := cc.productoRepo.GetByID(productoID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	if proveedor.Eliminado() || producto.Eliminado() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede agregar al catálogo un proveedor o producto eliminado"})
+		return
+	}
+
+	entrada.ProveedorID = proveedorID
+	entrada.ProductoID = productoID
+	err = cc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		if entrada.Preferido {
+			if err := repos.Catalogo.QuitarPreferido(productoID, proveedorID); err != nil {
+				return err
+			}
+		}
+		return repos.Catalogo.Upsert(&entrada)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entrada.NombreProveedor = proveedor.Nombre
+	entrada.NombreProducto = producto.Nombre
+	c.JSON(http.StatusOK, entrada)
+}
+
+// Delete quita un producto del catálogo de un proveedor
+func (cc *CatalogoProveedorController) Delete(c *gin.Context) {
+	proveedorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de proveedor inválido"})
+		return
+	}
+
+	productoID, err := strconv.Atoi(c.Param("id_producto"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de producto inválido"})
+		return
+	}
+
+	entrada, err := cc.repository.Get(proveedorID, productoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entrada == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "El producto no está en el catálogo del proveedor"})
+		return
+	}
+
+	if err := cc.repository.Delete(proveedorID, productoID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Producto retirado del catálogo del proveedor"})
+}
+
+// CompararCostos compara los costos de los proveedores que ofrecen un
+// producto, de menor a mayor costo
+func (cc *CatalogoProveedorController) CompararCostos(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	producto, err := cc.productoRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	entradas, err := cc.repository.GetByProducto(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	respuesta := gin.H{
+		"id_producto": id,
+		"nombre":      producto.Nombre,
+		"proveedores": []comparacionCosto{},
+	}
+	if len(entradas) == 0 {
+		c.JSON(http.StatusOK, respuesta)
+		return
+	}
+
+	// Las entradas vienen ordenadas por costo: la primera es la más barata
+	mejor := entradas[0]
+	comparacion := make([]comparacionCosto, 0, len(entradas))
+	for _, entrada := range entradas {
+		comparacion = append(comparacion, comparacionCosto{
+			ProveedorProducto:    entrada,
+			DiferenciaMejorCosto: entrada.Costo.Sub(mejor.Costo),
+		})
+		if entrada.Preferido {
+			respuesta["id_proveedor_preferido"] = entrada.ProveedorID
+		}
+	}
+
+	respuesta["proveedores"] = comparacion
+	respuesta["id_proveedor_mejor_costo"] = mejor.ProveedorID
+	respuesta["mejor_costo"] = mejor.Costo
+	c.JSON(http.StatusOK, respuesta)
+}
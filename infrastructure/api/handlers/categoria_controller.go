@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoriaController controla las solicitudes relacionadas con categorías de productos
+type CategoriaController struct {
+	repository   ports.CategoriaRepository
+	productoRepo ports.ProductoRepository
+}
+
+// NewCategoriaController crea un nuevo controlador de categorías
+func NewCategoriaController(
+	repository ports.CategoriaRepository,
+	productoRepo ports.ProductoRepository,
+) *CategoriaController {
+	return &CategoriaController{
+		repository:   repository,
+		productoRepo: productoRepo,
+	}
+}
+
+// GetAll obtiene todas las categorías. Con arbol=true las retorna anidadas
+// bajo su categoría padre.
+func (cc *CategoriaController) GetAll(c *gin.Context) {
+	categorias, err := cc.repository.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("arbol") == "true" {
+		c.JSON(http.StatusOK, domain.ArbolCategorias(categorias))
+		return
+	}
+
+	c.JSON(http.StatusOK, categorias)
+}
+
+// GetByID obtiene una categoría por su ID
+func (cc *CategoriaController) GetByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	categoria, err := cc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, categoria)
+}
+
+// GetProductos obtiene los productos de una categoría y de sus subcategorías
+func (cc *CategoriaController) GetProductos(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := cc.repository.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
+		return
+	}
+
+	categorias, err := cc.repository.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	productos, err := cc.productoRepo.GetByCategorias(domain.DescendientesDe(categorias, id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, productos)
+}
+
+// Create crea una nueva categoría
+func (cc *CategoriaController) Create(c *gin.Context) {
+	var categoria domain.Categoria
+	if err := c.ShouldBindJSON(&categoria); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if mensaje := cc.validarCategoria(&categoria); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
+	id, err := cc.repository.Create(&categoria)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	categoria.ID = id
+	c.JSON(http.StatusCreated, categoria)
+}
+
+// Update actualiza una categoría existente. La categoría padre no puede ser
+// la misma categoría ni una de sus subcategorías.
+func (cc *CategoriaController) Update(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := cc.repository.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
+		return
+	}
+
+	var categoria domain.Categoria
+	if err := c.ShouldBindJSON(&categoria); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	categoria.ID = id
+
+	if mensaje := cc.validarCategoria(&categoria); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
+	if categoria.CategoriaPadreID != nil {
+		categorias, err := cc.repository.GetAll()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		for _, descendiente := range domain.DescendientesDe(categorias, id) {
+			if descendiente == *categoria.CategoriaPadreID {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "La categoría padre no puede ser la misma categoría ni una de sus subcategorías",
+				})
+				return
+			}
+		}
+	}
+
+	if err := cc.repository.Update(&categoria); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categoria)
+}
+
+// Delete elimina una categoría. No se permite si tiene subcategorías o
+// productos asignados.
+func (cc *CategoriaController) Delete(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := cc.repository.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
+		return
+	}
+
+	categorias, err := cc.repository.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(domain.DescendientesDe(categorias, id)) > 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "La categoría tiene subcategorías"})
+		return
+	}
+
+	productos, err := cc.repository.CountProductos(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if productos > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "La categoría tiene productos asignados",
+			"productos": productos,
+		})
+		return
+	}
+
+	if err := cc.repository.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Categoría eliminada correctamente"})
+}
+
+// validarCategoria verifica el nombre y que la categoría padre exista.
+// Retorna el mensaje de error o una cadena vacía si es válida.
+func (cc *CategoriaController) validarCategoria(categoria *domain.Categoria) string {
+	if strings.TrimSpace(categoria.Nombre) == "" {
+		return "El nombre de la categoría es obligatorio"
+	}
+
+	if categoria.CategoriaPadreID != nil {
+		if _, err := cc.repository.GetByID(*categoria.CategoriaPadreID); err != nil {
+			return fmt.Sprintf("La categoría padre %d no existe", *categoria.CategoriaPadreID)
+		}
+	}
+
+	if categoria.TasaIVA != nil {
+		if err := categoria.TasaIVA.Validar(); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
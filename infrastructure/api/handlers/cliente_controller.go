@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errClienteNoEncontrado indica que un cliente referenciado no existe
+var errClienteNoEncontrado = errors.New("cliente no encontrado")
+
+// ClienteController controla las solicitudes relacionadas con clientes
+type ClienteController struct {
+	repository ports.ClienteRepository
+	pedidoRepo ports.PedidoRepository
+	ventaRepo  ports.VentaRepository
+}
+
+// NewClienteController crea un nuevo controlador de clientes
+func NewClienteController(
+	repository ports.ClienteRepository,
+	pedidoRepo ports.PedidoRepository,
+	ventaRepo ports.VentaRepository,
+) *ClienteController {
+	return &ClienteController{
+		repository: repository,
+		pedidoRepo: pedidoRepo,
+		ventaRepo:  ventaRepo,
+	}
+}
+
+// GetAll obtiene una página de los clientes. Se ordena por id, nombre o
+// fecha_registro y se filtra con desde y hasta.
+func (cc *ClienteController) GetAll(c *gin.Context) {
+	opciones, err := parseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientes, total, err := cc.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
+		return
+	}
+
+	responderListado(c, opciones, total, clientes)
+}
+
+// GetByID obtiene un cliente por su ID
+func (cc *ClienteController) GetByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	cliente, err := cc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cliente no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, cliente)
+}
+
+// Create crea un nuevo cliente
+func (cc *ClienteController) Create(c *gin.Context) {
+	var cliente domain.Cliente
+	if err := c.ShouldBindJSON(&cliente); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if mensaje := validarDatosCliente(&cliente); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
+	// Establecer fecha de registro
+	cliente.FechaRegistro = time.Now().Format("2006-01-02 15:04:05")
+
+	id, err := cc.repository.Create(&cliente)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	cliente.ID = id
+	c.JSON(http.StatusCreated, cliente)
+}
+
+// Update actualiza un cliente existente
+func (cc *ClienteController) Update(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	actual, err := cc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cliente no encontrado"})
+		return
+	}
+
+	var cliente domain.Cliente
+	if err := c.ShouldBindJSON(&cliente); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if mensaje := validarDatosCliente(&cliente); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
+	cliente.ID = id
+	cliente.FechaRegistro = actual.FechaRegistro
+	if err := cc.repository.Update(&cliente); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cliente)
+}
+
+// Delete elimina un cliente. No se permite si tiene pedidos o ventas.
+func (cc *ClienteController) Delete(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := cc.repository.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cliente no encontrado"})
+		return
+	}
+
+	pedidos, err := cc.pedidoRepo.GetByCliente(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ventas, err := cc.ventaRepo.GetByCliente(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(pedidos) > 0 || len(ventas) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "El cliente tiene pedidos o ventas registrados",
+			"pedidos": len(pedidos),
+			"ventas":  len(ventas),
+		})
+		return
+	}
+
+	if err := cc.repository.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cliente eliminado correctamente"})
+}
+
+// GetHistorial obtiene los pedidos y las compras de un cliente, del más
+// reciente al más antiguo, con sus totales
+func (cc *ClienteController) GetHistorial(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	cliente, err := cc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cliente no encontrado"})
+		return
+	}
+
+	pedidos, err := cc.pedidoRepo.GetByCliente(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ventas, err := cc.ventaRepo.GetByCliente(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cliente": cliente,
+		"pedidos": pedidos,
+		"ventas":  ventas,
+		"resumen": domain.NewResumenCliente(pedidos, ventas),
+	})
+}
+
+// validarDatosCliente verifica los datos obligatorios de un cliente. Retorna
+// el mensaje de error o una cadena vacía si son válidos.
+func validarDatosCliente(cliente *domain.Cliente) string {
+	cliente.RFC = strings.ToUpper(strings.TrimSpace(cliente.RFC))
+
+	switch {
+	case strings.TrimSpace(cliente.Nombre) == "":
+		return "El nombre del cliente es obligatorio"
+	case cliente.RFC != "" && len(cliente.RFC) != 12 && len(cliente.RFC) != 13:
+		return "El RFC debe tener 12 o 13 caracteres"
+	}
+	return ""
+}
+
+// validarCliente verifica que exista el cliente de un documento y retorna la
+// dirección de entrega: la indicada o, si está vacía, la del cliente. Un
+// documento sin cliente conserva la dirección indicada.
+func validarCliente(repos *ports.TxRepositories, clienteID *int, direccion string) (string, error) {
+	if clienteID == nil {
+		return direccion, nil
+	}
+
+	cliente, err := repos.Clientes.GetByID(*clienteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %d", errClienteNoEncontrado, *clienteID)
+		}
+		return "", err
+	}
+
+	if strings.TrimSpace(direccion) == "" {
+		return cliente.Direccion, nil
+	}
+	return direccion, nil
+}
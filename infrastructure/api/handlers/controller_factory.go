@@ -11,6 +11,17 @@ type ControllerFactory struct {
 	pedidoController         *PedidoController
 	ventaController          *VentaController
 	ordenProveedorController *OrdenProveedorController
+	almacenController        *AlmacenController
+	transferenciaController  *TransferenciaController
+	loteController           *LoteController
+	categoriaController      *CategoriaController
+	catalogoController       *CatalogoProveedorController
+	reporteController        *ReporteController
+	clienteController        *ClienteController
+	devolucionController     *DevolucionController
+	promocionController      *PromocionController
+	facturaController        *FacturaController
+	auditoriaController      *AuditoriaController
 }
 
 // NewControllerFactory crea una nueva fábrica de controladores
@@ -23,13 +34,40 @@ func NewControllerFactory(
 	detallesVentaRepo ports.DetallesVentaRepository,
 	ordenRepo ports.OrdenProveedorRepository,
 	detallesOrdenRepo ports.DetallesOrdenRepository,
+	movimientoRepo ports.MovimientoInventarioRepository,
+	historialRepo ports.HistorialEstadoRepository,
+	almacenRepo ports.AlmacenRepository,
+	transferenciaRepo ports.TransferenciaRepository,
+	loteRepo ports.LoteRepository,
+	categoriaRepo ports.CategoriaRepository,
+	catalogoRepo ports.CatalogoProveedorRepository,
+	reporteRepo ports.ReporteRepository,
+	clienteRepo ports.ClienteRepository,
+	devolucionRepo ports.DevolucionRepository,
+	promocionRepo ports.PromocionRepository,
+	auditoriaRepo ports.AuditoriaRepository,
+	unitOfWork ports.UnitOfWork,
 	notificationService ports.NotificationService,
+	reabastecimiento ports.ReabastecimientoService,
+	generadorFactura ports.GeneradorFactura,
+	buscadorProductos ports.BuscadorProductos,
 ) *ControllerFactory {
-	productoController := NewProductoController(productoRepo, notificationService)
-	proveedorController := NewProveedorController(proveedorRepo)
-	pedidoController := NewPedidoController(pedidoRepo, detallesPedidoRepo, productoRepo, notificationService)
-	ventaController := NewVentaController(ventaRepo, detallesVentaRepo, productoRepo, notificationService)
-	ordenProveedorController := NewOrdenProveedorController(ordenRepo, detallesOrdenRepo, proveedorRepo, productoRepo, notificationService)
+	productoController := NewProductoController(productoRepo, movimientoRepo, almacenRepo, categoriaRepo, unitOfWork, notificationService, reabastecimiento, buscadorProductos)
+	proveedorController := NewProveedorController(proveedorRepo, unitOfWork)
+	pedidoController := NewPedidoController(pedidoRepo, detallesPedidoRepo, ventaRepo, productoRepo, historialRepo, unitOfWork, notificationService, reabastecimiento)
+	ventaController := NewVentaController(ventaRepo, detallesVentaRepo, productoRepo, historialRepo, unitOfWork, notificationService, reabastecimiento)
+	ordenProveedorController := NewOrdenProveedorController(ordenRepo, detallesOrdenRepo, proveedorRepo, productoRepo, historialRepo, unitOfWork, notificationService, reabastecimiento)
+	almacenController := NewAlmacenController(almacenRepo)
+	transferenciaController := NewTransferenciaController(transferenciaRepo, productoRepo, unitOfWork, notificationService)
+	loteController := NewLoteController(loteRepo, productoRepo)
+	categoriaController := NewCategoriaController(categoriaRepo, productoRepo)
+	catalogoController := NewCatalogoProveedorController(catalogoRepo, proveedorRepo, productoRepo, unitOfWork)
+	reporteController := NewReporteController(reporteRepo)
+	clienteController := NewClienteController(clienteRepo, pedidoRepo, ventaRepo)
+	devolucionController := NewDevolucionController(devolucionRepo, ventaRepo, productoRepo, unitOfWork, notificationService)
+	promocionController := NewPromocionController(promocionRepo, productoRepo, categoriaRepo)
+	facturaController := NewFacturaController(unitOfWork, generadorFactura)
+	auditoriaController := NewAuditoriaController(auditoriaRepo)
 
 	return &ControllerFactory{
 		productoController:       productoController,
@@ -37,6 +75,17 @@ func NewControllerFactory(
 		pedidoController:         pedidoController,
 		ventaController:          ventaController,
 		ordenProveedorController: ordenProveedorController,
+		almacenController:        almacenController,
+		transferenciaController:  transferenciaController,
+		loteController:           loteController,
+		categoriaController:      categoriaController,
+		catalogoController:       catalogoController,
+		reporteController:        reporteController,
+		clienteController:        clienteController,
+		devolucionController:     devolucionController,
+		promocionController:      promocionController,
+		facturaController:        facturaController,
+		auditoriaController:      auditoriaController,
 	}
 }
 
@@ -64,3 +113,58 @@ func (cf *ControllerFactory) GetVentaController() *VentaController {
 func (cf *ControllerFactory) GetOrdenProveedorController() *OrdenProveedorController {
 	return cf.ordenProveedorController
 }
+
+// GetAlmacenController retorna el controlador de almacenes
+func (cf *ControllerFactory) GetAlmacenController() *AlmacenController {
+	return cf.almacenController
+}
+
+// GetTransferenciaController retorna el controlador de transferencias
+func (cf *ControllerFactory) GetTransferenciaController() *TransferenciaController {
+	return cf.transferenciaController
+}
+
+// GetLoteController retorna el controlador de lotes
+func (cf *ControllerFactory) GetLoteController() *LoteController {
+	return cf.loteController
+}
+
+// GetCategoriaController retorna el controlador de categorías
+func (cf *ControllerFactory) GetCategoriaController() *CategoriaController {
+	return cf.categoriaController
+}
+
+// GetCatalogoProveedorController retorna el controlador del catálogo de proveedores
+func (cf *ControllerFactory) GetCatalogoProveedorController() *CatalogoProveedorController {
+	return cf.catalogoController
+}
+
+// GetReporteController retorna el controlador de reportes
+func (cf *ControllerFactory) GetReporteController() *ReporteController {
+	return cf.reporteController
+}
+
+// GetClienteController retorna el controlador de clientes
+func (cf *ControllerFactory) GetClienteController() *ClienteController {
+	return cf.clienteController
+}
+
+// GetDevolucionController retorna el controlador de devoluciones
+func (cf *ControllerFactory) GetDevolucionController() *DevolucionController {
+	return cf.devolucionController
+}
+
+// GetPromocionController retorna el controlador de promociones
+func (cf *ControllerFactory) GetPromocionController() *PromocionController {
+	return cf.promocionController
+}
+
+// GetFacturaController retorna el controlador de facturas
+func (cf *ControllerFactory) GetFacturaController() *FacturaController {
+	return cf.facturaController
+}
+
+// GetAuditoriaController retorna el controlador de la bitácora de auditoría
+func (cf *ControllerFactory) GetAuditoriaController() *AuditoriaController {
+	return cf.auditoriaController
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DevolucionController controla las devoluciones de ventas
+type DevolucionController struct {
+	repository          ports.DevolucionRepository
+	ventaRepo           ports.VentaRepository
+	productoRepo        ports.ProductoRepository
+	unitOfWork          ports.UnitOfWork
+	notificationService ports.NotificationService
+}
+
+// NewDevolucionController crea un nuevo controlador de devoluciones
+func NewDevolucionController(
+	repository ports.DevolucionRepository,
+	ventaRepo ports.VentaRepository,
+	productoRepo ports.ProductoRepository,
+	unitOfWork ports.UnitOfWork,
+	notificationService ports.NotificationService,
+) *DevolucionController {
+	return &DevolucionController{
+		repository:          repository,
+		ventaRepo:           ventaRepo,
+		productoRepo:        productoRepo,
+		unitOfWork:          unitOfWork,
+		notificationService: notificationService,
+	}
+}
+
+// GetAll obtiene una página de las devoluciones, de la más reciente a la más
+// antigua. Se ordena por id, fecha o reembolso y se filtra con desde, hasta,
+// id_almacen, precio_min y precio_max (sobre el reembolso).
+func (dc *DevolucionController) GetAll(c *gin.Context) {
+	opciones, err := parseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	devoluciones, total, err := dc.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
+		return
+	}
+
+	responderListado(c, opciones, total, devoluciones)
+}
+
+// GetByID obtiene una devolución con sus detalles
+func (dc *DevolucionController) GetByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	devolucion, err := dc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Devolución no encontrada"})
+		return
+	}
+
+	detalles, err := dc.repository.GetDetalles(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"devolucion": devolucion,
+		"detalles":   detalles,
+	})
+}
+
+// GetByVenta obtiene las devoluciones de una venta
+func (dc *DevolucionController) GetByVenta(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := dc.ventaRepo.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venta no encontrada"})
+		return
+	}
+
+	devoluciones, err := dc.repository.GetByVenta(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, devoluciones)
+}
+
+// Create registra la devolución de líneas de una venta completada. Cada línea
+// indica el id_detalle_venta, la cantidad y el destino: vendible (por
+// omisión) regresa las unidades al inventario del almacén de la venta y
+// danado las aparta como existencia dañada. Una misma línea de venta puede
+// repetirse para repartir sus unidades entre ambos destinos, pero entre todas
+// las devoluciones de la venta no se puede devolver más de lo vendido. El
+// reembolso de cada línea es el precio al que se vendió.
+func (dc *DevolucionController) Create(c *gin.Context) {
+	idParam := c.Param("id")
+	ventaID, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de venta inválido"})
+		return
+	}
+
+	var devolucionRequest struct {
+		Motivo   string `json:"motivo"`
+		Detalles []struct {
+			DetalleVentaID int                      `json:"id_detalle_venta" binding:"required"`
+			Cantidad       int                      `json:"cantidad" binding:"required,gt=0"`
+			Destino        domain.DestinoDevolucion `json:"destino"`
+		} `json:"detalles" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&devolucionRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(devolucionRequest.Motivo) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El motivo no puede exceder 255 caracteres"})
+		return
+	}
+
+	devolucion := domain.Devolucion{
+		VentaID: ventaID,
+		Motivo:  devolucionRequest.Motivo,
+		Usuario: usuarioActual(c),
+		Fecha:   time.Now(),
+	}
+	var detalles []*domain.DetallesDevolucion
+	var movimientos []*domain.MovimientoInventario
+
+	err = dc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		// Bloquear la venta serializa las devoluciones concurrentes, de modo
+		// que lo ya devuelto no cambie mientras se valida
+		venta, err := repos.Ventas.GetByIDForUpdate(ventaID)
+		if err != nil {
+			return errVentaNoEncontrada
+		}
+		if venta.Estado != domain.VentaCompletada {
+			return fmt.Errorf("%w: sólo se pueden devolver ventas %s y la venta está %s",
+				domain.ErrDevolucionInvalida, domain.VentaCompletada, venta.Estado)
+		}
+		devolucion.AlmacenID = venta.AlmacenID
+
+		detallesVenta, err := repos.DetallesVenta.GetByVentaID(ventaID)
+		if err != nil {
+			return err
+		}
+		porID := make(map[int]*domain.DetallesVenta, len(detallesVenta))
+		for _, detalle := range detallesVenta {
+			porID[detalle.ID] = detalle
+		}
+
+		devuelto, err := repos.Devoluciones.GetDevueltoPorDetalle(ventaID)
+		if err != nil {
+			return err
+		}
+
+		// Unidades vendibles que regresan de cada producto
+		vendible := make(map[int]int)
+		for _, linea := range devolucionRequest.Detalles {
+			detalleVenta, ok := porID[linea.DetalleVentaID]
+			if !ok {
+				return fmt.Errorf("%w: el detalle %d no pertenece a la venta %d",
+					domain.ErrDevolucionInvalida, linea.DetalleVentaID, ventaID)
+			}
+
+			destino := linea.Destino
+			if destino == "" {
+				destino = domain.DevolucionVendible
+			}
+			detalle, err := domain.NewDetallesDevolucion(detalleVenta, devuelto[detalleVenta.ID], linea.Cantidad, destino)
+			if err != nil {
+				return err
+			}
+			devuelto[detalleVenta.ID] += detalle.Cantidad
+
+			if destino == domain.DevolucionVendible {
+				vendible[detalle.ProductoID] += detalle.Cantidad
+			}
+			detalles = append(detalles, detalle)
+			devolucion.Reembolso = devolucion.Reembolso.Add(detalle.Reembolso)
+		}
+
+		id, err := repos.Devoluciones.Create(&devolucion)
+		if err != nil {
+			return err
+		}
+		devolucion.ID = id
+
+		for _, detalle := range detalles {
+			detalle.DevolucionID = id
+			detalle.ID, err = repos.Devoluciones.CreateDetalle(detalle)
+			if err != nil {
+				return err
+			}
+
+			if detalle.Destino == domain.DevolucionDanado {
+				if err := repos.Productos.IncrementDanado(detalle.ProductoID, venta.AlmacenID, detalle.Cantidad); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Las entradas se registran a nombre de la venta para que regresen a
+		// los lotes de los que salieron; se aplican en orden de producto para
+		// bloquear las existencias siempre en el mismo orden
+		orden := make([]int, 0, len(vendible))
+		for productoID := range vendible {
+			orden = append(orden, productoID)
+		}
+		sort.Ints(orden)
+
+		for _, productoID := range orden {
+			movimiento := domain.NewMovimientoInventario(productoID, venta.AlmacenID, vendible[productoID],
+				domain.MotivoDevolucion, domain.DocumentoVenta, ventaID)
+			if err := aplicarMovimiento(repos, movimiento); err != nil {
+				return err
+			}
+			movimientos = append(movimientos, movimiento)
+		}
+
+		return nil
+	})
+	if errors.Is(err, errVentaNoEncontrada) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venta no encontrada"})
+		return
+	}
+	if errors.Is(err, domain.ErrDevolucionInvalida) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Notificar la devolución y los productos que salen del stock bajo
+	dc.notificationService.NotifySaleReturn(devolucion.ID, ventaID, devolucion.Reembolso)
+	notificarMovimientos(dc.notificationService, dc.productoRepo, movimientos)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"devolucion": devolucion,
+		"detalles":   detalles,
+	})
+}
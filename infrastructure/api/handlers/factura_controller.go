@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FacturaController controla la emisión de los comprobantes de venta
+type FacturaController struct {
+	unitOfWork ports.UnitOfWork
+	generador  ports.GeneradorFactura
+}
+
+// NewFacturaController crea un nuevo controlador de facturas
+func NewFacturaController(
+	unitOfWork ports.UnitOfWork,
+	generador ports.GeneradorFactura,
+) *FacturaController {
+	return &FacturaController{
+		unitOfWork: unitOfWork,
+		generador:  generador,
+	}
+}
+
+// GetByVenta obtiene el comprobante de una venta en el formato indicado con
+// ?formato=json|xml|pdf (json por omisión). La primera consulta emite el
+// comprobante con el siguiente folio de su serie y las siguientes retornan el
+// mismo documento. Una venta cancelada no se factura: se emite una
+// cancelación que relaciona su factura, si la tenía. Responde 409 si la venta
+// sigue pendiente.
+func (fc *FacturaController) GetByVenta(c *gin.Context) {
+	fc.responder(c, http.StatusOK)
+}
+
+// Emitir es un alias de GetByVenta para los clientes que prefieren emitir el
+// comprobante explícitamente. Responde 201 si se emitió en esta solicitud y
+// 200 si ya existía.
+func (fc *FacturaController) Emitir(c *gin.Context) {
+	fc.responder(c, http.StatusCreated)
+}
+
+// responder obtiene el comprobante de la venta de la ruta, emitiéndolo si aún
+// no existe, y lo escribe en el formato solicitado con statusEmitida cuando se
+// emitió en esta solicitud
+func (fc *FacturaController) responder(c *gin.Context, statusEmitida int) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	formato := c.DefaultQuery("formato", "json")
+	if formato != "json" && formato != "xml" && formato != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato inválido, use json, xml o pdf"})
+		return
+	}
+
+	var factura *domain.Factura
+	var emitida bool
+	err = fc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		factura, emitida, err = fc.armar(repos, id)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errVentaNoEncontrada) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Venta no encontrada"})
+			return
+		}
+		if errors.Is(err, domain.ErrFacturacionInvalida) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if emitida {
+		status = statusEmitida
+	}
+
+	switch formato {
+	case "xml":
+		contenido, err := fc.generador.XML(factura)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.xml"`, factura.FolioCompleto))
+		c.Data(status, "application/xml; charset=utf-8", contenido)
+	case "pdf":
+		contenido, err := fc.generador.PDF(factura)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, factura.FolioCompleto))
+		c.Data(status, "application/pdf", contenido)
+	default:
+		c.JSON(status, factura)
+	}
+}
+
+// armar arma el comprobante de una venta. Si el comprobante aún no existe lo
+// emite y retorna true; la venta queda bloqueada para que dos consultas
+// simultáneas no tomen dos folios distintos.
+func (fc *FacturaController) armar(repos *ports.TxRepositories, ventaID int) (*domain.Factura, bool, error) {
+	venta, err := repos.Ventas.GetByIDForUpdate(ventaID)
+	if err != nil {
+		return nil, false, errVentaNoEncontrada
+	}
+
+	var tipo domain.TipoComprobante
+	switch venta.Estado {
+	case domain.VentaCompletada:
+		tipo = domain.ComprobanteFactura
+	case domain.VentaCancelada:
+		tipo = domain.ComprobanteCancelacion
+	default:
+		return nil, false, fmt.Errorf("%w: la venta debe estar %s para facturarse y está %s",
+			domain.ErrFacturacionInvalida, domain.VentaCompletada, venta.Estado)
+	}
+
+	// La cancelación relaciona la factura de la venta, si se emitió
+	var relacionado *domain.DocumentoFiscal
+	if tipo == domain.ComprobanteCancelacion {
+		relacionado, err = buscarDocumento(repos, ventaID, domain.ComprobanteFactura)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	documento, err := buscarDocumento(repos, ventaID, tipo)
+	if err != nil {
+		return nil, false, err
+	}
+	emitida := false
+	if documento == nil {
+		documento = &domain.DocumentoFiscal{
+			VentaID:      ventaID,
+			Tipo:         tipo,
+			Serie:        tipo.Serie(),
+			FechaEmision: time.Now().Truncate(time.Second),
+		}
+		if relacionado != nil {
+			documento.DocumentoRelacionadoID = &relacionado.ID
+		}
+
+		documento.Folio, err = repos.Facturas.SiguienteFolio(documento.Serie)
+		if err != nil {
+			return nil, false, err
+		}
+		documento.ID, err = repos.Facturas.Create(documento)
+		if err != nil {
+			return nil, false, err
+		}
+		emitida = true
+	}
+
+	detalles, err := repos.DetallesVenta.GetByVentaID(ventaID)
+	if err != nil {
+		return nil, false, err
+	}
+	productos := make(map[int]*domain.Producto, len(detalles))
+	for _, detalle := range detalles {
+		if _, ok := productos[detalle.ProductoID]; ok {
+			continue
+		}
+		producto, err := repos.Productos.GetByID(detalle.ProductoID)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %d", errProductoNoEncontrado, detalle.ProductoID)
+		}
+		productos[detalle.ProductoID] = producto
+	}
+
+	devuelto, err := repos.Devoluciones.GetDevueltoPorDetalle(ventaID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var cliente *domain.Cliente
+	if venta.ClienteID != nil {
+		cliente, err = repos.Clientes.GetByID(*venta.ClienteID)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	receptor := domain.NewReceptor(venta, cliente)
+	factura := domain.NewFactura(documento, venta, detalles, productos, devuelto, fc.generador.Emisor(), receptor, relacionado)
+	return factura, emitida, nil
+}
+
+// buscarDocumento obtiene el comprobante de un tipo emitido para una venta, o
+// nil si no se ha emitido
+func buscarDocumento(repos *ports.TxRepositories, ventaID int, tipo domain.TipoComprobante) (*domain.DocumentoFiscal, error) {
+	documento, err := repos.Facturas.GetByVenta(ventaID, tipo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return documento, err
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errProductoNoEncontrado indica que un producto referenciado no existe
+var errProductoNoEncontrado = errors.New("producto no encontrado")
+
+// errAlmacenNoEncontrado indica que un almacén referenciado no existe
+var errAlmacenNoEncontrado = errors.New("almacén no encontrado")
+
+// validarAlmacen retorna el almacén indicado en una solicitud, o el principal
+// si no se indicó ninguno, verificando que exista
+func validarAlmacen(repos *ports.TxRepositories, almacenID int) (int, error) {
+	if almacenID == 0 {
+		almacenID = domain.AlmacenPrincipalID
+	}
+
+	if _, err := repos.Almacenes.GetByID(almacenID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errAlmacenNoEncontrado
+		}
+		return 0, err
+	}
+
+	return almacenID, nil
+}
+
+// validarProductoActivo verifica que un producto exista y no esté eliminado
+// para incluirlo en un documento nuevo
+func validarProductoActivo(repos *ports.TxRepositories, productoID int) error {
+	producto, err := repos.Productos.GetByID(productoID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", errProductoNoEncontrado, productoID)
+	}
+	if err != nil {
+		return err
+	}
+	if producto.Eliminado() {
+		return fmt.Errorf("%w: %d (eliminado)", errProductoNoEncontrado, productoID)
+	}
+	return nil
+}
+
+// validarProveedorActivo verifica que un proveedor exista y no esté eliminado
+// para asignarlo a un producto o a una orden
+func validarProveedorActivo(repos *ports.TxRepositories, proveedorID int) error {
+	proveedor, err := repos.Proveedores.GetByID(proveedorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", errProveedorNoEncontrado, proveedorID)
+	}
+	if err != nil {
+		return err
+	}
+	if proveedor.Eliminado() {
+		return fmt.Errorf("%w: %d (eliminado)", errProveedorNoEncontrado, proveedorID)
+	}
+	return nil
+}
+
+// responderStockInsuficiente responde con la lista de productos faltantes si
+// err es un error de stock. Retorna true si ya se envió la respuesta.
+func responderStockInsuficiente(c *gin.Context, err error) bool {
+	if !errors.Is(err, domain.ErrStockInsuficiente) {
+		return false
+	}
+
+	var stockErr *domain.StockInsuficienteError
+	if errors.As(err, &stockErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "Stock insuficiente",
+			"faltantes": stockErr.Faltantes,
+		})
+		return true
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": "Stock insuficiente"})
+	return true
+}
+
+// aplicarMovimiento aplica la variación de stock de un movimiento en su almacén
+// de forma atómica, lo registra en el kardex con los saldos resultantes y lo
+// reparte entre los lotes del almacén
+func aplicarMovimiento(repos *ports.TxRepositories, movimiento *domain.MovimientoInventario) error {
+	if movimiento.Cantidad == 0 {
+		return nil
+	}
+
+	var err error
+	if movimiento.Cantidad > 0 {
+		movimiento.SaldoAlmacen, movimiento.SaldoResultante, err = repos.Productos.IncrementStock(
+			movimiento.ProductoID, movimiento.AlmacenID, movimiento.Cantidad)
+	} else {
+		movimiento.SaldoAlmacen, movimiento.SaldoResultante, err = repos.Productos.DecrementStock(
+			movimiento.ProductoID, movimiento.AlmacenID, -movimiento.Cantidad)
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := repos.Movimientos.Create(movimiento)
+	if err != nil {
+		return err
+	}
+	movimiento.ID = id
+
+	return aplicarLotes(repos, movimiento)
+}
+
+// notificarMovimientos envía las notificaciones de stock de movimientos ya
+// confirmados: stock bajo si el saldo del almacén quedó en o por debajo del
+// stock mínimo del producto, o reabastecimiento si una entrada lo sacó del
+// stock bajo
+func notificarMovimientos(ns ports.NotificationService, productos ports.ProductoRepository, movimientos []*domain.MovimientoInventario) {
+	cache := make(map[int]*domain.Producto)
+	for _, m := range movimientos {
+		producto, ok := cache[m.ProductoID]
+		if !ok {
+			var err error
+			producto, err = productos.GetByID(m.ProductoID)
+			if err != nil {
+				log.Printf("Error al obtener el stock mínimo del producto %d: %v", m.ProductoID, err)
+				continue
+			}
+			cache[m.ProductoID] = producto
+		}
+
+		saldoAnterior := m.SaldoAlmacen - m.Cantidad
+		switch {
+		case producto.StockBajo(m.SaldoAlmacen):
+			ns.NotifyLowStock(m.ProductoID, m.AlmacenID, m.SaldoAlmacen, producto.StockMinimo)
+		case producto.StockBajo(saldoAnterior):
+			ns.NotifyRestock(m.ProductoID, m.AlmacenID, m.SaldoAlmacen, producto.StockMinimo)
+		}
+	}
+}
+
+// solicitarReabastecimiento pide evaluar el punto de reorden de los productos
+// cuya existencia disminuyó en movimientos ya confirmados
+func solicitarReabastecimiento(rs ports.ReabastecimientoService, movimientos []*domain.MovimientoInventario) {
+	var productoIDs []int
+	vistos := make(map[int]bool)
+	for _, m := range movimientos {
+		if m.Cantidad < 0 && !vistos[m.ProductoID] {
+			vistos[m.ProductoID] = true
+			productoIDs = append(productoIDs, m.ProductoID)
+		}
+	}
+
+	if len(productoIDs) > 0 {
+		rs.EvaluarProductos(productoIDs)
+	}
+}
+
+// responderTransicionInvalida responde 409 si err indica que el documento no
+// puede pasar al estado solicitado. Retorna true si ya se envió la respuesta.
+func responderTransicionInvalida(c *gin.Context, err error) bool {
+	if !errors.Is(err, domain.ErrTransicionInvalida) {
+		return false
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	return true
+}
+
+// responderDependencias escribe la respuesta 409 de una eliminación definitiva
+// que otros documentos impiden, con la lista de esos documentos. Retorna false
+// si el error es de otro tipo.
+func responderDependencias(c *gin.Context, err error) bool {
+	if !errors.Is(err, domain.ErrTieneDependencias) {
+		return false
+	}
+
+	var dependenciasErr *domain.DependenciasError
+	if errors.As(err, &dependenciasErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "El registro tiene documentos dependientes; elimínelo sin permanente=true para conservarlos",
+			"dependencias": dependenciasErr.Dependencias,
+		})
+		return true
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	return true
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseQueryOptions lee los parámetros de paginación, orden y filtros comunes
+// a los listados:
+//
+//   - limite (1 a 500, 50 por omisión) y desplazamiento
+//   - ordenar (campo de la API) y direccion (asc o desc)
+//   - estado, desde y hasta (AAAA-MM-DD o RFC3339)
+//   - id_proveedor, id_cliente e id_almacen
+//   - precio_min y precio_max, stock_menor_a y eliminados
+//
+// Cada listado aplica los filtros que tienen sentido para su entidad.
+func parseQueryOptions(c *gin.Context) (ports.QueryOptions, error) {
+	opciones := ports.QueryOptions{
+		Limite:     ports.LimitePredeterminado,
+		OrdenarPor: c.Query("ordenar"),
+		Direccion:  ports.DireccionOrden(strings.ToLower(c.Query("direccion"))),
+	}
+
+	if valor := c.Query("limite"); valor != "" {
+		limite, err := strconv.Atoi(valor)
+		if err != nil || limite < 1 || limite > ports.LimiteMaximo {
+			return opciones, fmt.Errorf("limite inválido: %s, debe estar entre 1 y %d", valor, ports.LimiteMaximo)
+		}
+		opciones.Limite = limite
+	}
+	if valor := c.Query("desplazamiento"); valor != "" {
+		offset, err := strconv.Atoi(valor)
+		if err != nil || offset < 0 {
+			return opciones, fmt.Errorf("desplazamiento inválido: %s", valor)
+		}
+		opciones.Offset = offset
+	}
+
+	filtros := &opciones.Filtros
+	filtros.Estado = c.Query("estado")
+
+	var err error
+	if filtros.Desde, filtros.Hasta, err = parseRangoFechas(c); err != nil {
+		return opciones, err
+	}
+	if filtros.ProveedorID, err = parseIDQuery(c, "id_proveedor"); err != nil {
+		return opciones, err
+	}
+	if filtros.ClienteID, err = parseIDQuery(c, "id_cliente"); err != nil {
+		return opciones, err
+	}
+	if filtros.AlmacenID, err = parseAlmacenQuery(c); err != nil {
+		return opciones, err
+	}
+	if filtros.PrecioMin, err = parseMontoQuery(c, "precio_min"); err != nil {
+		return opciones, err
+	}
+	if filtros.PrecioMax, err = parseMontoQuery(c, "precio_max"); err != nil {
+		return opciones, err
+	}
+	if filtros.PrecioMin != nil && filtros.PrecioMax != nil && filtros.PrecioMin.Cmp(*filtros.PrecioMax) > 0 {
+		return opciones, errors.New("precio_min no puede ser mayor que precio_max")
+	}
+
+	if valor := c.Query("stock_menor_a"); valor != "" {
+		stock, err := strconv.Atoi(valor)
+		if err != nil || stock < 0 {
+			return opciones, fmt.Errorf("stock_menor_a inválido: %s", valor)
+		}
+		filtros.StockMenorA = &stock
+	}
+
+	filtros.Eliminados, err = parseBoolQuery(c, "eliminados")
+	return opciones, err
+}
+
+// parseIDQuery lee un parámetro de consulta opcional con un ID. Retorna cero
+// si no se envió.
+func parseIDQuery(c *gin.Context, nombre string) (int, error) {
+	valor := c.Query(nombre)
+	if valor == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(valor)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%s inválido: %s", nombre, valor)
+	}
+	return id, nil
+}
+
+// parseMontoQuery lee un parámetro de consulta opcional con un monto. Retorna
+// nil si no se envió.
+func parseMontoQuery(c *gin.Context, nombre string) (*domain.Money, error) {
+	valor := c.Query(nombre)
+	if valor == "" {
+		return nil, nil
+	}
+
+	monto, err := domain.ParseMoney(valor)
+	if err != nil || monto.IsNegative() {
+		return nil, fmt.Errorf("%s inválido: %s", nombre, valor)
+	}
+	return &monto, nil
+}
+
+// responderListado responde una página de un listado. El total de registros
+// que cumplen los filtros va en el encabezado X-Total-Count y los enlaces a
+// las páginas siguiente y anterior en el encabezado Link.
+func responderListado(c *gin.Context, opciones ports.QueryOptions, total int, elementos interface{}) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+
+	var enlaces []string
+	if opciones.Limite > 0 {
+		if siguiente := opciones.Offset + opciones.Limite; siguiente < total {
+			enlaces = append(enlaces, enlacePagina(c, opciones.Limite, siguiente, "next"))
+		}
+		if opciones.Offset > 0 {
+			anterior := opciones.Offset - opciones.Limite
+			if anterior < 0 {
+				anterior = 0
+			}
+			enlaces = append(enlaces, enlacePagina(c, opciones.Limite, anterior, "prev"))
+		}
+	}
+	if len(enlaces) > 0 {
+		c.Header("Link", strings.Join(enlaces, ", "))
+	}
+
+	c.JSON(http.StatusOK, elementos)
+}
+
+// enlacePagina arma el enlace a otra página del listado, conservando los
+// demás parámetros de la consulta
+func enlacePagina(c *gin.Context, limite, offset int, rel string) string {
+	url := *c.Request.URL
+	parametros := url.Query()
+	parametros.Set("limite", strconv.Itoa(limite))
+	parametros.Set("desplazamiento", strconv.Itoa(offset))
+	url.RawQuery = parametros.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, url.RequestURI(), rel)
+}
+
+// responderErrorListado responde el error de un listado: 400 si la consulta
+// pide un orden o filtro inválido y 500 en otro caso
+func responderErrorListado(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrConsultaInvalida) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoteController controla las consultas de lotes y caducidades
+type LoteController struct {
+	repository   ports.LoteRepository
+	productoRepo ports.ProductoRepository
+}
+
+// NewLoteController crea un nuevo controlador de lotes
+func NewLoteController(repository ports.LoteRepository, productoRepo ports.ProductoRepository) *LoteController {
+	return &LoteController{
+		repository:   repository,
+		productoRepo: productoRepo,
+	}
+}
+
+// loteReporte es un lote junto con los días que le quedan antes de caducar
+type loteReporte struct {
+	*domain.Lote
+	DiasParaCaducar *int `json:"dias_para_caducar,omitempty"`
+	Caducado        bool `json:"caducado"`
+}
+
+// nuevoLoteReporte calcula los días para caducar de cada lote
+func nuevoLoteReporte(lotes []*domain.Lote) []loteReporte {
+	ahora := time.Now()
+	reporte := make([]loteReporte, 0, len(lotes))
+	for _, lote := range lotes {
+		dias := lote.DiasParaCaducar(ahora)
+		reporte = append(reporte, loteReporte{
+			Lote:            lote,
+			DiasParaCaducar: dias,
+			Caducado:        dias != nil && *dias < 0,
+		})
+	}
+	return reporte
+}
+
+// GetPorCaducar reporta los lotes con existencia que caducan en los próximos
+// días indicados en el parámetro dias (30 por omisión), incluidos los ya
+// caducados, en orden FEFO. Acepta id_almacen para filtrar por almacén.
+func (lc *LoteController) GetPorCaducar(c *gin.Context) {
+	dias := domain.DiasAvisoCaducidadPredeterminado
+	if valor := c.Query("dias"); valor != "" {
+		var err error
+		dias, err = strconv.Atoi(valor)
+		if err != nil || dias < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dias inválido: " + valor})
+			return
+		}
+	}
+
+	almacenID, err := parseAlmacenQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lotes, err := lc.repository.GetPorCaducar(time.Now().AddDate(0, 0, dias), almacenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, nuevoLoteReporte(lotes))
+}
+
+// GetByID obtiene un lote por su ID
+func (lc *LoteController) GetByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	lote, err := lc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lote no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nuevoLoteReporte([]*domain.Lote{lote})[0])
+}
+
+// GetByProducto obtiene los lotes con existencia de un producto en orden FEFO.
+// Acepta id_almacen para filtrar por almacén.
+func (lc *LoteController) GetByProducto(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	almacenID, err := parseAlmacenQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := lc.productoRepo.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	lotes, err := lc.repository.GetByProducto(id, almacenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, nuevoLoteReporte(lotes))
+}
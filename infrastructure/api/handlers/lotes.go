@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"fmt"
+	"sort"
+)
+
+// aplicarLotes reparte entre los lotes del almacén un movimiento ya registrado
+// en el kardex. Las entradas crean los lotes indicados en LotesEntrada o, si
+// no traen lotes, devuelven a sus lotes lo que el mismo documento había
+// sacado (por ejemplo al cancelar una venta). Las salidas primero retiran lo
+// que el mismo documento había metido a un lote (por ejemplo al cancelar una
+// orden recibida) y después consumen los lotes en orden FEFO: primero el que
+// caduca antes. Lo que no alcanza a cubrirse con lotes afecta a la existencia
+// sin lote.
+func aplicarLotes(repos *ports.TxRepositories, movimiento *domain.MovimientoInventario) error {
+	if movimiento.Cantidad > 0 {
+		if len(movimiento.LotesEntrada) > 0 {
+			return crearLotesEntrada(repos, movimiento)
+		}
+		return restaurarLotes(repos, movimiento)
+	}
+	return consumirLotes(repos, movimiento)
+}
+
+// crearLotesEntrada crea los lotes que trae una entrada
+func crearLotesEntrada(repos *ports.TxRepositories, movimiento *domain.MovimientoInventario) error {
+	total := 0
+	for _, lote := range movimiento.LotesEntrada {
+		total += lote.CantidadInicial
+	}
+	if total > movimiento.Cantidad {
+		return fmt.Errorf("%w: los lotes suman %d unidades y la entrada es de %d",
+			domain.ErrRecepcionInvalida, total, movimiento.Cantidad)
+	}
+
+	for _, lote := range movimiento.LotesEntrada {
+		if lote.CantidadInicial <= 0 {
+			continue
+		}
+		lote.ProductoID = movimiento.ProductoID
+		lote.AlmacenID = movimiento.AlmacenID
+		lote.Existencia = lote.CantidadInicial
+		lote.FechaRecepcion = movimiento.Fecha
+
+		id, err := repos.Lotes.Create(lote)
+		if err != nil {
+			return err
+		}
+		lote.ID = id
+
+		if err := registrarMovimientoLote(repos, movimiento, lote, lote.CantidadInicial); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restaurarLotes devuelve una entrada a los lotes de los que el mismo
+// documento sacó unidades, hasta lo que sacó de cada uno
+func restaurarLotes(repos *ports.TxRepositories, movimiento *domain.MovimientoInventario) error {
+	if movimiento.DocumentoID == nil {
+		return nil
+	}
+
+	netos, err := repos.Lotes.GetNetoPorDocumento(movimiento.TipoDocumento, *movimiento.DocumentoID,
+		movimiento.ProductoID, movimiento.AlmacenID)
+	if err != nil {
+		return err
+	}
+
+	loteIDs := make([]int, 0, len(netos))
+	for loteID, neto := range netos {
+		if neto < 0 {
+			loteIDs = append(loteIDs, loteID)
+		}
+	}
+	sort.Ints(loteIDs)
+
+	restante := movimiento.Cantidad
+	for _, loteID := range loteIDs {
+		if restante == 0 {
+			break
+		}
+		cantidad := min(restante, -netos[loteID])
+
+		lote, err := repos.Lotes.GetByID(loteID)
+		if err != nil {
+			return err
+		}
+		if err := repos.Lotes.AjustarExistencia(loteID, cantidad); err != nil {
+			return err
+		}
+		if err := registrarMovimientoLote(repos, movimiento, lote, cantidad); err != nil {
+			return err
+		}
+		restante -= cantidad
+	}
+
+	return nil
+}
+
+// consumirLotes descuenta una salida de los lotes disponibles del almacén
+func consumirLotes(repos *ports.TxRepositories, movimiento *domain.MovimientoInventario) error {
+	lotes, err := repos.Lotes.GetDisponiblesForUpdate(movimiento.ProductoID, movimiento.AlmacenID)
+	if err != nil || len(lotes) == 0 {
+		return err
+	}
+
+	// Los lotes que metió el mismo documento se retiran antes que el resto
+	var netos map[int]int
+	if movimiento.DocumentoID != nil {
+		netos, err = repos.Lotes.GetNetoPorDocumento(movimiento.TipoDocumento, *movimiento.DocumentoID,
+			movimiento.ProductoID, movimiento.AlmacenID)
+		if err != nil {
+			return err
+		}
+	}
+
+	restante := -movimiento.Cantidad
+	consumir := func(lote *domain.Lote, limite int) error {
+		cantidad := min(restante, lote.Existencia, limite)
+		if cantidad <= 0 {
+			return nil
+		}
+		if err := repos.Lotes.AjustarExistencia(lote.ID, -cantidad); err != nil {
+			return err
+		}
+		lote.Existencia -= cantidad
+		restante -= cantidad
+		return registrarMovimientoLote(repos, movimiento, lote, -cantidad)
+	}
+
+	for _, lote := range lotes {
+		if neto := netos[lote.ID]; neto > 0 {
+			if err := consumir(lote, neto); err != nil {
+				return err
+			}
+		}
+	}
+	for _, lote := range lotes {
+		if err := consumir(lote, lote.Existencia); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// registrarMovimientoLote guarda la parte de un movimiento que afectó a un lote
+func registrarMovimientoLote(repos *ports.TxRepositories, movimiento *domain.MovimientoInventario, lote *domain.Lote, cantidad int) error {
+	movimientoLote := &domain.MovimientoLote{
+		LoteID:       lote.ID,
+		MovimientoID: movimiento.ID,
+		NumeroLote:   lote.NumeroLote,
+		Cantidad:     cantidad,
+	}
+
+	id, err := repos.Lotes.CreateMovimiento(movimientoLote)
+	if err != nil {
+		return err
+	}
+	movimientoLote.ID = id
+
+	movimiento.Lotes = append(movimiento.Lotes, movimientoLote)
+	return nil
+}
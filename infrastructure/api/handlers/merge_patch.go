@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errPatchInvalido indica que un merge patch no se puede aplicar al recurso
+var errPatchInvalido = errors.New("parche inválido")
+
+// Tipos de contenido que aceptan las rutas PATCH
+const (
+	contenidoMergePatch = "application/merge-patch+json"
+	contenidoJSON       = "application/json"
+)
+
+// mergePatch es el cuerpo de una solicitud PATCH en formato JSON Merge Patch
+// (RFC 7396): cada miembro reemplaza el campo del mismo nombre, un objeto se
+// combina con el objeto actual y null quita el valor.
+type mergePatch map[string]json.RawMessage
+
+// campos retorna los nombres de los campos que modifica el parche, ordenados
+func (p mergePatch) campos() []string {
+	campos := make([]string, 0, len(p))
+	for campo := range p {
+		campos = append(campos, campo)
+	}
+	sort.Strings(campos)
+	return campos
+}
+
+// incluye indica si el parche modifica el campo
+func (p mergePatch) incluye(campo string) bool {
+	_, ok := p[campo]
+	return ok
+}
+
+// leerMergePatch lee el cuerpo de una solicitud PATCH. editables son los
+// campos que se pueden modificar; el valor indica si aceptan null. Responde
+// 415 si el cuerpo no es JSON y 400 si no es un objeto, si toca campos de sólo
+// lectura o desconocidos, o si anula un campo obligatorio. Retorna false si ya
+// se envió la respuesta.
+func leerMergePatch(c *gin.Context, editables map[string]bool) (mergePatch, bool) {
+	if tipo := c.ContentType(); tipo != contenidoMergePatch && tipo != contenidoJSON {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": fmt.Sprintf("El cuerpo debe enviarse como %s", contenidoMergePatch),
+		})
+		return nil, false
+	}
+
+	cuerpo, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	var parche mergePatch
+	if !bytes.HasPrefix(bytes.TrimSpace(cuerpo), []byte("{")) || json.Unmarshal(cuerpo, &parche) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El cuerpo debe ser un objeto JSON"})
+		return nil, false
+	}
+
+	var noEditables, obligatorios []string
+	for _, campo := range parche.campos() {
+		anulable, editable := editables[campo]
+		switch {
+		case !editable:
+			noEditables = append(noEditables, campo)
+		case !anulable && string(bytes.TrimSpace(parche[campo])) == "null":
+			obligatorios = append(obligatorios, campo)
+		}
+	}
+	if len(noEditables) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Los campos son de sólo lectura o no existen: " + strings.Join(noEditables, ", "),
+			"campos": noEditables,
+		})
+		return nil, false
+	}
+	if len(obligatorios) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Los campos no pueden quitarse con null: " + strings.Join(obligatorios, ", "),
+			"campos": obligatorios,
+		})
+		return nil, false
+	}
+
+	return parche, true
+}
+
+// aplicarMergePatch aplica el parche al estado actual de un recurso y deja el
+// resultado en destino, que debe ser un recurso vacío del mismo tipo.
+// Retorna errPatchInvalido si algún valor no tiene el tipo del campo.
+func aplicarMergePatch(actual interface{}, parche mergePatch, destino interface{}) error {
+	documento, err := json.Marshal(actual)
+	if err != nil {
+		return err
+	}
+	objetivo, err := decodificarJSON(documento)
+	if err != nil {
+		return err
+	}
+
+	for campo, valor := range parche {
+		cambio, err := decodificarJSON(valor)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errPatchInvalido, err)
+		}
+		objetivo = combinarJSON(objetivo, map[string]interface{}{campo: cambio})
+	}
+
+	resultado, err := json.Marshal(objetivo)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(resultado, destino); err != nil {
+		return fmt.Errorf("%w: %s", errPatchInvalido, err)
+	}
+	return nil
+}
+
+// decodificarJSON decodifica un documento conservando el texto de los números
+// para no perder precisión en los montos
+func decodificarJSON(documento []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(documento))
+	decoder.UseNumber()
+
+	var valor interface{}
+	err := decoder.Decode(&valor)
+	return valor, err
+}
+
+// combinarJSON implementa el algoritmo MergePatch de la sección 2 del RFC 7396
+func combinarJSON(objetivo, parche interface{}) interface{} {
+	cambios, ok := parche.(map[string]interface{})
+	if !ok {
+		return parche
+	}
+
+	resultado, ok := objetivo.(map[string]interface{})
+	if !ok {
+		resultado = map[string]interface{}{}
+	}
+	for campo, valor := range cambios {
+		if valor == nil {
+			delete(resultado, campo)
+			continue
+		}
+		resultado[campo] = combinarJSON(resultado[campo], valor)
+	}
+	return resultado
+}
+
+// responderPatchInvalido responde 400 si err es un parche o un descuento
+// inválidos, o un cliente o proveedor inexistente. Retorna true si ya se envió
+// la respuesta.
+func responderPatchInvalido(c *gin.Context, err error) bool {
+	if !errors.Is(err, errPatchInvalido) && !errors.Is(err, errClienteNoEncontrado) &&
+		!errors.Is(err, errProveedorNoEncontrado) && !errors.Is(err, domain.ErrDescuentoInvalido) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	return true
+}
@@ -3,6 +3,9 @@ package handlers
 import (
 	"ActividadDesempenioAPIz/core/domain"
 	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,13 +13,35 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// errOrdenNoEncontrada indica que la orden referenciada no existe
+var errOrdenNoEncontrada = errors.New("orden no encontrada")
+
+// errPrecioNoDisponible indica que una línea no trae precio y el proveedor no
+// tiene el producto en su catálogo
+var errPrecioNoDisponible = errors.New("precio_unitario requerido: el producto no está en el catálogo del proveedor")
+
+// errEstadoNoEditable indica que una actualización intentó cambiar el estado de
+// la orden fuera del endpoint de transición
+var errEstadoNoEditable = errors.New("el estado no se modifica con PUT")
+
+// lineaOrdenRequest es una línea de orden enviada por el cliente. Sin
+// precio_unitario toma el costo del catálogo del proveedor.
+type lineaOrdenRequest struct {
+	ProductoID     int           `json:"id_producto" binding:"required"`
+	Cantidad       int           `json:"cantidad" binding:"required,gt=0"`
+	PrecioUnitario *domain.Money `json:"precio_unitario"`
+}
+
 // OrdenProveedorController controla las solicitudes relacionadas con órdenes de proveedor
 type OrdenProveedorController struct {
 	repository          ports.OrdenProveedorRepository
 	detallesRepo        ports.DetallesOrdenRepository
 	proveedorRepo       ports.ProveedorRepository
 	productoRepo        ports.ProductoRepository
+	historialRepo       ports.HistorialEstadoRepository
+	unitOfWork          ports.UnitOfWork
 	notificationService ports.NotificationService
+	reabastecimiento    ports.ReabastecimientoService
 }
 
 // NewOrdenProveedorController crea un nuevo controlador de órdenes de proveedor
@@ -25,26 +50,40 @@ func NewOrdenProveedorController(
 	detallesRepo ports.DetallesOrdenRepository,
 	proveedorRepo ports.ProveedorRepository,
 	productoRepo ports.ProductoRepository,
+	historialRepo ports.HistorialEstadoRepository,
+	unitOfWork ports.UnitOfWork,
 	notificationService ports.NotificationService,
+	reabastecimiento ports.ReabastecimientoService,
 ) *OrdenProveedorController {
 	return &OrdenProveedorController{
 		repository:          repository,
 		detallesRepo:        detallesRepo,
 		proveedorRepo:       proveedorRepo,
 		productoRepo:        productoRepo,
+		historialRepo:       historialRepo,
+		unitOfWork:          unitOfWork,
 		notificationService: notificationService,
+		reabastecimiento:    reabastecimiento,
 	}
 }
 
-// GetAll obtiene todas las órdenes de proveedor
+// GetAll obtiene una página de las órdenes de proveedor. Se ordena por id,
+// fecha, estado o total y se filtra con estado, desde, hasta, id_proveedor,
+// id_almacen, precio_min y precio_max (sobre el total).
 func (c *OrdenProveedorController) GetAll(ctx *gin.Context) {
-	ordenes, err := c.repository.GetAll()
+	opciones, err := parseQueryOptions(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ordenes, total, err := c.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, ordenes)
+	responderListado(ctx, opciones, total, ordenes)
 }
 
 // GetByID obtiene una orden de proveedor por su ID
@@ -65,15 +104,14 @@ func (c *OrdenProveedorController) GetByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, orden)
 }
 
-// Create crea una nueva orden de proveedor
+// Create crea una nueva orden de proveedor. Las líneas sin precio_unitario
+// toman el costo del catálogo del proveedor; las que no coinciden con el
+// catálogo se crean igual pero se reportan en advertencias.
 func (c *OrdenProveedorController) Create(ctx *gin.Context) {
 	var createOrdenRequest struct {
-		ProveedorID int `json:"id_proveedor" binding:"required"`
-		Detalles    []struct {
-			ProductoID     int     `json:"id_producto" binding:"required"`
-			Cantidad       int     `json:"cantidad" binding:"required"`
-			PrecioUnitario float64 `json:"precio_unitario" binding:"required"`
-		} `json:"detalles" binding:"required"`
+		ProveedorID int                 `json:"id_proveedor" binding:"required"`
+		AlmacenID   int                 `json:"id_almacen"`
+		Detalles    []lineaOrdenRequest `json:"detalles" binding:"required,min=1,dive"`
 	}
 
 	if err := ctx.ShouldBindJSON(&createOrdenRequest); err != nil {
@@ -81,63 +119,101 @@ func (c *OrdenProveedorController) Create(ctx *gin.Context) {
 		return
 	}
 
-	// Verificar que el proveedor existe
-	_, err := c.proveedorRepo.GetByID(createOrdenRequest.ProveedorID)
+	for _, detalleRequest := range createOrdenRequest.Detalles {
+		if detalleRequest.PrecioUnitario != nil && detalleRequest.PrecioUnitario.IsNegative() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "El precio unitario no puede ser negativo"})
+			return
+		}
+	}
+
+	// Verificar que el proveedor existe y no está eliminado
+	proveedor, err := c.proveedorRepo.GetByID(createOrdenRequest.ProveedorID)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Proveedor no encontrado"})
 		return
 	}
+	if proveedor.Eliminado() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "El proveedor está eliminado"})
+		return
+	}
 
-	// Crear la orden
+	// Crear la orden, sus detalles y el total en una sola transacción
 	orden := &domain.OrdenProveedor{
 		ProveedorID: createOrdenRequest.ProveedorID,
-		Estado:      "pendiente",
+		Estado:      domain.OrdenPendiente,
 		FechaOrden:  time.Now().Format("2006-01-02 15:04:05"),
-		Total:       0,
+		Total:       domain.NewMoney(0),
 	}
+	usuario := usuarioActual(ctx)
+
+	var ordenID int
+	var advertencias []domain.AdvertenciaOrden
+	err = c.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		advertencias = nil
+		orden.AlmacenID, err = validarAlmacen(repos, createOrdenRequest.AlmacenID)
+		if err != nil {
+			return err
+		}
 
-	ordenID, err := c.repository.Create(orden)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+		ordenID, err = repos.Ordenes.Create(orden)
+		if err != nil {
+			return err
+		}
 
-	// Crear los detalles de la orden y calcular el total
-	var totalFloat float64
-	for _, detalleRequest := range createOrdenRequest.Detalles {
-		detalle := &domain.DetallesOrden{
-			OrdenProveedorID: ordenID,
-			ProductoID:       detalleRequest.ProductoID,
-			Cantidad:         detalleRequest.Cantidad,
-			PrecioUnitario:   detalleRequest.PrecioUnitario,
-			Subtotal:         float64(detalleRequest.Cantidad) * detalleRequest.PrecioUnitario,
+		historial := domain.NewHistorialEstado(ordenID, "", string(orden.Estado), usuario, "")
+		if _, err := repos.Historial.Create(domain.DocumentoOrdenProveedor, historial); err != nil {
+			return err
 		}
 
-		_, err := c.detallesRepo.Create(detalle)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		for _, detalleRequest := range createOrdenRequest.Detalles {
+			detalle, advertenciasLinea, err := armarLineaOrden(repos, orden.ProveedorID, ordenID, detalleRequest)
+			if err != nil {
+				return err
+			}
+			advertencias = append(advertencias, advertenciasLinea...)
+
+			if _, err := repos.DetallesOrden.Create(detalle); err != nil {
+				return err
+			}
+
+			orden.Total = orden.Total.Add(detalle.Subtotal)
 		}
 
-		totalFloat += detalle.Subtotal
-	}
+		// Actualizar el total de la orden
+		orden.ID = ordenID
+		if err := repos.Ordenes.Update(orden); err != nil {
+			return err
+		}
 
-	// Actualizar el total de la orden
-	orden.ID = ordenID
-	orden.Total = int(totalFloat)
-	c.repository.Update(orden)
+		return auditar(repos, ctx, domain.AccionCrear, domain.EntidadOrdenProveedor, ordenID, nil, orden)
+	})
+	if errors.Is(err, errAlmacenNoEncontrado) || errors.Is(err, errPrecioNoDisponible) ||
+		errors.Is(err, errProductoNoEncontrado) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Enviar notificación de nueva orden
-	c.notificationService.NotifyNewOrdenProveedor(ordenID, totalFloat)
+	c.notificationService.NotifyNewOrdenProveedor(ordenID, orden.Total)
 
-	ctx.JSON(http.StatusCreated, gin.H{
+	respuesta := gin.H{
 		"id_orden": ordenID,
-		"total":    totalFloat,
+		"total":    orden.Total,
 		"mensaje":  "Orden creada correctamente",
-	})
+	}
+	if len(advertencias) > 0 {
+		respuesta["advertencias"] = advertencias
+	}
+	ctx.JSON(http.StatusCreated, respuesta)
 }
 
-// Update actualiza una orden de proveedor existente
+// Update actualiza una orden de proveedor existente. El estado, el almacén, la
+// fecha y el total no se modifican.
 func (c *OrdenProveedorController) Update(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -152,8 +228,54 @@ func (c *OrdenProveedorController) Update(ctx *gin.Context) {
 		return
 	}
 
-	orden.ID = id
-	if err := c.repository.Update(&orden); err != nil {
+	var actual *domain.OrdenProveedor
+	err = c.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		actual, err = repos.Ordenes.GetByIDForUpdate(id)
+		if err != nil {
+			return errOrdenNoEncontrada
+		}
+
+		// El estado sólo cambia mediante el endpoint de transición
+		if orden.Estado != "" && orden.Estado != actual.Estado {
+			return errEstadoNoEditable
+		}
+
+		// Una orden puede conservar un proveedor que se eliminó después, pero
+		// no asignarse a uno eliminado
+		if orden.ProveedorID != actual.ProveedorID {
+			if err := validarProveedorActivo(repos, orden.ProveedorID); err != nil {
+				return err
+			}
+		}
+
+		// El total se calcula con los detalles y la fecha es la de creación
+		orden.ID = id
+		orden.Estado = actual.Estado
+		orden.AlmacenID = actual.AlmacenID
+		orden.FechaOrden = actual.FechaOrden
+		orden.Total = actual.Total
+		if err := repos.Ordenes.Update(&orden); err != nil {
+			return err
+		}
+
+		return auditar(repos, ctx, domain.AccionActualizar, domain.EntidadOrdenProveedor, id, actual, orden)
+	})
+	if errors.Is(err, errOrdenNoEncontrada) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Orden no encontrada"})
+		return
+	}
+	if errors.Is(err, errEstadoNoEditable) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": "El estado sólo puede cambiarse mediante POST /api/ordenes/:id/estado",
+		})
+		return
+	}
+	if errors.Is(err, errProveedorNoEncontrado) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -161,8 +283,18 @@ func (c *OrdenProveedorController) Update(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, orden)
 }
 
-// CancelOrden cancela una orden de proveedor
-func (c *OrdenProveedorController) CancelOrden(ctx *gin.Context) {
+// camposEditablesOrden son los campos que acepta PATCH /api/ordenes/:id; el
+// valor indica si aceptan null para quitar su valor. El estado cambia mediante
+// POST /api/ordenes/:id/estado y el total se calcula con los detalles.
+var camposEditablesOrden = map[string]bool{
+	"id_proveedor": false,
+}
+
+// Patch modifica una orden de proveedor con un JSON Merge Patch (RFC 7396).
+// Sólo valida y actualiza los campos enviados. Responde 400 si el proveedor
+// no existe o está eliminado, o si el parche toca campos de sólo lectura como
+// id_orden_proveedor, fecha_orden, estado, id_almacen o total.
+func (c *OrdenProveedorController) Patch(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -170,22 +302,71 @@ func (c *OrdenProveedorController) CancelOrden(ctx *gin.Context) {
 		return
 	}
 
-	// Obtener la orden actual
-	orden, err := c.repository.GetByID(id)
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Orden no encontrada"})
+	parche, ok := leerMergePatch(ctx, camposEditablesOrden)
+	if !ok {
 		return
 	}
 
-	// Actualizar el estado a cancelado
-	err = c.repository.UpdateEstado(id, "cancelada")
+	var actual, orden *domain.OrdenProveedor
+	err = c.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		actual, err = repos.Ordenes.GetByIDForUpdate(id)
+		if err != nil {
+			return errOrdenNoEncontrada
+		}
+
+		orden = &domain.OrdenProveedor{}
+		if err := aplicarMergePatch(actual, parche, orden); err != nil {
+			return err
+		}
+		if parche.incluye("id_proveedor") {
+			if err := validarProveedorActivo(repos, orden.ProveedorID); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.Ordenes.UpdateCampos(orden, parche.campos()); err != nil {
+			return err
+		}
+
+		return auditar(repos, ctx, domain.AccionActualizar, domain.EntidadOrdenProveedor, id, actual, orden)
+	})
+	if errors.Is(err, errOrdenNoEncontrada) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Orden no encontrada"})
+		return
+	}
+	if responderPatchInvalido(ctx, err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Notificar la cancelación de la orden
-	c.notificationService.NotifyCanceledOrdenProveedor(id, float64(orden.Total), orden.ProveedorID)
+	ctx.JSON(http.StatusOK, orden)
+}
+
+// CancelOrden cancela una orden de proveedor. Si la orden ya fue recibida total
+// o parcialmente se retiran del inventario las unidades que entraron con ella.
+func (c *OrdenProveedorController) CancelOrden(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	cambio, err := c.cambiarEstado(ctx, id, domain.OrdenCancelada, "")
+	if c.responderErrorEstado(ctx, err) {
+		return
+	}
+
+	orden := cambio.orden
+
+	// Notificar la cancelación de la orden y los cambios de stock
+	c.notificationService.NotifyCanceledOrdenProveedor(id, orden.Total, orden.ProveedorID)
+	notificarMovimientos(c.notificationService, c.productoRepo, cambio.movimientos)
+	solicitarReabastecimiento(c.reabastecimiento, cambio.movimientos)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message":  "Orden cancelada correctamente",
@@ -211,7 +392,9 @@ func (c *OrdenProveedorController) GetDetallesOrden(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, detalles)
 }
 
-// AddDetalleOrden añade un detalle a una orden
+// AddDetalleOrden añade un detalle a una orden. Como al crear la orden, sin
+// precio_unitario toma el costo del catálogo del proveedor y las diferencias
+// con el catálogo se reportan en advertencias.
 func (c *OrdenProveedorController) AddDetalleOrden(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	ordenID, err := strconv.Atoi(idParam)
@@ -220,37 +403,108 @@ func (c *OrdenProveedorController) AddDetalleOrden(ctx *gin.Context) {
 		return
 	}
 
-	var detalle domain.DetallesOrden
-	if err := ctx.ShouldBindJSON(&detalle); err != nil {
+	var detalleRequest lineaOrdenRequest
+	if err := ctx.ShouldBindJSON(&detalleRequest); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	detalle.OrdenProveedorID = ordenID
-	detalle.Subtotal = float64(detalle.Cantidad) * detalle.PrecioUnitario
+	if detalleRequest.PrecioUnitario != nil && detalleRequest.PrecioUnitario.IsNegative() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "El precio unitario no puede ser negativo"})
+		return
+	}
 
-	id, err := c.detallesRepo.Create(&detalle)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Insertar el detalle y actualizar el total de la orden de forma atómica
+	var antes, orden *domain.OrdenProveedor
+	var detalle *domain.DetallesOrden
+	var advertencias []domain.AdvertenciaOrden
+	err = c.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		orden, err = repos.Ordenes.GetByIDForUpdate(ordenID)
+		if err != nil {
+			return errOrdenNoEncontrada
+		}
+		copia := *orden
+		antes = &copia
+
+		// Una orden recibida o cancelada ya no admite líneas nuevas
+		if !orden.Estado.AdmiteDetalles() {
+			return fmt.Errorf("%w: no se pueden agregar productos a una orden %s", domain.ErrTransicionInvalida, orden.Estado)
+		}
+
+		detalle, advertencias, err = armarLineaOrden(repos, orden.ProveedorID, ordenID, detalleRequest)
+		if err != nil {
+			return err
+		}
+
+		detalle.ID, err = repos.DetallesOrden.Create(detalle)
+		if err != nil {
+			return err
+		}
+
+		orden.Total = orden.Total.Add(detalle.Subtotal)
+		if err := repos.Ordenes.Update(orden); err != nil {
+			return err
+		}
+
+		return auditar(repos, ctx, domain.AccionActualizar, domain.EntidadOrdenProveedor, ordenID, antes,
+			conLineaAgregada(orden, detalle))
+	})
+	if errors.Is(err, errProductoNoEncontrado) || errors.Is(err, errPrecioNoDisponible) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.responderErrorEstado(ctx, err) {
 		return
 	}
 
-	detalle.ID = id
+	ctx.JSON(http.StatusCreated, struct {
+		*domain.DetallesOrden
+		Advertencias []domain.AdvertenciaOrden `json:"advertencias,omitempty"`
+	}{detalle, advertencias})
+}
 
-	// Actualizar el total de la orden
-	orden, err := c.repository.GetByID(ordenID)
+// armarLineaOrden arma una línea de la orden de un proveedor. El producto debe
+// estar activo; sin precio_unitario se usa el costo del catálogo del proveedor
+// y, si no lo tiene, retorna errPrecioNoDisponible. También retorna las
+// advertencias de la línea contra el catálogo.
+func armarLineaOrden(repos *ports.TxRepositories, proveedorID, ordenID int, linea lineaOrdenRequest) (*domain.DetallesOrden, []domain.AdvertenciaOrden, error) {
+	if err := validarProductoActivo(repos, linea.ProductoID); err != nil {
+		return nil, nil, err
+	}
+
+	entrada, err := repos.Catalogo.Get(proveedorID, linea.ProductoID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la orden"})
-		return
+		return nil, nil, err
 	}
 
-	orden.Total += int(detalle.Subtotal)
-	c.repository.Update(orden)
+	var precio domain.Money
+	switch {
+	case linea.PrecioUnitario != nil:
+		precio = *linea.PrecioUnitario
+	case entrada != nil:
+		precio = entrada.Costo
+	default:
+		return nil, nil, fmt.Errorf("%w (producto %d)", errPrecioNoDisponible, linea.ProductoID)
+	}
 
-	ctx.JSON(http.StatusCreated, detalle)
+	detalle := &domain.DetallesOrden{
+		OrdenProveedorID: ordenID,
+		ProductoID:       linea.ProductoID,
+		Cantidad:         linea.Cantidad,
+		PrecioUnitario:   precio,
+		Subtotal:         precio.Mul(linea.Cantidad),
+	}
+	return detalle, domain.RevisarLineaOrden(entrada, linea.ProductoID, linea.Cantidad, precio), nil
 }
 
-// RecibirOrden marca una orden como recibida y actualiza el inventario
+// RecibirOrden registra la recepción de mercancía de una orden. El cuerpo es
+// opcional: sin detalles se recibe todo lo pendiente; con detalles sólo se
+// reciben las cantidades indicadas por línea y la orden queda
+// parcialmente_recibida hasta que todas sus líneas se completen. La mercancía
+// entra al almacén de la orden. Cada línea puede indicar numero_lote y
+// fecha_caducidad (AAAA-MM-DD); una misma línea puede repetirse para recibir
+// varios lotes.
 func (c *OrdenProveedorController) RecibirOrden(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -259,45 +513,480 @@ func (c *OrdenProveedorController) RecibirOrden(ctx *gin.Context) {
 		return
 	}
 
-	// Obtener la orden actual
-	orden, err := c.repository.GetByID(id)
+	var recepcionRequest struct {
+		Detalles []struct {
+			DetalleID      int    `json:"id_detalle_orden" binding:"required"`
+			Cantidad       int    `json:"cantidad" binding:"required,min=1"`
+			NumeroLote     string `json:"numero_lote"`
+			FechaCaducidad string `json:"fecha_caducidad"`
+		} `json:"detalles" binding:"dive"`
+		Comentario string `json:"comentario"`
+	}
+	if err := ctx.ShouldBindJSON(&recepcionRequest); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cantidades map[int]int
+	lotes := make(map[int][]*domain.Lote)
+	if len(recepcionRequest.Detalles) > 0 {
+		cantidades = make(map[int]int, len(recepcionRequest.Detalles))
+		for _, detalle := range recepcionRequest.Detalles {
+			cantidades[detalle.DetalleID] += detalle.Cantidad
+
+			if detalle.NumeroLote == "" {
+				if detalle.FechaCaducidad != "" {
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "fecha_caducidad requiere numero_lote"})
+					return
+				}
+				continue
+			}
+
+			lote := &domain.Lote{
+				NumeroLote:       detalle.NumeroLote,
+				CantidadInicial:  detalle.Cantidad,
+				OrdenProveedorID: &id,
+			}
+			if detalle.FechaCaducidad != "" {
+				caducidad, err := time.Parse("2006-01-02", detalle.FechaCaducidad)
+				if err != nil {
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "fecha_caducidad inválida: " + detalle.FechaCaducidad})
+					return
+				}
+				lote.FechaCaducidad = &caducidad
+			}
+			lotes[detalle.DetalleID] = append(lotes[detalle.DetalleID], lote)
+		}
+	}
+
+	cambio, err := c.recibir(ctx, id, cantidades, lotes, recepcionRequest.Comentario)
+	if c.responderErrorEstado(ctx, err) {
+		return
+	}
+
+	orden := cambio.orden
+
+	// Notificar los productos que siguen con stock bajo o que se reabastecieron
+	notificarMovimientos(c.notificationService, c.productoRepo, cambio.movimientos)
+
+	mensaje := "Orden recibida correctamente"
+	if orden.Estado == domain.OrdenParcialmenteRecibida {
+		mensaje = "Recepción parcial registrada correctamente"
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  mensaje,
+		"orden_id": id,
+		"estado":   orden.Estado,
+		"detalles": cambio.detalles,
+		"lotes":    lotesCreados(cambio.movimientos),
+	})
+}
+
+// actualizarCostoPromedio pondera el costo promedio del producto con la
+// entrada ya aplicada. La existencia anterior es la total del producto, sin
+// importar el almacén que recibe.
+func actualizarCostoPromedio(repos *ports.TxRepositories, movimiento *domain.MovimientoInventario, costo domain.Money) error {
+	producto, err := repos.Productos.GetByID(movimiento.ProductoID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Orden no encontrada"})
+		return err
+	}
+
+	existenciaAnterior := movimiento.SaldoResultante - movimiento.Cantidad
+	nuevo := domain.CostoPromedioPonderado(existenciaAnterior, producto.CostoPromedio, movimiento.Cantidad, costo)
+	if nuevo.Cmp(producto.CostoPromedio) == 0 {
+		return nil
+	}
+	return repos.Productos.SetCostoPromedio(producto.ID, nuevo)
+}
+
+// lotesCreados retorna los lotes que crearon las entradas de los movimientos
+func lotesCreados(movimientos []*domain.MovimientoInventario) []*domain.Lote {
+	lotes := []*domain.Lote{}
+	for _, movimiento := range movimientos {
+		lotes = append(lotes, movimiento.LotesEntrada...)
+	}
+	return lotes
+}
+
+// CerrarPendientes cierra una orden parcialmente recibida cancelando lo que
+// quedó pendiente en cada línea (backorder cancelado). La orden pasa a
+// recibida con lo que efectivamente entró al inventario.
+func (c *OrdenProveedorController) CerrarPendientes(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
 
-	// Verificar que la orden está pendiente
-	if orden.Estado != "pendiente" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "La orden no está en estado pendiente"})
+	var cierreRequest struct {
+		Comentario string `json:"comentario"`
+	}
+	if err := ctx.ShouldBindJSON(&cierreRequest); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Actualizar el estado a recibida
-	err = c.repository.UpdateEstado(id, "recibida")
+	cambio, err := c.cerrarPendientes(ctx, id, cierreRequest.Comentario)
+	if c.responderErrorEstado(ctx, err) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Pendientes de la orden cancelados correctamente",
+		"orden_id": id,
+		"estado":   cambio.orden.Estado,
+		"detalles": cambio.detalles,
+	})
+}
+
+// CambiarEstado cambia el estado de una orden respetando las transiciones
+// permitidas. Responde 409 si la transición no es válida.
+func (c *OrdenProveedorController) CambiarEstado(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var estadoRequest struct {
+		Estado     domain.EstadoOrden `json:"estado" binding:"required"`
+		Comentario string             `json:"comentario"`
+	}
+	if err := ctx.ShouldBindJSON(&estadoRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cambio, err := c.cambiarEstado(ctx, id, estadoRequest.Estado, estadoRequest.Comentario)
+	if c.responderErrorEstado(ctx, err) {
+		return
+	}
+
+	orden := cambio.orden
+	if orden.Estado == domain.OrdenCancelada {
+		c.notificationService.NotifyCanceledOrdenProveedor(id, orden.Total, orden.ProveedorID)
+	}
+	notificarMovimientos(c.notificationService, c.productoRepo, cambio.movimientos)
+	solicitarReabastecimiento(c.reabastecimiento, cambio.movimientos)
+
+	ctx.JSON(http.StatusOK, orden)
+}
+
+// GetHistorial obtiene el historial de cambios de estado de una orden
+func (c *OrdenProveedorController) GetHistorial(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	historial, err := c.historialRepo.GetByDocumento(domain.DocumentoOrdenProveedor, id)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Obtener detalles de la orden para actualizar el stock
-	detalles, err := c.detallesRepo.GetByOrdenID(id)
-	if err == nil {
+	ctx.JSON(http.StatusOK, historial)
+}
+
+// cambioOrden es el resultado de un cambio de estado, una recepción o un
+// cierre de pendientes de una orden
+type cambioOrden struct {
+	antes       *domain.OrdenProveedor // la orden como estaba al bloquearla
+	orden       *domain.OrdenProveedor
+	detalles    []*domain.DetallesOrden
+	movimientos []*domain.MovimientoInventario
+}
+
+// cambiarEstado valida y aplica un cambio de estado dentro de una transacción,
+// registrando el historial y los efectos sobre el inventario: pasar a recibida
+// recibe todo lo pendiente y cancelar una orden (parcialmente) recibida retira
+// lo que entró con ella.
+func (c *OrdenProveedorController) cambiarEstado(ctx *gin.Context, id int, destino domain.EstadoOrden, comentario string) (*cambioOrden, error) {
+	switch destino {
+	case domain.OrdenRecibida:
+		return c.recibir(ctx, id, nil, nil, comentario)
+	case domain.OrdenParcialmenteRecibida:
+		return nil, fmt.Errorf("%w: las recepciones parciales se registran con POST /api/ordenes/:id/recibir",
+			domain.ErrTransicionInvalida)
+	}
+
+	accion := domain.AccionActualizar
+	if destino == domain.OrdenCancelada {
+		accion = domain.AccionCancelar
+	}
+
+	usuario := usuarioActual(ctx)
+	cambio := &cambioOrden{}
+	err := c.ejecutarCambio(ctx, accion, cambio, func(repos *ports.TxRepositories) error {
+		orden, err := c.bloquearOrden(repos, id, cambio)
+		if err != nil {
+			return err
+		}
+
+		if err := orden.Estado.ValidarTransicion(destino); err != nil {
+			return err
+		}
+
+		ok, err := repos.Ordenes.UpdateEstadoDesde(id, orden.Estado, destino)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: la orden cambió de estado", domain.ErrTransicionInvalida)
+		}
+
+		historial := domain.NewHistorialEstado(id, string(orden.Estado), string(destino), usuario, comentario)
+		if _, err := repos.Historial.Create(domain.DocumentoOrdenProveedor, historial); err != nil {
+			return err
+		}
+		estadoAnterior := orden.Estado
+		orden.Estado = destino
+
+		if destino != domain.OrdenCancelada ||
+			(estadoAnterior != domain.OrdenRecibida && estadoAnterior != domain.OrdenParcialmenteRecibida) {
+			return nil
+		}
+
+		detalles, err := repos.DetallesOrden.GetByOrdenID(id)
+		if err != nil {
+			return err
+		}
+
+		// Si alguna unidad recibida ya se vendió no es posible revertir la orden
+		var faltantes []domain.FaltanteStock
 		for _, detalle := range detalles {
-			producto, err := c.productoRepo.GetByID(detalle.ProductoID)
-			if err == nil {
-				nuevoStock := producto.Existencia + detalle.Cantidad
-				c.productoRepo.UpdateStock(detalle.ProductoID, nuevoStock)
-
-				// Verificar si aún hay stock bajo después de recibir
-				if nuevoStock <= 5 {
-					c.notificationService.NotifyLowStock(detalle.ProductoID, nuevoStock)
-				}
+			if detalle.CantidadRecibida == 0 {
+				continue
 			}
+			movimiento := domain.NewMovimientoInventario(detalle.ProductoID, orden.AlmacenID, -detalle.CantidadRecibida,
+				domain.MotivoCancelacion, domain.DocumentoOrdenProveedor, id)
+			err := aplicarMovimiento(repos, movimiento)
+			var stockErr *domain.StockInsuficienteError
+			if errors.As(err, &stockErr) {
+				faltantes = append(faltantes, stockErr.Faltantes...)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			cambio.movimientos = append(cambio.movimientos, movimiento)
+		}
+		if len(faltantes) > 0 {
+			return &domain.StockInsuficienteError{Faltantes: faltantes}
 		}
-	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message":  "Orden recibida correctamente",
-		"orden_id": id,
+		return nil
+	})
+
+	return cambio, err
+}
+
+// recibir registra dentro de una transacción la recepción de mercancía de una
+// orden. cantidades indica las unidades recibidas por id de detalle; si es nil
+// se recibe todo lo pendiente. lotes indica, por id de detalle, los lotes en
+// que se reciben esas unidades. Ninguna línea puede recibir más de lo que tiene
+// pendiente. La orden pasa a recibida cuando todas sus líneas quedan completas
+// y a parcialmente_recibida en caso contrario.
+func (c *OrdenProveedorController) recibir(ctx *gin.Context, id int, cantidades map[int]int, lotes map[int][]*domain.Lote, comentario string) (*cambioOrden, error) {
+	usuario := usuarioActual(ctx)
+	cambio := &cambioOrden{}
+	err := c.ejecutarCambio(ctx, domain.AccionActualizar, cambio, func(repos *ports.TxRepositories) error {
+		orden, err := c.bloquearOrden(repos, id, cambio)
+		if err != nil {
+			return err
+		}
+
+		if !orden.Estado.PuedeRecibir() {
+			return fmt.Errorf("%w: no se puede recibir una orden %s", domain.ErrTransicionInvalida, orden.Estado)
+		}
+
+		detalles, err := repos.DetallesOrden.GetByOrdenID(id)
+		if err != nil {
+			return err
+		}
+		cambio.detalles = detalles
+
+		if cantidades == nil {
+			cantidades = make(map[int]int, len(detalles))
+			for _, detalle := range detalles {
+				cantidades[detalle.ID] = detalle.Pendiente()
+			}
+		}
+
+		porID := make(map[int]*domain.DetallesOrden, len(detalles))
+		for _, detalle := range detalles {
+			porID[detalle.ID] = detalle
+		}
+		for detalleID := range cantidades {
+			if _, ok := porID[detalleID]; !ok {
+				return fmt.Errorf("%w: el detalle %d no pertenece a la orden %d", domain.ErrRecepcionInvalida, detalleID, id)
+			}
+		}
+
+		for _, detalle := range detalles {
+			cantidad := cantidades[detalle.ID]
+			if cantidad <= 0 {
+				continue
+			}
+			if cantidad > detalle.Pendiente() {
+				return fmt.Errorf("%w: el detalle %d tiene %d unidades pendientes y se intentó recibir %d",
+					domain.ErrRecepcionInvalida, detalle.ID, detalle.Pendiente(), cantidad)
+			}
+
+			detalle.CantidadRecibida += cantidad
+			if err := repos.DetallesOrden.Update(detalle); err != nil {
+				return err
+			}
+
+			movimiento := domain.NewMovimientoInventario(detalle.ProductoID, orden.AlmacenID, cantidad,
+				domain.MotivoRecepcionCompra, domain.DocumentoOrdenProveedor, id)
+			movimiento.LotesEntrada = lotes[detalle.ID]
+			if err := aplicarMovimiento(repos, movimiento); err != nil {
+				return err
+			}
+			cambio.movimientos = append(cambio.movimientos, movimiento)
+
+			if err := actualizarCostoPromedio(repos, movimiento, detalle.PrecioUnitario); err != nil {
+				return err
+			}
+		}
+		if len(cambio.movimientos) == 0 {
+			return fmt.Errorf("%w: la orden no tiene unidades pendientes por recibir", domain.ErrRecepcionInvalida)
+		}
+
+		destino := domain.OrdenRecibida
+		for _, detalle := range detalles {
+			if detalle.Pendiente() > 0 {
+				destino = domain.OrdenParcialmenteRecibida
+				break
+			}
+		}
+
+		// Una recepción parcial sobre una orden ya parcialmente recibida no cambia su estado
+		if destino == orden.Estado {
+			return nil
+		}
+		return c.registrarEstado(repos, orden, destino, usuario, comentario)
 	})
+
+	return cambio, err
+}
+
+// cerrarPendientes cancela lo que falta por recibir en cada línea de una orden
+// parcialmente recibida y la marca como recibida
+func (c *OrdenProveedorController) cerrarPendientes(ctx *gin.Context, id int, comentario string) (*cambioOrden, error) {
+	usuario := usuarioActual(ctx)
+	cambio := &cambioOrden{}
+	err := c.ejecutarCambio(ctx, domain.AccionActualizar, cambio, func(repos *ports.TxRepositories) error {
+		orden, err := c.bloquearOrden(repos, id, cambio)
+		if err != nil {
+			return err
+		}
+
+		// Una orden sin recepciones no tiene backorder: se cancela completa
+		if orden.Estado != domain.OrdenParcialmenteRecibida {
+			return fmt.Errorf("%w: sólo se pueden cerrar los pendientes de una orden %s",
+				domain.ErrTransicionInvalida, domain.OrdenParcialmenteRecibida)
+		}
+
+		detalles, err := repos.DetallesOrden.GetByOrdenID(id)
+		if err != nil {
+			return err
+		}
+		cambio.detalles = detalles
+
+		for _, detalle := range detalles {
+			pendiente := detalle.Pendiente()
+			if pendiente <= 0 {
+				continue
+			}
+			detalle.CantidadCancelada += pendiente
+			if err := repos.DetallesOrden.Update(detalle); err != nil {
+				return err
+			}
+		}
+
+		if comentario == "" {
+			comentario = "Pendientes cancelados (backorder)"
+		}
+		return c.registrarEstado(repos, orden, domain.OrdenRecibida, usuario, comentario)
+	})
+
+	return cambio, err
+}
+
+// ejecutarCambio aplica fn en una transacción y registra en ella la auditoría
+// de la orden que fn deja en cambio
+func (c *OrdenProveedorController) ejecutarCambio(ctx *gin.Context, accion domain.AccionAuditoria, cambio *cambioOrden,
+	fn func(repos *ports.TxRepositories) error) error {
+	return c.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		if err := fn(repos); err != nil {
+			return err
+		}
+		return auditar(repos, ctx, accion, domain.EntidadOrdenProveedor, cambio.orden.ID, cambio.antes, cambio.orden)
+	})
+}
+
+// bloquearOrden bloquea la orden en la transacción actual y guarda en cambio
+// la orden y una copia de cómo estaba antes de modificarla
+func (c *OrdenProveedorController) bloquearOrden(repos *ports.TxRepositories, id int, cambio *cambioOrden) (*domain.OrdenProveedor, error) {
+	orden, err := repos.Ordenes.GetByIDForUpdate(id)
+	if err != nil {
+		return nil, errOrdenNoEncontrada
+	}
+
+	copia := *orden
+	cambio.antes = &copia
+	cambio.orden = orden
+	return orden, nil
+}
+
+// registrarEstado valida y guarda el nuevo estado de una orden bloqueada por
+// la transacción actual, junto con su registro en el historial
+func (c *OrdenProveedorController) registrarEstado(repos *ports.TxRepositories, orden *domain.OrdenProveedor, destino domain.EstadoOrden, usuario, comentario string) error {
+	if err := orden.Estado.ValidarTransicion(destino); err != nil {
+		return err
+	}
+
+	if err := repos.Ordenes.UpdateEstado(orden.ID, destino); err != nil {
+		return err
+	}
+
+	historial := domain.NewHistorialEstado(orden.ID, string(orden.Estado), string(destino), usuario, comentario)
+	if _, err := repos.Historial.Create(domain.DocumentoOrdenProveedor, historial); err != nil {
+		return err
+	}
+
+	orden.Estado = destino
+	return nil
+}
+
+// responderErrorEstado traduce los errores de un cambio de estado a respuestas
+// HTTP. Retorna true si ya se envió una respuesta.
+func (c *OrdenProveedorController) responderErrorEstado(ctx *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, errOrdenNoEncontrada) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Orden no encontrada"})
+		return true
+	}
+
+	if errors.Is(err, domain.ErrRecepcionInvalida) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return true
+	}
+
+	if responderTransicionInvalida(ctx, err) || responderStockInsuficiente(ctx, err) {
+		return true
+	}
+
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	return true
 }
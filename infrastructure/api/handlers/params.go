@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseFecha interpreta una fecha en formato AAAA-MM-DD o RFC3339. Si esFin es
+// true y la fecha no incluye hora, se retorna el inicio del día siguiente para
+// que el rango incluya el día completo.
+func parseFecha(valor string, esFin bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, valor); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", valor, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if esFin {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseRangoFechas lee los parámetros de consulta desde y hasta
+func parseRangoFechas(c *gin.Context) (*time.Time, *time.Time, error) {
+	var desde, hasta *time.Time
+
+	if valor := c.Query("desde"); valor != "" {
+		t, err := parseFecha(valor, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fecha 'desde' inválida: %s", valor)
+		}
+		desde = &t
+	}
+
+	if valor := c.Query("hasta"); valor != "" {
+		t, err := parseFecha(valor, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fecha 'hasta' inválida: %s", valor)
+		}
+		hasta = &t
+	}
+
+	return desde, hasta, nil
+}
+
+// parseAlmacenQuery lee el parámetro de consulta opcional id_almacen. Retorna
+// cero si no se envió.
+func parseAlmacenQuery(c *gin.Context) (int, error) {
+	valor := c.Query("id_almacen")
+	if valor == "" {
+		return 0, nil
+	}
+
+	almacenID, err := strconv.Atoi(valor)
+	if err != nil || almacenID <= 0 {
+		return 0, fmt.Errorf("id_almacen inválido: %s", valor)
+	}
+	return almacenID, nil
+}
+
+// usuarioActual identifica a quien realiza la solicitud mediante el encabezado
+// X-Usuario. Si no se envía se registra como anónimo.
+func usuarioActual(c *gin.Context) string {
+	if usuario := c.GetHeader("X-Usuario"); usuario != "" {
+		return usuario
+	}
+	return "anonimo"
+}
+
+// parseBoolQuery lee un parámetro de consulta booleano opcional. Retorna false
+// si no se envió.
+func parseBoolQuery(c *gin.Context, nombre string) (bool, error) {
+	valor := c.Query(nombre)
+	if valor == "" {
+		return false, nil
+	}
+
+	activo, err := strconv.ParseBool(valor)
+	if err != nil {
+		return false, fmt.Errorf("%s inválido: %s", nombre, valor)
+	}
+	return activo, nil
+}
@@ -3,45 +3,72 @@ package handlers
 import (
 	"ActividadDesempenioAPIz/core/domain"
 	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// errPedidoNoEncontrado indica que el pedido referenciado no existe
+var errPedidoNoEncontrado = errors.New("pedido no encontrado")
+
 // PedidoController controla las solicitudes relacionadas con pedidos
 type PedidoController struct {
 	repository          ports.PedidoRepository
 	detallesRepo        ports.DetallesPedidoRepository
+	ventaRepo           ports.VentaRepository
 	productoRepo        ports.ProductoRepository
+	historialRepo       ports.HistorialEstadoRepository
+	unitOfWork          ports.UnitOfWork
 	notificationService ports.NotificationService
+	reabastecimiento    ports.ReabastecimientoService
 }
 
 // NewPedidoController crea un nuevo controlador de pedidos
 func NewPedidoController(
 	repository ports.PedidoRepository,
 	detallesRepo ports.DetallesPedidoRepository,
+	ventaRepo ports.VentaRepository,
 	productoRepo ports.ProductoRepository,
+	historialRepo ports.HistorialEstadoRepository,
+	unitOfWork ports.UnitOfWork,
 	notificationService ports.NotificationService,
+	reabastecimiento ports.ReabastecimientoService,
 ) *PedidoController {
 	return &PedidoController{
 		repository:          repository,
 		detallesRepo:        detallesRepo,
+		ventaRepo:           ventaRepo,
 		productoRepo:        productoRepo,
+		historialRepo:       historialRepo,
+		unitOfWork:          unitOfWork,
 		notificationService: notificationService,
+		reabastecimiento:    reabastecimiento,
 	}
 }
 
-// GetAll obtiene todos los pedidos
+// GetAll obtiene una página de los pedidos. Se ordena por id, fecha, estado
+// o total y se filtra con estado, desde, hasta, id_cliente, id_almacen,
+// precio_min y precio_max (sobre el total).
 func (pc *PedidoController) GetAll(c *gin.Context) {
-	pedidos, err := pc.repository.GetAll()
+	opciones, err := parseQueryOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pedidos, total, err := pc.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, pedidos)
+	responderListado(c, opciones, total, pedidos)
 }
 
 // GetByID obtiene un pedido por su ID
@@ -62,7 +89,9 @@ func (pc *PedidoController) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, pedido)
 }
 
-// Create crea un nuevo pedido
+// Create crea un nuevo pedido. Si indica id_cliente sin direccion_entrega se
+// entrega en la dirección del cliente. Acepta un descuento_documento; los
+// importes se calculan conforme se agregan las líneas.
 func (pc *PedidoController) Create(c *gin.Context) {
 	var pedido domain.Pedido
 	if err := c.ShouldBindJSON(&pedido); err != nil {
@@ -70,24 +99,62 @@ func (pc *PedidoController) Create(c *gin.Context) {
 		return
 	}
 
-	// Establecer fecha del pedido
-	pedido.FechaPedido = time.Now().Format("2006-01-02 15:04:05")
+	descuentoDocumento, err := normalizarDescuento(pedido.DescuentoDocumento)
+	if responderDescuentoInvalido(c, err) {
+		return
+	}
 
-	id, err := pc.repository.Create(&pedido)
+	// Establecer fecha, estado inicial e importes del pedido sin líneas
+	pedido.FechaPedido = time.Now().Format("2006-01-02 15:04:05")
+	pedido.Estado = domain.PedidoPendiente
+	pedido.ImportesDocumento, pedido.Total = domain.TotalizarImportes(nil, nil, descuentoDocumento)
+	usuario := usuarioActual(c)
+
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		almacenID, err := validarAlmacen(repos, pedido.AlmacenID)
+		if err != nil {
+			return err
+		}
+		pedido.AlmacenID = almacenID
+
+		pedido.DireccionEntrega, err = validarCliente(repos, pedido.ClienteID, pedido.DireccionEntrega)
+		if err != nil {
+			return err
+		}
+
+		id, err := repos.Pedidos.Create(&pedido)
+		if err != nil {
+			return err
+		}
+		pedido.ID = id
+
+		historial := domain.NewHistorialEstado(id, "", string(pedido.Estado), usuario, "")
+		if _, err := repos.Historial.Create(domain.DocumentoPedido, historial); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionCrear, domain.EntidadPedido, id, nil, pedido)
+	})
+	if errors.Is(err, errAlmacenNoEncontrado) || errors.Is(err, errClienteNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	pedido.ID = id
-
 	// Notificar la creación del pedido
-	pc.notificationService.NotifyNewPedido(id, pedido.Total)
+	pc.notificationService.NotifyNewPedido(pedido.ID, pedido.Total)
 
 	c.JSON(http.StatusCreated, pedido)
 }
 
-// Update actualiza un pedido existente
+// Update actualiza un pedido existente. El cliente, la dirección de entrega y
+// el descuento_documento que no se envían conservan su valor actual; si cambia
+// el descuento se recalculan los importes de todas las líneas; responde 409 si
+// el pedido ya no admite cambios en sus líneas. Los demás importes no se
+// modifican aquí.
 func (pc *PedidoController) Update(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -102,8 +169,75 @@ func (pc *PedidoController) Update(c *gin.Context) {
 		return
 	}
 
-	pedido.ID = id
-	if err := pc.repository.Update(&pedido); err != nil {
+	// Un descuento vacío ({"porcentaje": 0}) quita el descuento actual
+	cambiaDescuento := pedido.DescuentoDocumento != nil
+	descuentoDocumento, err := normalizarDescuento(pedido.DescuentoDocumento)
+	if responderDescuentoInvalido(c, err) {
+		return
+	}
+
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		actual, err := repos.Pedidos.GetByIDForUpdate(id)
+		if err != nil {
+			return errPedidoNoEncontrado
+		}
+
+		// El estado sólo cambia mediante el endpoint de transición
+		if pedido.Estado != "" && pedido.Estado != actual.Estado {
+			return errEstadoNoEditable
+		}
+
+		pedido.ID = id
+		pedido.Estado = actual.Estado
+		pedido.AlmacenID = actual.AlmacenID
+		pedido.ImportesDocumento = actual.ImportesDocumento
+		pedido.Total = actual.Total
+		if pedido.ClienteID == nil {
+			pedido.ClienteID = actual.ClienteID
+			if pedido.DireccionEntrega == "" {
+				pedido.DireccionEntrega = actual.DireccionEntrega
+			}
+		}
+
+		pedido.DireccionEntrega, err = validarCliente(repos, pedido.ClienteID, pedido.DireccionEntrega)
+		if err != nil {
+			return err
+		}
+
+		if cambiaDescuento && !descuentoDocumento.Igual(actual.DescuentoDocumento) {
+			if err := validarCambioDescuento(actual); err != nil {
+				return err
+			}
+			pedido.DescuentoDocumento = descuentoDocumento
+			if err := recalcularImportesPedido(repos, &pedido, nil); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.Pedidos.Update(&pedido); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadPedido, id, actual, pedido)
+	})
+	if errors.Is(err, errPedidoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return
+	}
+	if errors.Is(err, errEstadoNoEditable) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "El estado sólo puede cambiarse mediante POST /api/pedidos/:id/estado",
+		})
+		return
+	}
+	if errors.Is(err, errClienteNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if responderTransicionInvalida(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -111,8 +245,23 @@ func (pc *PedidoController) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, pedido)
 }
 
-// CancelPedido cancela un pedido
-func (pc *PedidoController) CancelPedido(c *gin.Context) {
+// camposEditablesPedido son los campos que acepta PATCH /api/pedidos/:id; el
+// valor indica si aceptan null para quitar su valor. El estado cambia mediante
+// POST /api/pedidos/:id/estado y los importes se recalculan al cambiar el
+// descuento.
+var camposEditablesPedido = map[string]bool{
+	"id_cliente":          true,
+	"direccion_entrega":   true,
+	"descuento_documento": true,
+}
+
+// Patch modifica un pedido con un JSON Merge Patch (RFC 7396). Sólo valida y
+// actualiza los campos enviados. Si cambia el cliente sin indicar dirección se
+// usa la del cliente nuevo; si cambia el descuento se recalculan los importes,
+// o responde 409 si el pedido ya no admite cambios en sus líneas.
+// Responde 400 si el parche toca campos de sólo lectura como id_pedido,
+// fecha_pedido, estado o los importes.
+func (pc *PedidoController) Patch(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -120,22 +269,90 @@ func (pc *PedidoController) CancelPedido(c *gin.Context) {
 		return
 	}
 
-	// Obtener el pedido actual
-	pedido, err := pc.repository.GetByID(id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+	parche, ok := leerMergePatch(c, camposEditablesPedido)
+	if !ok {
 		return
 	}
 
-	// Actualizar el estado a cancelado
-	err = pc.repository.UpdateEstado(id, "cancelado")
+	var actual, pedido *domain.Pedido
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		actual, err = repos.Pedidos.GetByIDForUpdate(id)
+		if err != nil {
+			return errPedidoNoEncontrado
+		}
+
+		pedido = &domain.Pedido{}
+		if err := aplicarMergePatch(actual, parche, pedido); err != nil {
+			return err
+		}
+
+		campos := parche.campos()
+		if parche.incluye("id_cliente") || parche.incluye("direccion_entrega") {
+			if !parche.incluye("direccion_entrega") {
+				pedido.DireccionEntrega = ""
+				campos = append(campos, "direccion_entrega")
+			}
+			pedido.DireccionEntrega, err = validarCliente(repos, pedido.ClienteID, pedido.DireccionEntrega)
+			if err != nil {
+				return err
+			}
+		}
+
+		if parche.incluye("descuento_documento") {
+			pedido.DescuentoDocumento, err = normalizarDescuento(pedido.DescuentoDocumento)
+			if err != nil {
+				return err
+			}
+			if !pedido.DescuentoDocumento.Igual(actual.DescuentoDocumento) {
+				if err := validarCambioDescuento(actual); err != nil {
+					return err
+				}
+				if err := recalcularImportesPedido(repos, pedido, nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := repos.Pedidos.UpdateCampos(pedido, campos); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadPedido, id, actual, pedido)
+	})
+	if errors.Is(err, errPedidoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return
+	}
+	if responderPatchInvalido(c, err) || responderTransicionInvalida(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Notificar la cancelación del pedido
-	pc.notificationService.NotifyCanceledPedido(id, pedido.Total)
+	c.JSON(http.StatusOK, pedido)
+}
+
+// CancelPedido cancela un pedido y devuelve al inventario las unidades
+// apartadas que aún no se facturan
+func (pc *PedidoController) CancelPedido(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	cambio, err := pc.cambiarEstado(c, id, domain.PedidoCancelado, "")
+	if pc.responderErrorEstado(c, err) {
+		return
+	}
+
+	// Notificar la cancelación del pedido y los cambios de stock
+	pc.notificationService.NotifyCanceledPedido(id, cambio.pedido.Total)
+	notificarMovimientos(pc.notificationService, pc.productoRepo, cambio.movimientos)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "Pedido cancelado correctamente",
@@ -143,6 +360,450 @@ func (pc *PedidoController) CancelPedido(c *gin.Context) {
 	})
 }
 
+// CambiarEstado cambia el estado de un pedido respetando las transiciones
+// permitidas. Responde 409 si la transición no es válida.
+func (pc *PedidoController) CambiarEstado(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var estadoRequest struct {
+		Estado     domain.EstadoPedido `json:"estado" binding:"required"`
+		Comentario string              `json:"comentario"`
+	}
+	if err := c.ShouldBindJSON(&estadoRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cambio, err := pc.cambiarEstado(c, id, estadoRequest.Estado, estadoRequest.Comentario)
+	if pc.responderErrorEstado(c, err) {
+		return
+	}
+
+	pedido := cambio.pedido
+	if pedido.Estado == domain.PedidoCancelado {
+		pc.notificationService.NotifyCanceledPedido(id, pedido.Total)
+	}
+
+	// Completar el pedido lo factura y genera una venta
+	if venta := cambio.venta; venta != nil {
+		pc.notificationService.NotifyNewVenta(venta.ID, venta.Total)
+	}
+	notificarMovimientos(pc.notificationService, pc.productoRepo, cambio.movimientos)
+
+	c.JSON(http.StatusOK, pedido)
+}
+
+// GetHistorial obtiene el historial de cambios de estado de un pedido
+func (pc *PedidoController) GetHistorial(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	historial, err := pc.historialRepo.GetByDocumento(domain.DocumentoPedido, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, historial)
+}
+
+// cambioPedido es el resultado de un cambio de estado o de una facturación de
+// un pedido
+type cambioPedido struct {
+	antes       *domain.Pedido // el pedido como estaba al bloquearlo
+	pedido      *domain.Pedido
+	venta       *domain.Venta // la venta generada, si se facturó
+	detalles    []*domain.DetallesVenta
+	movimientos []*domain.MovimientoInventario
+}
+
+// cambiarEstado valida y aplica un cambio de estado dentro de una transacción,
+// registrando el historial y los efectos sobre el inventario: pasar a
+// completado factura todo lo pendiente y cancelar libera lo apartado que no
+// se facturó.
+func (pc *PedidoController) cambiarEstado(c *gin.Context, id int, destino domain.EstadoPedido, comentario string) (*cambioPedido, error) {
+	switch destino {
+	case domain.PedidoCompletado:
+		return pc.facturar(c, id, nil, comentario)
+	case domain.PedidoParcialmenteFacturado:
+		return nil, fmt.Errorf("%w: las facturaciones parciales se registran con POST /api/pedidos/:id/facturar",
+			domain.ErrTransicionInvalida)
+	}
+
+	accion := domain.AccionActualizar
+	if destino == domain.PedidoCancelado {
+		accion = domain.AccionCancelar
+	}
+
+	usuario := usuarioActual(c)
+	cambio := &cambioPedido{}
+	err := pc.ejecutarCambio(c, accion, cambio, func(repos *ports.TxRepositories) error {
+		pedido, err := repos.Pedidos.GetByIDForUpdate(id)
+		if err != nil {
+			return errPedidoNoEncontrado
+		}
+		copia := *pedido
+		cambio.antes = &copia
+		cambio.pedido = pedido
+
+		if err := pedido.Estado.ValidarTransicion(destino); err != nil {
+			return err
+		}
+
+		ok, err := repos.Pedidos.UpdateEstadoDesde(id, pedido.Estado, destino)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: el pedido cambió de estado", domain.ErrTransicionInvalida)
+		}
+
+		historial := domain.NewHistorialEstado(id, string(pedido.Estado), string(destino), usuario, comentario)
+		if _, err := repos.Historial.Create(domain.DocumentoPedido, historial); err != nil {
+			return err
+		}
+		pedido.Estado = destino
+
+		// Al cancelar se devuelven al inventario las unidades apartadas; las
+		// facturadas ya salieron con su venta
+		if destino == domain.PedidoCancelado {
+			detalles, err := repos.DetallesPedido.GetByPedidoID(id)
+			if err != nil {
+				return err
+			}
+
+			for _, detalle := range detalles {
+				if detalle.Pendiente() <= 0 {
+					continue
+				}
+				movimiento := domain.NewMovimientoInventario(detalle.ProductoID, pedido.AlmacenID, detalle.Pendiente(),
+					domain.MotivoCancelacion, domain.DocumentoPedido, id)
+				if err := aplicarMovimiento(repos, movimiento); err != nil {
+					return err
+				}
+				cambio.movimientos = append(cambio.movimientos, movimiento)
+			}
+		}
+
+		return nil
+	})
+
+	return cambio, err
+}
+
+// ejecutarCambio aplica fn en una transacción y registra en ella la auditoría
+// del pedido que fn deja en cambio y, si se facturó, la de la venta generada
+func (pc *PedidoController) ejecutarCambio(c *gin.Context, accion domain.AccionAuditoria, cambio *cambioPedido,
+	fn func(repos *ports.TxRepositories) error) error {
+	return pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		if err := fn(repos); err != nil {
+			return err
+		}
+		if err := auditar(repos, c, accion, domain.EntidadPedido, cambio.pedido.ID, cambio.antes, cambio.pedido); err != nil {
+			return err
+		}
+		if venta := cambio.venta; venta != nil {
+			return auditar(repos, c, domain.AccionCrear, domain.EntidadVenta, venta.ID, nil, venta)
+		}
+		return nil
+	})
+}
+
+// Facturar convierte un pedido en una venta. El cuerpo es opcional: sin
+// detalles se factura todo lo pendiente; con detalles sólo se facturan las
+// cantidades indicadas por línea y el pedido queda parcialmente_facturado
+// hasta que todas sus líneas se facturen. Cada facturación genera una venta
+// ligada al pedido, con su cliente, su dirección de entrega, su almacén y los
+// precios, descuentos e IVA pactados en el pedido. Un pedido sin unidades
+// pendientes no se vuelve a facturar.
+func (pc *PedidoController) Facturar(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var facturacionRequest struct {
+		Detalles []struct {
+			DetalleID int `json:"id_detalle_pedido" binding:"required"`
+			Cantidad  int `json:"cantidad" binding:"required,min=1"`
+		} `json:"detalles" binding:"dive"`
+		Comentario string `json:"comentario"`
+	}
+	if err := c.ShouldBindJSON(&facturacionRequest); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cantidades map[int]int
+	if len(facturacionRequest.Detalles) > 0 {
+		cantidades = make(map[int]int, len(facturacionRequest.Detalles))
+		for _, detalle := range facturacionRequest.Detalles {
+			cantidades[detalle.DetalleID] += detalle.Cantidad
+		}
+	}
+
+	cambio, err := pc.facturar(c, id, cantidades, facturacionRequest.Comentario)
+	if pc.responderErrorEstado(c, err) {
+		return
+	}
+
+	pedido, venta := cambio.pedido, cambio.venta
+
+	pc.notificationService.NotifyNewVenta(venta.ID, venta.Total)
+	notificarMovimientos(pc.notificationService, pc.productoRepo, cambio.movimientos)
+
+	mensaje := "Pedido facturado correctamente"
+	if pedido.Estado == domain.PedidoParcialmenteFacturado {
+		mensaje = "Facturación parcial registrada correctamente"
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  mensaje,
+		"pedido":   pedido,
+		"venta":    venta,
+		"detalles": cambio.detalles,
+	})
+}
+
+// GetVentas obtiene las ventas generadas al facturar un pedido
+func (pc *PedidoController) GetVentas(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := pc.repository.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return
+	}
+
+	ventas, err := pc.ventaRepo.GetByPedido(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ventas)
+}
+
+// facturar crea dentro de una transacción la venta de un pedido.
+// cantidades indica las unidades a facturar por id de detalle; si es nil se
+// factura todo lo pendiente. Ninguna línea puede facturar más de lo que tiene
+// pendiente. Las unidades ya estaban apartadas por el pedido, así que cada
+// línea libera lo apartado y registra la salida a nombre de la venta; el
+// kardex y los lotes quedan ligados a la venta y cancelarla los devuelve al
+// inventario. El pedido pasa a completado cuando todas sus líneas quedan
+// facturadas y a parcialmente_facturado en caso contrario.
+func (pc *PedidoController) facturar(c *gin.Context, id int, cantidades map[int]int, comentario string) (*cambioPedido, error) {
+	usuario := usuarioActual(c)
+	cambio := &cambioPedido{}
+	err := pc.ejecutarCambio(c, domain.AccionActualizar, cambio, func(repos *ports.TxRepositories) error {
+		pedido, err := repos.Pedidos.GetByIDForUpdate(id)
+		if err != nil {
+			return errPedidoNoEncontrado
+		}
+		copia := *pedido
+		cambio.antes = &copia
+		cambio.pedido = pedido
+
+		if !pedido.Estado.PuedeFacturar() {
+			return fmt.Errorf("%w: no se puede facturar un pedido %s", domain.ErrTransicionInvalida, pedido.Estado)
+		}
+
+		detalles, err := repos.DetallesPedido.GetByPedidoID(id)
+		if err != nil {
+			return err
+		}
+
+		if cantidades == nil {
+			cantidades = make(map[int]int, len(detalles))
+			for _, detalle := range detalles {
+				cantidades[detalle.ID] = detalle.Pendiente()
+			}
+		}
+
+		porID := make(map[int]*domain.DetallesPedido, len(detalles))
+		for _, detalle := range detalles {
+			porID[detalle.ID] = detalle
+		}
+		for detalleID := range cantidades {
+			if _, ok := porID[detalleID]; !ok {
+				return fmt.Errorf("%w: el detalle %d no pertenece al pedido %d", domain.ErrFacturacionInvalida, detalleID, id)
+			}
+		}
+
+		// Recorrer las líneas en orden de producto para bloquear las
+		// existencias siempre en el mismo orden
+		orden := make([]*domain.DetallesPedido, 0, len(detalles))
+		for _, detalle := range detalles {
+			cantidad := cantidades[detalle.ID]
+			if cantidad <= 0 {
+				continue
+			}
+			if cantidad > detalle.Pendiente() {
+				return fmt.Errorf("%w: el detalle %d tiene %d unidades pendientes y se intentó facturar %d",
+					domain.ErrFacturacionInvalida, detalle.ID, detalle.Pendiente(), cantidad)
+			}
+			orden = append(orden, detalle)
+		}
+		if len(orden) == 0 {
+			return fmt.Errorf("%w: el pedido no tiene unidades pendientes por facturar", domain.ErrFacturacionInvalida)
+		}
+		sort.SliceStable(orden, func(i, j int) bool {
+			return orden[i].ProductoID < orden[j].ProductoID
+		})
+
+		venta := &domain.Venta{
+			FechaVenta:       time.Now(),
+			Estado:           domain.VentaCompletada,
+			AlmacenID:        pedido.AlmacenID,
+			ClienteID:        pedido.ClienteID,
+			DireccionEntrega: pedido.DireccionEntrega,
+			PedidoID:         &pedido.ID,
+		}
+		cambio.venta = venta
+		subtotales := make([]domain.Money, 0, len(orden))
+		importes := make([]domain.ImportesLinea, 0, len(orden))
+		for _, detalle := range orden {
+			producto, err := repos.Productos.GetByID(detalle.ProductoID)
+			if err != nil {
+				return err
+			}
+
+			// La venta cobra la parte proporcional de los importes pactados
+			// en la línea del pedido: descuentos, promoción e IVA
+			detalleVenta := &domain.DetallesVenta{
+				ProductoID:     detalle.ProductoID,
+				Cantidad:       cantidades[detalle.ID],
+				PrecioUnitario: detalle.PrecioUnitario,
+				CostoUnitario:  producto.CostoPromedio,
+			}
+			detalleVenta.Subtotal = detalleVenta.PrecioUnitario.Mul(detalleVenta.Cantidad)
+			detalleVenta.ImportesLinea = detalle.ImportesLinea.ParteDe(detalleVenta.Subtotal,
+				detalle.CantidadFacturada, detalleVenta.Cantidad, detalle.Cantidad)
+			cambio.detalles = append(cambio.detalles, detalleVenta)
+			subtotales = append(subtotales, detalleVenta.Subtotal)
+			importes = append(importes, detalleVenta.ImportesLinea)
+		}
+
+		descuentoDocumento := pedido.DescuentoDocumento
+		if descuentoDocumento != nil && !descuentoDocumento.Monto.IsZero() {
+			// El monto fijo del pedido se reparte según la parte del subtotal que se factura
+			facturado, _ := domain.TotalizarImportes(subtotales, importes, nil)
+			descuentoDocumento = &domain.Descuento{Monto: descuentoDocumento.Monto.Proporcion(
+				int(facturado.Subtotal.Centavos), int(pedido.Subtotal.Centavos))}
+		}
+		venta.ImportesDocumento, venta.Total = domain.TotalizarImportes(subtotales, importes, descuentoDocumento)
+
+		ventaID, err := repos.Ventas.Create(venta)
+		if err != nil {
+			return err
+		}
+		venta.ID = ventaID
+
+		historial := domain.NewHistorialEstado(ventaID, "", string(venta.Estado), usuario,
+			fmt.Sprintf("Facturación del pedido %d", id))
+		if _, err := repos.Historial.Create(domain.DocumentoVenta, historial); err != nil {
+			return err
+		}
+
+		for i, detalle := range orden {
+			detalleVenta := cambio.detalles[i]
+			detalleVenta.VentaID = ventaID
+			detalleID, err := repos.DetallesVenta.Create(detalleVenta)
+			if err != nil {
+				return err
+			}
+			detalleVenta.ID = detalleID
+
+			detalle.CantidadFacturada += detalleVenta.Cantidad
+			if err := repos.DetallesPedido.Update(detalle); err != nil {
+				return err
+			}
+
+			liberacion := domain.NewMovimientoInventario(detalle.ProductoID, pedido.AlmacenID, detalleVenta.Cantidad,
+				domain.MotivoFacturacion, domain.DocumentoPedido, id)
+			salida := domain.NewMovimientoInventario(detalle.ProductoID, pedido.AlmacenID, -detalleVenta.Cantidad,
+				domain.MotivoVenta, domain.DocumentoVenta, ventaID)
+			for _, movimiento := range []*domain.MovimientoInventario{liberacion, salida} {
+				if err := aplicarMovimiento(repos, movimiento); err != nil {
+					return err
+				}
+			}
+			cambio.movimientos = append(cambio.movimientos, salida)
+		}
+
+		destino := domain.PedidoCompletado
+		for _, detalle := range detalles {
+			if detalle.Pendiente() > 0 {
+				destino = domain.PedidoParcialmenteFacturado
+				break
+			}
+		}
+
+		// Una facturación parcial sobre un pedido ya parcialmente facturado no cambia su estado
+		if destino == pedido.Estado {
+			return nil
+		}
+
+		ok, err := repos.Pedidos.UpdateEstadoDesde(id, pedido.Estado, destino)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: el pedido cambió de estado", domain.ErrTransicionInvalida)
+		}
+
+		historial = domain.NewHistorialEstado(id, string(pedido.Estado), string(destino), usuario, comentario)
+		if _, err := repos.Historial.Create(domain.DocumentoPedido, historial); err != nil {
+			return err
+		}
+		pedido.Estado = destino
+
+		return nil
+	})
+
+	return cambio, err
+}
+
+// responderErrorEstado traduce los errores de un cambio de estado a respuestas
+// HTTP. Retorna true si ya se envió una respuesta.
+func (pc *PedidoController) responderErrorEstado(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, errPedidoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return true
+	}
+
+	if errors.Is(err, domain.ErrFacturacionInvalida) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return true
+	}
+
+	if responderTransicionInvalida(c, err) || responderStockInsuficiente(c, err) {
+		return true
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	return true
+}
+
 // GetDetallesPedido obtiene los detalles de un pedido
 func (pc *PedidoController) GetDetallesPedido(c *gin.Context) {
 	idParam := c.Param("id")
@@ -161,7 +822,11 @@ func (pc *PedidoController) GetDetallesPedido(c *gin.Context) {
 	c.JSON(http.StatusOK, detalles)
 }
 
-// AddDetallePedido añade un detalle a un pedido
+// AddDetallePedido añade un detalle a un pedido y recalcula los importes de
+// todas sus líneas, ya que las promociones y el descuento del documento
+// dependen del conjunto de líneas. El precio unitario se toma del producto;
+// la línea sólo acepta un descuento_linea. Responde 409 si el pedido ya no
+// está pendiente ni aprobado.
 func (pc *PedidoController) AddDetallePedido(c *gin.Context) {
 	idParam := c.Param("id")
 	pedidoID, err := strconv.Atoi(idParam)
@@ -176,39 +841,128 @@ func (pc *PedidoController) AddDetallePedido(c *gin.Context) {
 		return
 	}
 
-	detalle.PedidoID = pedidoID
-	detalle.Subtotal = float64(detalle.Cantidad) * detalle.PrecioUnitario
-
-	id, err := pc.detallesRepo.Create(&detalle)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if detalle.Cantidad <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La cantidad debe ser mayor a cero"})
 		return
 	}
 
-	detalle.ID = id
-
-	// Actualizar el stock del producto
-	producto, err := pc.productoRepo.GetByID(detalle.ProductoID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el producto"})
+	descuentoLinea, err := normalizarDescuento(detalle.DescuentoLinea)
+	if responderDescuentoInvalido(c, err) {
 		return
 	}
 
-	nuevoStock := producto.Existencia - detalle.Cantidad
-	if nuevoStock < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock insuficiente"})
+	detalle.ID = 0
+	detalle.PedidoID = pedidoID
+	detalle.CantidadFacturada = 0
+	detalle.ImportesLinea = domain.ImportesLinea{DescuentoLinea: descuentoLinea}
+
+	// Descontar el stock, insertar el detalle y actualizar los importes del
+	// pedido en una sola transacción
+	var movimiento *domain.MovimientoInventario
+	var antes, pedido *domain.Pedido
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		pedido, err = repos.Pedidos.GetByIDForUpdate(pedidoID)
+		if err != nil {
+			return errPedidoNoEncontrado
+		}
+		copia := *pedido
+		antes = &copia
+
+		// Un pedido enviado, facturado o cancelado ya no aparta stock
+		if !pedido.Estado.AdmiteDetalles() {
+			return fmt.Errorf("%w: no se pueden agregar productos a un pedido %s", domain.ErrTransicionInvalida, pedido.Estado)
+		}
+
+		producto, err := repos.Productos.GetByID(detalle.ProductoID)
+		if err != nil {
+			return fmt.Errorf("%w: %d", errProductoNoEncontrado, detalle.ProductoID)
+		}
+
+		movimiento = domain.NewMovimientoInventario(detalle.ProductoID, pedido.AlmacenID, -detalle.Cantidad,
+			domain.MotivoPedido, domain.DocumentoPedido, pedidoID)
+		if err := aplicarMovimiento(repos, movimiento); err != nil {
+			return err
+		}
+
+		// El precio lo fija el catálogo, no el cliente, y es el que se
+		// factura después en la venta
+		detalle.PrecioUnitario = producto.Precio
+		detalle.Subtotal = detalle.PrecioUnitario.Mul(detalle.Cantidad)
+
+		if err := recalcularImportesPedido(repos, pedido, &detalle); err != nil {
+			return err
+		}
+
+		id, err := repos.DetallesPedido.Create(&detalle)
+		if err != nil {
+			return err
+		}
+		detalle.ID = id
+
+		if err := repos.Pedidos.Update(pedido); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadPedido, pedidoID, antes,
+			conLineaAgregada(pedido, detalle))
+	})
+	if responderStockInsuficiente(c, err) || responderTransicionInvalida(c, err) {
 		return
 	}
-
-	if err := pc.productoRepo.UpdateStock(detalle.ProductoID, nuevoStock); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el stock"})
+	if errors.Is(err, errPedidoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pedido no encontrado"})
+		return
+	}
+	if errors.Is(err, errProductoNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Verificar si el stock es bajo y enviar notificación
-	if nuevoStock <= 5 {
-		pc.notificationService.NotifyLowStock(detalle.ProductoID, nuevoStock)
-	}
+	notificarMovimientos(pc.notificationService, pc.productoRepo, []*domain.MovimientoInventario{movimiento})
+	solicitarReabastecimiento(pc.reabastecimiento, []*domain.MovimientoInventario{movimiento})
 
 	c.JSON(http.StatusCreated, detalle)
 }
+
+// validarCambioDescuento verifica que el pedido admita cambiar su descuento.
+// Las ventas emitidas de un pedido facturado conservan los importes con que se
+// facturaron, así que el pedido ya no se recalcula.
+func validarCambioDescuento(pedido *domain.Pedido) error {
+	if !pedido.Estado.AdmiteDetalles() {
+		return fmt.Errorf("%w: no se puede cambiar el descuento de un pedido %s",
+			domain.ErrTransicionInvalida, pedido.Estado)
+	}
+	return nil
+}
+
+// recalcularImportesPedido recalcula los importes de todas las líneas de un
+// pedido más la línea nueva, si se indica, y guarda las líneas existentes. La
+// línea nueva y el pedido los guarda quien llama.
+func recalcularImportesPedido(repos *ports.TxRepositories, pedido *domain.Pedido, nuevo *domain.DetallesPedido) error {
+	detalles, err := repos.DetallesPedido.GetByPedidoID(pedido.ID)
+	if err != nil {
+		return err
+	}
+
+	lineas := detalles
+	if nuevo != nil {
+		lineas = append(lineas, nuevo)
+	}
+	err = calcularImportes(repos, fechaPedido(pedido), lineasPedido(lineas), &pedido.ImportesDocumento, &pedido.Total)
+	if err != nil {
+		return err
+	}
+
+	for _, detalle := range detalles {
+		if err := repos.DetallesPedido.Update(detalle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lineaDocumento es una línea de venta o de pedido vista por el cálculo de
+// importes. Las líneas nuevas toman la tasa de IVA vigente del producto; las
+// ya registradas conservan la tasa con la que se registraron.
+type lineaDocumento struct {
+	nueva          bool
+	productoID     int
+	cantidad       int
+	precioUnitario domain.Money
+	importes       *domain.ImportesLinea
+}
+
+// lineasVenta retorna las líneas de cálculo de los detalles de una venta
+func lineasVenta(detalles []*domain.DetallesVenta) []lineaDocumento {
+	lineas := make([]lineaDocumento, len(detalles))
+	for i, detalle := range detalles {
+		lineas[i] = lineaDocumento{
+			nueva:          detalle.ID == 0,
+			productoID:     detalle.ProductoID,
+			cantidad:       detalle.Cantidad,
+			precioUnitario: detalle.PrecioUnitario,
+			importes:       &detalle.ImportesLinea,
+		}
+	}
+	return lineas
+}
+
+// lineasPedido retorna las líneas de cálculo de los detalles de un pedido
+func lineasPedido(detalles []*domain.DetallesPedido) []lineaDocumento {
+	lineas := make([]lineaDocumento, len(detalles))
+	for i, detalle := range detalles {
+		lineas[i] = lineaDocumento{
+			nueva:          detalle.ID == 0,
+			productoID:     detalle.ProductoID,
+			cantidad:       detalle.Cantidad,
+			precioUnitario: detalle.PrecioUnitario,
+			importes:       &detalle.ImportesLinea,
+		}
+	}
+	return lineas
+}
+
+// calcularImportes calcula los importes de las líneas de un documento y los
+// del documento completo con las promociones vigentes en fecha. Los
+// descuentos de línea y del documento son los que ya traen importes; el
+// resultado se escribe sobre importes y total.
+func calcularImportes(repos *ports.TxRepositories, fecha time.Time, lineas []lineaDocumento, importes *domain.ImportesDocumento, total *domain.Money) error {
+	categorias, err := repos.Categorias.GetAll()
+	if err != nil {
+		return err
+	}
+	porID := make(map[int]*domain.Categoria, len(categorias))
+	for _, categoria := range categorias {
+		porID[categoria.ID] = categoria
+	}
+
+	promociones, err := repos.Promociones.GetVigentes(fecha)
+	if err != nil {
+		return err
+	}
+
+	calculo := make([]domain.LineaCalculo, len(lineas))
+	for i, linea := range lineas {
+		producto, err := repos.Productos.GetByID(linea.productoID)
+		if err != nil {
+			return fmt.Errorf("%w: %d", errProductoNoEncontrado, linea.productoID)
+		}
+		// Las líneas que ya existían conservan su producto aunque se haya
+		// eliminado; las nuevas no lo aceptan
+		if linea.nueva && producto.Eliminado() {
+			return fmt.Errorf("%w: %d (eliminado)", errProductoNoEncontrado, linea.productoID)
+		}
+
+		tasa := linea.importes.TasaIVA
+		if linea.nueva {
+			tasa = domain.ResolverTasaIVA(producto, porID)
+		}
+		var categoriaIDs []int
+		if producto.CategoriaID != nil {
+			categoriaIDs = domain.AncestrosDe(porID, *producto.CategoriaID)
+		}
+
+		calculo[i] = domain.LineaCalculo{
+			ProductoID:     linea.productoID,
+			CategoriaIDs:   categoriaIDs,
+			Cantidad:       linea.cantidad,
+			PrecioUnitario: linea.precioUnitario,
+			TasaIVA:        tasa,
+			Descuento:      linea.importes.DescuentoLinea,
+		}
+	}
+
+	resultado, documento, totalDocumento := domain.CalcularImportes(calculo, promociones, importes.DescuentoDocumento)
+	for i := range lineas {
+		*lineas[i].importes = resultado[i]
+	}
+	*importes = documento
+	*total = totalDocumento
+	return nil
+}
+
+// normalizarDescuento valida un descuento recibido en una solicitud. Un
+// descuento vacío se descarta y se retorna nil.
+func normalizarDescuento(descuento *domain.Descuento) (*domain.Descuento, error) {
+	if descuento.Vacio() {
+		return nil, nil
+	}
+	return descuento, descuento.Validar()
+}
+
+// fechaPedido retorna la fecha de un pedido, con la que se eligen las
+// promociones que le aplican. Si no se puede interpretar se usa la fecha actual.
+func fechaPedido(pedido *domain.Pedido) time.Time {
+	for _, formato := range []string{time.RFC3339Nano, "2006-01-02 15:04:05"} {
+		if fecha, err := time.ParseInLocation(formato, pedido.FechaPedido, time.Local); err == nil {
+			return fecha
+		}
+	}
+	return time.Now()
+}
+
+// responderDescuentoInvalido responde 400 si err es un descuento o una tasa
+// inválidos. Retorna true si ya se envió la respuesta.
+func responderDescuentoInvalido(c *gin.Context, err error) bool {
+	if !errors.Is(err, domain.ErrDescuentoInvalido) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	return true
+}
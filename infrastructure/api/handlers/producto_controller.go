@@ -3,8 +3,11 @@ package handlers
 import (
 	"ActividadDesempenioAPIz/core/domain"
 	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,29 +16,63 @@ import (
 // ProductoController controla las solicitudes relacionadas con productos
 type ProductoController struct {
 	repository          ports.ProductoRepository
+	movimientoRepo      ports.MovimientoInventarioRepository
+	almacenRepo         ports.AlmacenRepository
+	categoriaRepo       ports.CategoriaRepository
+	unitOfWork          ports.UnitOfWork
 	notificationService ports.NotificationService
+	reabastecimiento    ports.ReabastecimientoService
+	buscador            ports.BuscadorProductos
 }
 
 // NewProductoController crea un nuevo controlador de productos
 func NewProductoController(
 	repository ports.ProductoRepository,
+	movimientoRepo ports.MovimientoInventarioRepository,
+	almacenRepo ports.AlmacenRepository,
+	categoriaRepo ports.CategoriaRepository,
+	unitOfWork ports.UnitOfWork,
 	notificationService ports.NotificationService,
+	reabastecimiento ports.ReabastecimientoService,
+	buscador ports.BuscadorProductos,
 ) *ProductoController {
 	return &ProductoController{
 		repository:          repository,
+		movimientoRepo:      movimientoRepo,
+		almacenRepo:         almacenRepo,
+		categoriaRepo:       categoriaRepo,
+		unitOfWork:          unitOfWork,
 		notificationService: notificationService,
+		reabastecimiento:    reabastecimiento,
+		buscador:            buscador,
 	}
 }
 
-// GetAll obtiene todos los productos
+// GetAll obtiene una página de los productos que no están eliminados. Con el
+// parámetro opcional categoria sólo retorna los de esa categoría y sus
+// subcategorías; con eliminados=true retorna únicamente los eliminados. Se
+// ordena por id, sku, nombre, precio, existencia o fecha_creacion y se filtra
+// con id_proveedor, precio_min, precio_max, stock_menor_a, desde y hasta.
 func (pc *ProductoController) GetAll(c *gin.Context) {
-	productos, err := pc.repository.GetAll()
+	opciones, err := parseQueryOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, productos)
+	opciones.Filtros.CategoriaIDs, err = pc.parseCategoriaQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	productos, total, err := pc.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
+		return
+	}
+
+	responderListado(c, opciones, total, productos)
 }
 
 // GetByID obtiene un producto por su ID
@@ -56,28 +93,157 @@ func (pc *ProductoController) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, producto)
 }
 
-// Create crea un nuevo producto
+// GetBySKU obtiene un producto por su SKU
+func (pc *ProductoController) GetBySKU(c *gin.Context) {
+	producto, err := pc.repository.GetBySKU(c.Param("sku"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, producto)
+}
+
+// GetByCodigoBarras obtiene un producto por su código de barras EAN/UPC
+func (pc *ProductoController) GetByCodigoBarras(c *gin.Context) {
+	codigo := c.Param("code")
+	if err := domain.ValidarCodigoBarras(codigo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	producto, err := pc.repository.GetByCodigoBarras(codigo)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, producto)
+}
+
+// Buscar busca productos por nombre y descripción con el parámetro q. No
+// distingue mayúsculas ni acentos, y la última palabra puede estar incompleta
+// para autocompletar. Retorna hasta limite resultados (20 por omisión, 100 como
+// máximo), del más relevante al menos relevante, con las coincidencias
+// resaltadas.
+func (pc *ProductoController) Buscar(c *gin.Context) {
+	terminos := domain.TerminosBusqueda(c.Query("q"))
+	if len(terminos) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro q debe contener al menos una palabra"})
+		return
+	}
+
+	limite := domain.LimiteBusquedaPredeterminado
+	if valor := c.Query("limite"); valor != "" {
+		var err error
+		limite, err = strconv.Atoi(valor)
+		if err != nil || limite < 1 || limite > domain.LimiteBusquedaMaximo {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limite inválido: %s, debe estar entre 1 y %d", valor, domain.LimiteBusquedaMaximo)})
+			return
+		}
+	}
+
+	resultados, err := pc.buscador.Buscar(terminos, limite)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resultados)
+}
+
+// GetStockBajo obtiene los productos en o por debajo de su stock mínimo. Con
+// el parámetro opcional id_almacen se evalúa la existencia de ese almacén en
+// lugar de la total; con categoria sólo se consideran los productos de esa
+// categoría y sus subcategorías.
+func (pc *ProductoController) GetStockBajo(c *gin.Context) {
+	almacenID, err := parseAlmacenQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categoriaIDs, err := pc.parseCategoriaQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	productos, err := pc.repository.GetStockBajo(almacenID, categoriaIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, productos)
+}
+
+// Create crea un nuevo producto. Si no se indica stock_minimo se usa el
+// predeterminado. costo_promedio es el costo de la existencia inicial. La existencia inicial entra al almacén principal como un
+// ajuste manual para que quede registrada en el kardex.
 func (pc *ProductoController) Create(c *gin.Context) {
-	var producto domain.Producto
+	producto := domain.Producto{StockMinimo: domain.StockMinimoPredeterminado}
 	if err := c.ShouldBindJSON(&producto); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if mensaje := pc.validarProducto(&producto); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
 	// Establecer fecha de creación
 	producto.FechaCreacion = time.Now().Format("2006-01-02 15:04:05")
+	producto.DeletedAt = nil
 
-	id, err := pc.repository.Create(&producto)
+	if producto.Existencia < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La existencia no puede ser negativa"})
+		return
+	}
+
+	err := pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		if err := validarProveedorActivo(repos, producto.ProveedorID); err != nil {
+			return err
+		}
+
+		id, err := repos.Productos.Create(&producto)
+		if err != nil {
+			return err
+		}
+		producto.ID = id
+
+		if err := repos.Productos.SetAtributos(id, producto.Atributos); err != nil {
+			return err
+		}
+
+		movimiento := domain.NewMovimientoInventario(id, domain.AlmacenPrincipalID, producto.Existencia,
+			domain.MotivoAjusteManual, "", 0)
+		if err := aplicarMovimiento(repos, movimiento); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionCrear, domain.EntidadProducto, producto.ID, nil, producto)
+	})
+	if errors.Is(err, errProveedorNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, domain.ErrDuplicado) {
+		c.JSON(http.StatusConflict, gin.H{"error": "El SKU o el código de barras ya están registrados"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	producto.ID = id
 	c.JSON(http.StatusCreated, producto)
 }
 
-// Update actualiza un producto existente
+// Update actualiza un producto existente. La existencia no se modifica: se
+// ajusta con PATCH /api/productos/:id/stock. El costo promedio tampoco: lo
+// recalculan las recepciones de órdenes.
 func (pc *ProductoController) Update(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -86,14 +252,161 @@ func (pc *ProductoController) Update(c *gin.Context) {
 		return
 	}
 
-	var producto domain.Producto
+	actual, err := pc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	// Los umbrales de stock, los códigos, la categoría y la tasa de IVA que no
+	// se envían conservan su valor actual. Los atributos sólo se reemplazan si
+	// se envían.
+	producto := domain.Producto{
+		SKU:             actual.SKU,
+		CodigoBarras:    actual.CodigoBarras,
+		CategoriaID:     actual.CategoriaID,
+		TasaIVA:         actual.TasaIVA,
+		StockMinimo:     actual.StockMinimo,
+		PuntoReorden:    actual.PuntoReorden,
+		CantidadReorden: actual.CantidadReorden,
+	}
 	if err := c.ShouldBindJSON(&producto); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if mensaje := pc.validarProducto(&producto); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
 	producto.ID = id
-	if err := pc.repository.Update(&producto); err != nil {
+	producto.Existencia = actual.Existencia
+	producto.CostoPromedio = actual.CostoPromedio
+	producto.DeletedAt = actual.DeletedAt
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		// Un producto puede conservar un proveedor que se eliminó después,
+		// pero no asignarse a uno eliminado
+		if producto.ProveedorID != actual.ProveedorID {
+			if err := validarProveedorActivo(repos, producto.ProveedorID); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.Productos.Update(&producto); err != nil {
+			return err
+		}
+
+		if producto.Atributos == nil {
+			producto.Atributos = actual.Atributos
+		} else if err := repos.Productos.SetAtributos(id, producto.Atributos); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadProducto, id, actual, producto)
+	})
+	if errors.Is(err, errProveedorNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, domain.ErrDuplicado) {
+		c.JSON(http.StatusConflict, gin.H{"error": "El SKU o el código de barras ya están registrados"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, producto)
+}
+
+// camposEditablesProducto son los campos que acepta PATCH /api/productos/:id;
+// el valor indica si aceptan null para quitar su valor. La existencia se fija
+// con PATCH /api/productos/:id/stock y el costo promedio lo calculan las
+// recepciones.
+var camposEditablesProducto = map[string]bool{
+	"sku":              true,
+	"codigo_barras":    true,
+	"nombre":           false,
+	"descripcion":      true,
+	"precio":           false,
+	"stock_minimo":     false,
+	"punto_reorden":    false,
+	"cantidad_reorden": false,
+	"id_proveedor":     false,
+	"id_categoria":     true,
+	"tasa_iva":         true,
+	"atributos":        true,
+}
+
+// Patch modifica un producto con un JSON Merge Patch (RFC 7396). Sólo valida
+// y actualiza los campos enviados; en atributos, una clave con null quita ese
+// atributo. Responde 400 si el parche toca campos de sólo lectura como
+// id_producto, existencia, costo_promedio o fecha_creacion.
+func (pc *ProductoController) Patch(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	parche, ok := leerMergePatch(c, camposEditablesProducto)
+	if !ok {
+		return
+	}
+
+	var actual, producto *domain.Producto
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		actual, err = repos.Productos.GetByID(id)
+		if err != nil {
+			return errProductoNoEncontrado
+		}
+
+		producto = &domain.Producto{}
+		if err := aplicarMergePatch(actual, parche, producto); err != nil {
+			return err
+		}
+		if mensaje := pc.validarCamposProducto(producto, parche.campos()); mensaje != "" {
+			return fmt.Errorf("%w: %s", errPatchInvalido, mensaje)
+		}
+		if parche.incluye("id_proveedor") {
+			if err := validarProveedorActivo(repos, producto.ProveedorID); err != nil {
+				return err
+			}
+		}
+
+		var columnas []string
+		for _, campo := range parche.campos() {
+			if campo != "atributos" {
+				columnas = append(columnas, campo)
+			}
+		}
+		if err := repos.Productos.UpdateCampos(producto, columnas); err != nil {
+			return err
+		}
+		if parche.incluye("atributos") {
+			if err := repos.Productos.SetAtributos(id, producto.Atributos); err != nil {
+				return err
+			}
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadProducto, id, actual, producto)
+	})
+	if errors.Is(err, errProductoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if responderPatchInvalido(c, err) {
+		return
+	}
+	if errors.Is(err, domain.ErrDuplicado) {
+		c.JSON(http.StatusConflict, gin.H{"error": "El SKU o el código de barras ya están registrados"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -101,7 +414,8 @@ func (pc *ProductoController) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, producto)
 }
 
-// UpdateStock actualiza el stock de un producto
+// UpdateStock fija la existencia de un producto en un almacén (el principal si
+// no se indica id_almacen)
 func (pc *ProductoController) UpdateStock(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -111,7 +425,8 @@ func (pc *ProductoController) UpdateStock(c *gin.Context) {
 	}
 
 	var stockData struct {
-		Stock int `json:"stock" binding:"required"`
+		Stock     int `json:"stock" binding:"required"`
+		AlmacenID int `json:"id_almacen"`
 	}
 
 	if err := c.ShouldBindJSON(&stockData); err != nil {
@@ -119,24 +434,76 @@ func (pc *ProductoController) UpdateStock(c *gin.Context) {
 		return
 	}
 
-	if err := pc.repository.UpdateStock(id, stockData.Stock); err != nil {
+	if stockData.Stock < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El stock no puede ser negativo"})
+		return
+	}
+
+	// Bloquear la fila y aplicar la diferencia como un ajuste atómico
+	var movimiento *domain.MovimientoInventario
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		almacenID, err := validarAlmacen(repos, stockData.AlmacenID)
+		if err != nil {
+			return err
+		}
+
+		existencia, err := repos.Productos.GetStockForUpdate(id, almacenID)
+		if err != nil {
+			return errProductoNoEncontrado
+		}
+
+		antes, err := repos.Productos.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		movimiento = domain.NewMovimientoInventario(id, almacenID, stockData.Stock-existencia,
+			domain.MotivoAjusteManual, "", 0)
+		if movimiento.Cantidad == 0 {
+			return nil
+		}
+		if err := aplicarMovimiento(repos, movimiento); err != nil {
+			return err
+		}
+
+		despues, err := repos.Productos.GetByID(id)
+		if err != nil {
+			return err
+		}
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadProducto, id, antes, despues)
+	})
+	if errors.Is(err, errProductoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if errors.Is(err, errAlmacenNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Verificar si el stock es bajo y enviar notificación
-	if stockData.Stock <= 5 {
-		pc.notificationService.NotifyLowStock(id, stockData.Stock)
+	// Verificar si el stock es bajo o si se reabasteció y enviar notificación
+	if movimiento.Cantidad != 0 {
+		notificarMovimientos(pc.notificationService, pc.repository, []*domain.MovimientoInventario{movimiento})
+		solicitarReabastecimiento(pc.reabastecimiento, []*domain.MovimientoInventario{movimiento})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "Stock actualizado correctamente",
 		"producto_id": id,
+		"id_almacen":  movimiento.AlmacenID,
 		"stock":       stockData.Stock,
 	})
 }
 
-// Delete elimina un producto
+// Delete elimina un producto. Por omisión sólo lo marca como eliminado: deja
+// de listarse y de aceptarse en documentos nuevos, pero las ventas, pedidos y
+// órdenes que ya lo incluyen no cambian y puede restaurarse. Con
+// permanente=true lo borra definitivamente; si algún documento lo referencia
+// responde 409 con la lista de esos documentos.
 func (pc *ProductoController) Delete(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -145,10 +512,331 @@ func (pc *ProductoController) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := pc.repository.Delete(id); err != nil {
+	permanente, err := parseBoolQuery(c, "permanente")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		antes, err := repos.Productos.GetByID(id)
+		if err != nil {
+			return errProductoNoEncontrado
+		}
+
+		if !permanente {
+			if err := repos.Productos.SoftDelete(id); err != nil {
+				return err
+			}
+			despues, err := repos.Productos.GetByID(id)
+			if err != nil {
+				return err
+			}
+			return auditar(repos, c, domain.AccionEliminar, domain.EntidadProducto, id, antes, despues)
+		}
+
+		dependencias, err := repos.Productos.GetDependencias(id)
+		if err != nil {
+			return err
+		}
+		if len(dependencias) > 0 {
+			return &domain.DependenciasError{Dependencias: dependencias}
+		}
+		if err := repos.Productos.Delete(id); err != nil {
+			return err
+		}
+		return auditar(repos, c, domain.AccionEliminar, domain.EntidadProducto, id, antes, nil)
+	})
+	if errors.Is(err, errProductoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if responderDependencias(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Producto eliminado correctamente"})
 }
+
+// Restore restaura un producto eliminado sin permanente=true
+func (pc *ProductoController) Restore(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var producto *domain.Producto
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		antes, err := repos.Productos.GetByID(id)
+		if err != nil {
+			return errProductoNoEncontrado
+		}
+
+		if err := repos.Productos.Restore(id); err != nil {
+			return err
+		}
+
+		producto, err = repos.Productos.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionRestaurar, domain.EntidadProducto, id, antes, producto)
+	})
+	if errors.Is(err, errProductoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, producto)
+}
+
+// GetMovimientos obtiene el kardex de un producto. Acepta los parámetros
+// opcionales desde y hasta (AAAA-MM-DD o RFC3339) para filtrar por fecha e
+// id_almacen para filtrar por almacén.
+func (pc *ProductoController) GetMovimientos(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	desde, hasta, err := parseRangoFechas(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	almacenID, err := parseAlmacenQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := pc.repository.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	movimientos, err := pc.movimientoRepo.GetByProductoID(id, almacenID, desde, hasta)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, movimientos)
+}
+
+// GetExistencias obtiene la existencia de un producto en cada almacén
+func (pc *ProductoController) GetExistencias(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	producto, err := pc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	existencias, err := pc.almacenRepo.GetExistenciasByProducto(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id_producto": id,
+		"existencia":  producto.Existencia,
+		"almacenes":   existencias,
+	})
+}
+
+// GetAtributos obtiene los atributos de un producto
+func (pc *ProductoController) GetAtributos(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	producto, err := pc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	atributos := producto.Atributos
+	if atributos == nil {
+		atributos = map[string]string{}
+	}
+
+	c.JSON(http.StatusOK, atributos)
+}
+
+// SetAtributos reemplaza todos los atributos de un producto
+func (pc *ProductoController) SetAtributos(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var atributos map[string]string
+	if err := c.ShouldBindJSON(&atributos); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if mensaje := validarAtributos(atributos); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		actual, err := repos.Productos.GetByID(id)
+		if err != nil {
+			return errProductoNoEncontrado
+		}
+
+		if err := repos.Productos.SetAtributos(id, atributos); err != nil {
+			return err
+		}
+
+		producto := *actual
+		producto.Atributos = atributos
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadProducto, id, actual, producto)
+	})
+	if errors.Is(err, errProductoNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, atributos)
+}
+
+// parseCategoriaQuery lee el parámetro de consulta opcional categoria y
+// retorna el ID de esa categoría junto con los de sus subcategorías. Retorna
+// nil si no se envió.
+func (pc *ProductoController) parseCategoriaQuery(c *gin.Context) ([]int, error) {
+	valor := c.Query("categoria")
+	if valor == "" {
+		return nil, nil
+	}
+
+	categoriaID, err := strconv.Atoi(valor)
+	if err != nil || categoriaID <= 0 {
+		return nil, fmt.Errorf("categoria inválida: %s", valor)
+	}
+
+	categorias, err := pc.categoriaRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, categoria := range categorias {
+		if categoria.ID == categoriaID {
+			return domain.DescendientesDe(categorias, categoriaID), nil
+		}
+	}
+	return nil, fmt.Errorf("la categoría %d no existe", categoriaID)
+}
+
+// camposValidadosProducto son los campos que revisa validarProducto al crear
+// o reemplazar un producto
+var camposValidadosProducto = []string{
+	"precio", "stock_minimo", "punto_reorden", "cantidad_reorden", "sku",
+	"codigo_barras", "id_categoria", "tasa_iva", "atributos",
+}
+
+// validarProducto verifica el precio, el costo, los umbrales de stock, el
+// código de barras, la categoría y los atributos de un producto. Retorna el
+// mensaje de error o una cadena vacía si es válido.
+func (pc *ProductoController) validarProducto(producto *domain.Producto) string {
+	if producto.CostoPromedio.IsNegative() {
+		return "El costo no puede ser negativo"
+	}
+	return pc.validarCamposProducto(producto, camposValidadosProducto)
+}
+
+// validarCamposProducto verifica sólo los campos indicados de un producto.
+// Retorna el mensaje de error o una cadena vacía si son válidos.
+func (pc *ProductoController) validarCamposProducto(producto *domain.Producto, campos []string) string {
+	for _, campo := range campos {
+		switch campo {
+		case "nombre":
+			if strings.TrimSpace(producto.Nombre) == "" {
+				return "El nombre no puede estar vacío"
+			}
+		case "precio":
+			if producto.Precio.IsNegative() {
+				return "El precio no puede ser negativo"
+			}
+		case "stock_minimo", "punto_reorden", "cantidad_reorden":
+			if producto.StockMinimo < 0 || producto.PuntoReorden < 0 || producto.CantidadReorden < 0 {
+				return "stock_minimo, punto_reorden y cantidad_reorden no pueden ser negativos"
+			}
+		case "sku":
+			if len(producto.SKU) > 50 {
+				return "El SKU no puede exceder 50 caracteres"
+			}
+		case "codigo_barras":
+			if producto.CodigoBarras != "" {
+				if err := domain.ValidarCodigoBarras(producto.CodigoBarras); err != nil {
+					return err.Error()
+				}
+			}
+		case "id_categoria":
+			if producto.CategoriaID != nil {
+				if _, err := pc.categoriaRepo.GetByID(*producto.CategoriaID); err != nil {
+					return fmt.Sprintf("La categoría %d no existe", *producto.CategoriaID)
+				}
+			}
+		case "tasa_iva":
+			if producto.TasaIVA != nil {
+				if err := producto.TasaIVA.Validar(); err != nil {
+					return err.Error()
+				}
+			}
+		case "atributos":
+			if mensaje := validarAtributos(producto.Atributos); mensaje != "" {
+				return mensaje
+			}
+		}
+	}
+	return ""
+}
+
+// validarAtributos verifica que las claves de los atributos no estén vacías y
+// que claves y valores quepan en sus columnas
+func validarAtributos(atributos map[string]string) string {
+	for clave, valor := range atributos {
+		if clave == "" || len(clave) > 50 {
+			return "Las claves de los atributos deben tener entre 1 y 50 caracteres"
+		}
+		if len(valor) > 255 {
+			return fmt.Sprintf("El valor del atributo %s no puede exceder 255 caracteres", clave)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromocionController controla las solicitudes relacionadas con promociones
+type PromocionController struct {
+	repository    ports.PromocionRepository
+	productoRepo  ports.ProductoRepository
+	categoriaRepo ports.CategoriaRepository
+}
+
+// NewPromocionController crea un nuevo controlador de promociones
+func NewPromocionController(
+	repository ports.PromocionRepository,
+	productoRepo ports.ProductoRepository,
+	categoriaRepo ports.CategoriaRepository,
+) *PromocionController {
+	return &PromocionController{
+		repository:    repository,
+		productoRepo:  productoRepo,
+		categoriaRepo: categoriaRepo,
+	}
+}
+
+// GetAll obtiene una página de las promociones. Se ordena por id, nombre,
+// fecha_inicio o fecha_fin y se filtra con estado (activa o inactiva). Con
+// ?vigentes=true retorna, sin paginar, sólo las que aplican en este momento.
+func (pc *PromocionController) GetAll(c *gin.Context) {
+	if c.Query("vigentes") == "true" {
+		promociones, err := pc.repository.GetVigentes(time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, promociones)
+		return
+	}
+
+	opciones, err := parseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promociones, total, err := pc.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
+		return
+	}
+
+	responderListado(c, opciones, total, promociones)
+}
+
+// GetByID obtiene una promoción por su ID
+func (pc *PromocionController) GetByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	promocion, err := pc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promoción no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promocion)
+}
+
+// Create crea una nueva promoción. Si no se indica activa, la promoción se
+// crea activa.
+func (pc *PromocionController) Create(c *gin.Context) {
+	promocion := domain.Promocion{Activa: true}
+	if err := c.ShouldBindJSON(&promocion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if mensaje := pc.validarPromocion(&promocion); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
+	id, err := pc.repository.Create(&promocion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	promocion.ID = id
+	c.JSON(http.StatusCreated, promocion)
+}
+
+// Update actualiza una promoción existente. Los cambios sólo afectan a los
+// documentos cuyos importes se calculen después.
+func (pc *PromocionController) Update(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	actual, err := pc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promoción no encontrada"})
+		return
+	}
+
+	promocion := domain.Promocion{Activa: actual.Activa}
+	if err := c.ShouldBindJSON(&promocion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	promocion.ID = id
+
+	if mensaje := pc.validarPromocion(&promocion); mensaje != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensaje})
+		return
+	}
+
+	if err := pc.repository.Update(&promocion); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promocion)
+}
+
+// Delete elimina una promoción
+func (pc *PromocionController) Delete(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if _, err := pc.repository.GetByID(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promoción no encontrada"})
+		return
+	}
+
+	if err := pc.repository.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promoción eliminada correctamente"})
+}
+
+// validarPromocion verifica los datos de una promoción y que existan el
+// producto y la categoría a los que se refiere. Retorna el mensaje de error o
+// una cadena vacía si es válida.
+func (pc *PromocionController) validarPromocion(promocion *domain.Promocion) string {
+	if strings.TrimSpace(promocion.Nombre) == "" {
+		return "El nombre de la promoción es obligatorio"
+	}
+
+	if err := promocion.Validar(); err != nil {
+		return err.Error()
+	}
+
+	if promocion.ProductoID != nil {
+		producto, err := pc.productoRepo.GetByID(*promocion.ProductoID)
+		if err != nil {
+			return fmt.Sprintf("El producto %d no existe", *promocion.ProductoID)
+		}
+		if producto.Eliminado() {
+			return fmt.Sprintf("El producto %d está eliminado", *promocion.ProductoID)
+		}
+	}
+
+	if promocion.CategoriaID != nil {
+		if _, err := pc.categoriaRepo.GetByID(*promocion.CategoriaID); err != nil {
+			return fmt.Sprintf("La categoría %d no existe", *promocion.CategoriaID)
+		}
+	}
+	return ""
+}
@@ -3,8 +3,11 @@ package handlers
 import (
 	"ActividadDesempenioAPIz/core/domain"
 	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,24 +16,40 @@ import (
 // ProveedorController controla las solicitudes relacionadas con proveedores
 type ProveedorController struct {
 	repository ports.ProveedorRepository
+	unitOfWork ports.UnitOfWork
 }
 
 // NewProveedorController crea un nuevo controlador de proveedores
-func NewProveedorController(repository ports.ProveedorRepository) *ProveedorController {
+func NewProveedorController(
+	repository ports.ProveedorRepository,
+	unitOfWork ports.UnitOfWork,
+) *ProveedorController {
 	return &ProveedorController{
 		repository: repository,
+		unitOfWork: unitOfWork,
 	}
 }
 
-// GetAll obtiene todos los proveedores
+// errProveedorNoEncontrado indica que el proveedor solicitado no existe
+var errProveedorNoEncontrado = errors.New("proveedor no encontrado")
+
+// GetAll obtiene una página de los proveedores que no están eliminados. Con
+// eliminados=true retorna únicamente los eliminados. Se ordena por id, nombre
+// o fecha_registro y se filtra con desde y hasta.
 func (pc *ProveedorController) GetAll(c *gin.Context) {
-	proveedores, err := pc.repository.GetAll()
+	opciones, err := parseQueryOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	proveedores, total, err := pc.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, proveedores)
+	responderListado(c, opciones, total, proveedores)
 }
 
 // GetByID obtiene un proveedor por su ID
@@ -61,14 +80,22 @@ func (pc *ProveedorController) Create(c *gin.Context) {
 
 	// Establecer fecha de registro
 	proveedor.FechaRegistro = time.Now().Format("2006-01-02 15:04:05")
+	proveedor.DeletedAt = nil
+
+	err := pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		id, err := repos.Proveedores.Create(&proveedor)
+		if err != nil {
+			return err
+		}
+		proveedor.ID = id
 
-	id, err := pc.repository.Create(&proveedor)
+		return auditar(repos, c, domain.AccionCrear, domain.EntidadProveedor, id, nil, proveedor)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	proveedor.ID = id
 	c.JSON(http.StatusCreated, proveedor)
 }
 
@@ -87,8 +114,88 @@ func (pc *ProveedorController) Update(c *gin.Context) {
 		return
 	}
 
-	proveedor.ID = id
-	if err := pc.repository.Update(&proveedor); err != nil {
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		actual, err := repos.Proveedores.GetByIDForUpdate(id)
+		if err != nil {
+			return errProveedorNoEncontrado
+		}
+
+		proveedor.ID = id
+		proveedor.FechaRegistro = actual.FechaRegistro
+		proveedor.DeletedAt = actual.DeletedAt
+		if err := repos.Proveedores.Update(&proveedor); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadProveedor, id, actual, proveedor)
+	})
+	if errors.Is(err, errProveedorNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proveedor)
+}
+
+// camposEditablesProveedor son los campos que acepta PATCH
+// /api/proveedores/:id; el valor indica si aceptan null para quitar su valor
+var camposEditablesProveedor = map[string]bool{
+	"nombre":    false,
+	"direccion": true,
+	"telefono":  true,
+	"email":     true,
+}
+
+// Patch modifica un proveedor con un JSON Merge Patch (RFC 7396). Sólo valida
+// y actualiza los campos enviados. Responde 400 si el parche toca campos de
+// sólo lectura como id_proveedor o fecha_registro.
+func (pc *ProveedorController) Patch(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	parche, ok := leerMergePatch(c, camposEditablesProveedor)
+	if !ok {
+		return
+	}
+
+	var actual, proveedor *domain.Proveedor
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		actual, err = repos.Proveedores.GetByIDForUpdate(id)
+		if err != nil {
+			return errProveedorNoEncontrado
+		}
+
+		proveedor = &domain.Proveedor{}
+		if err := aplicarMergePatch(actual, parche, proveedor); err != nil {
+			return err
+		}
+		if parche.incluye("nombre") && strings.TrimSpace(proveedor.Nombre) == "" {
+			return fmt.Errorf("%w: El nombre no puede estar vacío", errPatchInvalido)
+		}
+
+		if err := repos.Proveedores.UpdateCampos(proveedor, parche.campos()); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadProveedor, id, actual, proveedor)
+	})
+	if errors.Is(err, errProveedorNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+	if responderPatchInvalido(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -96,7 +203,10 @@ func (pc *ProveedorController) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, proveedor)
 }
 
-// Delete elimina un proveedor
+// Delete elimina un proveedor. Por omisión sólo lo marca como eliminado: deja
+// de listarse y de aceptar órdenes nuevas, pero sus órdenes no cambian y puede
+// restaurarse. Con permanente=true lo borra definitivamente junto con su
+// catálogo; si algún producto u orden lo referencia responde 409 con la lista.
 func (pc *ProveedorController) Delete(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -105,10 +215,91 @@ func (pc *ProveedorController) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := pc.repository.Delete(id); err != nil {
+	permanente, err := parseBoolQuery(c, "permanente")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		antes, err := repos.Proveedores.GetByIDForUpdate(id)
+		if err != nil {
+			return errProveedorNoEncontrado
+		}
+
+		if !permanente {
+			if err := repos.Proveedores.SoftDelete(id); err != nil {
+				return err
+			}
+			despues, err := repos.Proveedores.GetByID(id)
+			if err != nil {
+				return err
+			}
+			return auditar(repos, c, domain.AccionEliminar, domain.EntidadProveedor, id, antes, despues)
+		}
+
+		dependencias, err := repos.Proveedores.GetDependencias(id)
+		if err != nil {
+			return err
+		}
+		if len(dependencias) > 0 {
+			return &domain.DependenciasError{Dependencias: dependencias}
+		}
+		if err := repos.Proveedores.Delete(id); err != nil {
+			return err
+		}
+		return auditar(repos, c, domain.AccionEliminar, domain.EntidadProveedor, id, antes, nil)
+	})
+	if errors.Is(err, errProveedorNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+	if responderDependencias(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Proveedor eliminado correctamente"})
 }
+
+// Restore restaura un proveedor eliminado sin permanente=true
+func (pc *ProveedorController) Restore(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var proveedor *domain.Proveedor
+	err = pc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		antes, err := repos.Proveedores.GetByIDForUpdate(id)
+		if err != nil {
+			return errProveedorNoEncontrado
+		}
+
+		if err := repos.Proveedores.Restore(id); err != nil {
+			return err
+		}
+
+		proveedor, err = repos.Proveedores.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionRestaurar, domain.EntidadProveedor, id, antes, proveedor)
+	})
+	if errors.Is(err, errProveedorNoEncontrado) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proveedor)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReporteController controla las solicitudes de reportes
+type ReporteController struct {
+	repository ports.ReporteRepository
+}
+
+// NewReporteController crea un nuevo controlador de reportes
+func NewReporteController(repository ports.ReporteRepository) *ReporteController {
+	return &ReporteController{
+		repository: repository,
+	}
+}
+
+// GetMargen obtiene el reporte de ingresos, costo y margen bruto de las
+// ventas. El parámetro agrupar desglosa por producto (predeterminado),
+// proveedor, dia, semana o mes; desde y hasta (AAAA-MM-DD o RFC3339) limitan
+// el periodo.
+func (rc *ReporteController) GetMargen(c *gin.Context) {
+	agrupacion := domain.AgrupacionMargen(c.DefaultQuery("agrupar", string(domain.MargenPorProducto)))
+	if err := domain.ValidarAgrupacionMargen(agrupacion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	desde, hasta, err := parseRangoFechas(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lineas, err := rc.repository.GetMargen(agrupacion, desde, hasta)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := domain.LineaMargen{Clave: "total", Nombre: "Total"}
+	for _, linea := range lineas {
+		total.Cantidad += linea.Cantidad
+		total.Ingresos = total.Ingresos.Add(linea.Ingresos)
+		total.Costo = total.Costo.Add(linea.Costo)
+	}
+	total.CalcularMargen()
+
+	c.JSON(http.StatusOK, gin.H{
+		"agrupacion": agrupacion,
+		"desde":      desde,
+		"hasta":      hasta,
+		"lineas":     lineas,
+		"total":      total,
+	})
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransferenciaController controla las transferencias de inventario entre almacenes
+type TransferenciaController struct {
+	repository          ports.TransferenciaRepository
+	productoRepo        ports.ProductoRepository
+	unitOfWork          ports.UnitOfWork
+	notificationService ports.NotificationService
+}
+
+// NewTransferenciaController crea un nuevo controlador de transferencias
+func NewTransferenciaController(
+	repository ports.TransferenciaRepository,
+	productoRepo ports.ProductoRepository,
+	unitOfWork ports.UnitOfWork,
+	notificationService ports.NotificationService,
+) *TransferenciaController {
+	return &TransferenciaController{
+		repository:          repository,
+		productoRepo:        productoRepo,
+		unitOfWork:          unitOfWork,
+		notificationService: notificationService,
+	}
+}
+
+// GetAll obtiene una página de las transferencias, de la más reciente a la
+// más antigua. Se ordena por id o fecha y se filtra con desde, hasta e
+// id_almacen, que puede ser el de origen o el de destino.
+func (tc *TransferenciaController) GetAll(c *gin.Context) {
+	opciones, err := parseQueryOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transferencias, total, err := tc.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
+		return
+	}
+
+	responderListado(c, opciones, total, transferencias)
+}
+
+// GetByID obtiene una transferencia con sus detalles
+func (tc *TransferenciaController) GetByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	transferencia, err := tc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transferencia no encontrada"})
+		return
+	}
+
+	detalles, err := tc.repository.GetDetalles(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transferencia": transferencia,
+		"detalles":      detalles,
+	})
+}
+
+// Create mueve inventario de un almacén a otro. Todas las líneas se verifican
+// contra la existencia del almacén origen y se aplican en una sola
+// transacción: si alguna no alcanza se rechaza la transferencia completa. Cada
+// línea genera una salida en el origen y una entrada en el destino en el
+// kardex; los lotes consumidos en el origen se recrean en el destino.
+func (tc *TransferenciaController) Create(c *gin.Context) {
+	var transferenciaRequest struct {
+		AlmacenOrigenID  int `json:"id_almacen_origen" binding:"required"`
+		AlmacenDestinoID int `json:"id_almacen_destino" binding:"required"`
+		Detalles         []struct {
+			ProductoID int `json:"id_producto" binding:"required"`
+			Cantidad   int `json:"cantidad" binding:"required,gt=0"`
+		} `json:"detalles" binding:"required,min=1,dive"`
+		Comentario string `json:"comentario"`
+	}
+
+	if err := c.ShouldBindJSON(&transferenciaRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if transferenciaRequest.AlmacenOrigenID == transferenciaRequest.AlmacenDestinoID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El almacén origen y el destino deben ser distintos"})
+		return
+	}
+
+	transferencia := domain.Transferencia{
+		AlmacenOrigenID:  transferenciaRequest.AlmacenOrigenID,
+		AlmacenDestinoID: transferenciaRequest.AlmacenDestinoID,
+		Usuario:          usuarioActual(c),
+		Comentario:       transferenciaRequest.Comentario,
+		Fecha:            time.Now(),
+	}
+	var detalles []*domain.DetallesTransferencia
+	var movimientos []*domain.MovimientoInventario
+
+	err := tc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		for _, almacenID := range []int{transferencia.AlmacenOrigenID, transferencia.AlmacenDestinoID} {
+			if _, err := validarAlmacen(repos, almacenID); err != nil {
+				return fmt.Errorf("%w: %d", err, almacenID)
+			}
+		}
+
+		// Calcular la cantidad total a transferir de cada producto
+		solicitado := make(map[int]int)
+		for _, linea := range transferenciaRequest.Detalles {
+			solicitado[linea.ProductoID] += linea.Cantidad
+		}
+
+		// Bloquear las existencias del origen en orden de ID para evitar
+		// interbloqueos y verificar todas las líneas antes de escribir nada
+		orden := make([]int, 0, len(solicitado))
+		for productoID := range solicitado {
+			orden = append(orden, productoID)
+		}
+		sort.Ints(orden)
+
+		var faltantes []domain.FaltanteStock
+		for _, productoID := range orden {
+			existencia, err := repos.Productos.GetStockForUpdate(productoID, transferencia.AlmacenOrigenID)
+			if err != nil {
+				return fmt.Errorf("%w: %d", errProductoNoEncontrado, productoID)
+			}
+			if err := validarProductoActivo(repos, productoID); err != nil {
+				return err
+			}
+
+			if existencia < solicitado[productoID] {
+				faltantes = append(faltantes, domain.FaltanteStock{
+					ProductoID: productoID,
+					AlmacenID:  transferencia.AlmacenOrigenID,
+					Solicitado: solicitado[productoID],
+					Disponible: existencia,
+				})
+			}
+		}
+		if len(faltantes) > 0 {
+			return &domain.StockInsuficienteError{Faltantes: faltantes}
+		}
+
+		id, err := repos.Transferencias.Create(&transferencia)
+		if err != nil {
+			return err
+		}
+		transferencia.ID = id
+
+		for _, linea := range transferenciaRequest.Detalles {
+			detalle := &domain.DetallesTransferencia{
+				TransferenciaID: id,
+				ProductoID:      linea.ProductoID,
+				Cantidad:        linea.Cantidad,
+			}
+			detalle.ID, err = repos.Transferencias.CreateDetalle(detalle)
+			if err != nil {
+				return err
+			}
+			detalles = append(detalles, detalle)
+		}
+
+		for _, productoID := range orden {
+			salida := domain.NewMovimientoInventario(productoID, transferencia.AlmacenOrigenID, -solicitado[productoID],
+				domain.MotivoTransferencia, domain.DocumentoTransferencia, id)
+			if err := aplicarMovimiento(repos, salida); err != nil {
+				return err
+			}
+
+			// Los lotes que salen del origen llegan al destino con el mismo
+			// número y caducidad
+			entrada := domain.NewMovimientoInventario(productoID, transferencia.AlmacenDestinoID, solicitado[productoID],
+				domain.MotivoTransferencia, domain.DocumentoTransferencia, id)
+			for _, consumo := range salida.Lotes {
+				origen, err := repos.Lotes.GetByID(consumo.LoteID)
+				if err != nil {
+					return err
+				}
+				entrada.LotesEntrada = append(entrada.LotesEntrada, &domain.Lote{
+					NumeroLote:       origen.NumeroLote,
+					FechaCaducidad:   origen.FechaCaducidad,
+					CantidadInicial:  -consumo.Cantidad,
+					OrdenProveedorID: origen.OrdenProveedorID,
+				})
+			}
+			if err := aplicarMovimiento(repos, entrada); err != nil {
+				return err
+			}
+
+			movimientos = append(movimientos, salida, entrada)
+		}
+
+		return nil
+	})
+	if responderStockInsuficiente(c, err) {
+		return
+	}
+	if errors.Is(err, errProductoNoEncontrado) || errors.Is(err, errAlmacenNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// La existencia total no cambia, pero cada almacén puede quedar en stock bajo
+	notificarMovimientos(tc.notificationService, tc.productoRepo, movimientos)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"transferencia": transferencia,
+		"detalles":      detalles,
+	})
+}
@@ -3,19 +3,28 @@ package handlers
 import (
 	"ActividadDesempenioAPIz/core/domain"
 	"ActividadDesempenioAPIz/core/ports"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// errVentaNoEncontrada indica que la venta referenciada no existe
+var errVentaNoEncontrada = errors.New("venta no encontrada")
+
 // VentaController controla las solicitudes relacionadas con ventas
 type VentaController struct {
 	repository          ports.VentaRepository
 	detallesRepo        ports.DetallesVentaRepository
 	productoRepo        ports.ProductoRepository
+	historialRepo       ports.HistorialEstadoRepository
+	unitOfWork          ports.UnitOfWork
 	notificationService ports.NotificationService
+	reabastecimiento    ports.ReabastecimientoService
 }
 
 // NewVentaController crea un nuevo controlador de ventas
@@ -23,25 +32,39 @@ func NewVentaController(
 	repository ports.VentaRepository,
 	detallesRepo ports.DetallesVentaRepository,
 	productoRepo ports.ProductoRepository,
+	historialRepo ports.HistorialEstadoRepository,
+	unitOfWork ports.UnitOfWork,
 	notificationService ports.NotificationService,
+	reabastecimiento ports.ReabastecimientoService,
 ) *VentaController {
 	return &VentaController{
 		repository:          repository,
 		detallesRepo:        detallesRepo,
 		productoRepo:        productoRepo,
+		historialRepo:       historialRepo,
+		unitOfWork:          unitOfWork,
 		notificationService: notificationService,
+		reabastecimiento:    reabastecimiento,
 	}
 }
 
-// GetAll obtiene todas las ventas
+// GetAll obtiene una página de las ventas. Se ordena por id, fecha, estado o
+// total y se filtra con estado, desde, hasta, id_cliente, id_almacen,
+// precio_min y precio_max (sobre el total).
 func (vc *VentaController) GetAll(c *gin.Context) {
-	ventas, err := vc.repository.GetAll()
+	opciones, err := parseQueryOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ventas, total, err := vc.repository.GetAll(opciones)
+	if err != nil {
+		responderErrorListado(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, ventas)
+	responderListado(c, opciones, total, ventas)
 }
 
 // GetByID obtiene una venta por su ID
@@ -62,32 +85,184 @@ func (vc *VentaController) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, venta)
 }
 
-// Create crea una nueva venta
+// Create crea una nueva venta con sus detalles. Los precios se toman del
+// catálogo de productos y el stock de todas las líneas se verifica y descuenta
+// en una sola transacción; si alguna línea no tiene existencia suficiente se
+// rechaza la venta completa. Si indica id_cliente sin direccion_entrega se
+// entrega en la dirección del cliente. Cada línea acepta un descuento_linea y
+// la venta un descuento_documento; las promociones vigentes y el IVA se
+// aplican al calcular los importes.
 func (vc *VentaController) Create(c *gin.Context) {
-	var venta domain.Venta
-	if err := c.ShouldBindJSON(&venta); err != nil {
+	var createVentaRequest struct {
+		AlmacenID          int               `json:"id_almacen"`
+		ClienteID          *int              `json:"id_cliente"`
+		DireccionEntrega   string            `json:"direccion_entrega"`
+		DescuentoDocumento *domain.Descuento `json:"descuento_documento"`
+		Detalles           []struct {
+			ProductoID     int               `json:"id_producto" binding:"required"`
+			Cantidad       int               `json:"cantidad" binding:"required,gt=0"`
+			DescuentoLinea *domain.Descuento `json:"descuento_linea"`
+		} `json:"detalles" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&createVentaRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Establecer fecha de venta
-	venta.FechaVenta = time.Now()
+	descuentoDocumento, err := normalizarDescuento(createVentaRequest.DescuentoDocumento)
+	if responderDescuentoInvalido(c, err) {
+		return
+	}
+	descuentosLinea := make([]*domain.Descuento, len(createVentaRequest.Detalles))
+	for i, linea := range createVentaRequest.Detalles {
+		descuentosLinea[i], err = normalizarDescuento(linea.DescuentoLinea)
+		if responderDescuentoInvalido(c, err) {
+			return
+		}
+	}
 
-	id, err := vc.repository.Create(&venta)
+	venta := domain.Venta{
+		FechaVenta:        time.Now(),
+		Estado:            domain.VentaCompletada,
+		ClienteID:         createVentaRequest.ClienteID,
+		ImportesDocumento: domain.ImportesDocumento{DescuentoDocumento: descuentoDocumento},
+	}
+	usuario := usuarioActual(c)
+	var detalles []*domain.DetallesVenta
+	var movimientos []*domain.MovimientoInventario
+
+	err = vc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		almacenID, err := validarAlmacen(repos, createVentaRequest.AlmacenID)
+		if err != nil {
+			return err
+		}
+		venta.AlmacenID = almacenID
+
+		venta.DireccionEntrega, err = validarCliente(repos, venta.ClienteID, createVentaRequest.DireccionEntrega)
+		if err != nil {
+			return err
+		}
+
+		// Calcular la cantidad total solicitada de cada producto
+		solicitado := make(map[int]int)
+		for _, linea := range createVentaRequest.Detalles {
+			solicitado[linea.ProductoID] += linea.Cantidad
+		}
+
+		// Bloquear las filas en orden de ID para evitar interbloqueos y
+		// verificar el stock de todas las líneas antes de escribir nada
+		orden := make([]int, 0, len(solicitado))
+		for productoID := range solicitado {
+			orden = append(orden, productoID)
+		}
+		sort.Ints(orden)
+
+		productos := make(map[int]*domain.Producto)
+		var faltantes []domain.FaltanteStock
+		for _, productoID := range orden {
+			existencia, err := repos.Productos.GetStockForUpdate(productoID, almacenID)
+			if err != nil {
+				return fmt.Errorf("%w: %d", errProductoNoEncontrado, productoID)
+			}
+
+			producto, err := repos.Productos.GetByID(productoID)
+			if err != nil {
+				return err
+			}
+			productos[productoID] = producto
+
+			if existencia < solicitado[productoID] {
+				faltantes = append(faltantes, domain.FaltanteStock{
+					ProductoID: productoID,
+					AlmacenID:  almacenID,
+					Solicitado: solicitado[productoID],
+					Disponible: existencia,
+				})
+			}
+		}
+		if len(faltantes) > 0 {
+			return &domain.StockInsuficienteError{Faltantes: faltantes}
+		}
+
+		for i, linea := range createVentaRequest.Detalles {
+			detalle := &domain.DetallesVenta{
+				ProductoID:     linea.ProductoID,
+				Cantidad:       linea.Cantidad,
+				PrecioUnitario: productos[linea.ProductoID].Precio,
+				CostoUnitario:  productos[linea.ProductoID].CostoPromedio,
+				ImportesLinea:  domain.ImportesLinea{DescuentoLinea: descuentosLinea[i]},
+			}
+			detalle.Subtotal = detalle.PrecioUnitario.Mul(detalle.Cantidad)
+			detalles = append(detalles, detalle)
+		}
+
+		err = calcularImportes(repos, venta.FechaVenta, lineasVenta(detalles), &venta.ImportesDocumento, &venta.Total)
+		if err != nil {
+			return err
+		}
+
+		id, err := repos.Ventas.Create(&venta)
+		if err != nil {
+			return err
+		}
+		venta.ID = id
+
+		historial := domain.NewHistorialEstado(id, "", string(venta.Estado), usuario, "")
+		if _, err := repos.Historial.Create(domain.DocumentoVenta, historial); err != nil {
+			return err
+		}
+
+		for _, detalle := range detalles {
+			detalle.VentaID = id
+			detalleID, err := repos.DetallesVenta.Create(detalle)
+			if err != nil {
+				return err
+			}
+			detalle.ID = detalleID
+		}
+
+		for _, productoID := range orden {
+			movimiento := domain.NewMovimientoInventario(productoID, almacenID, -solicitado[productoID],
+				domain.MotivoVenta, domain.DocumentoVenta, id)
+			if err := aplicarMovimiento(repos, movimiento); err != nil {
+				return err
+			}
+			movimientos = append(movimientos, movimiento)
+		}
+
+		return auditar(repos, c, domain.AccionCrear, domain.EntidadVenta, id, nil, gin.H{
+			"venta":    venta,
+			"detalles": detalles,
+		})
+	})
+	if responderStockInsuficiente(c, err) {
+		return
+	}
+	if errors.Is(err, errProductoNoEncontrado) || errors.Is(err, errAlmacenNoEncontrado) ||
+		errors.Is(err, errClienteNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	venta.ID = id
-
-	// Notificar la creación de la venta
-	vc.notificationService.NotifyNewVenta(id, venta.Total)
+	// Notificar la creación de la venta y los productos con stock bajo
+	vc.notificationService.NotifyNewVenta(venta.ID, venta.Total)
+	notificarMovimientos(vc.notificationService, vc.productoRepo, movimientos)
+	solicitarReabastecimiento(vc.reabastecimiento, movimientos)
 
-	c.JSON(http.StatusCreated, venta)
+	c.JSON(http.StatusCreated, gin.H{
+		"venta":    venta,
+		"detalles": detalles,
+	})
 }
 
-// Update actualiza una venta existente
+// Update actualiza una venta existente. El cliente y la dirección de entrega
+// que no se envían conservan su valor actual. Los importes no se modifican
+// aquí: se calculan a partir de las líneas.
 func (vc *VentaController) Update(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -102,8 +277,50 @@ func (vc *VentaController) Update(c *gin.Context) {
 		return
 	}
 
+	actual, err := vc.repository.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venta no encontrada"})
+		return
+	}
+
+	// El estado sólo cambia mediante el endpoint de transición
+	if venta.Estado != "" && venta.Estado != actual.Estado {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "El estado sólo puede cambiarse mediante POST /api/ventas/:id/estado",
+		})
+		return
+	}
+
 	venta.ID = id
-	if err := vc.repository.Update(&venta); err != nil {
+	venta.Estado = actual.Estado
+	venta.AlmacenID = actual.AlmacenID
+	venta.PedidoID = actual.PedidoID
+	venta.ImportesDocumento = actual.ImportesDocumento
+	venta.Total = actual.Total
+	if venta.ClienteID == nil {
+		venta.ClienteID = actual.ClienteID
+		if venta.DireccionEntrega == "" {
+			venta.DireccionEntrega = actual.DireccionEntrega
+		}
+	}
+
+	err = vc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		venta.DireccionEntrega, err = validarCliente(repos, venta.ClienteID, venta.DireccionEntrega)
+		if err != nil {
+			return err
+		}
+		if err := repos.Ventas.Update(&venta); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadVenta, id, actual, venta)
+	})
+	if errors.Is(err, errClienteNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -111,8 +328,19 @@ func (vc *VentaController) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, venta)
 }
 
-// CancelVenta cancela una venta
-func (vc *VentaController) CancelVenta(c *gin.Context) {
+// camposEditablesVenta son los campos que acepta PATCH /api/ventas/:id; el
+// valor indica si aceptan null para quitar su valor. El estado cambia mediante
+// POST /api/ventas/:id/estado y los importes no cambian después de la venta.
+var camposEditablesVenta = map[string]bool{
+	"id_cliente":        true,
+	"direccion_entrega": true,
+}
+
+// Patch modifica una venta con un JSON Merge Patch (RFC 7396). Sólo valida y
+// actualiza los campos enviados. Si cambia el cliente sin indicar dirección se
+// usa la del cliente nuevo. Responde 400 si el parche toca campos de sólo
+// lectura como id_venta, fecha_venta, estado, id_pedido o los importes.
+func (vc *VentaController) Patch(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -120,22 +348,74 @@ func (vc *VentaController) CancelVenta(c *gin.Context) {
 		return
 	}
 
-	// Obtener la venta actual
-	venta, err := vc.repository.GetByID(id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Venta no encontrada"})
+	parche, ok := leerMergePatch(c, camposEditablesVenta)
+	if !ok {
 		return
 	}
 
-	// Actualizar el estado a cancelado
-	err = vc.repository.UpdateEstado(id, "cancelada")
+	var actual, venta *domain.Venta
+	err = vc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		actual, err = repos.Ventas.GetByIDForUpdate(id)
+		if err != nil {
+			return errVentaNoEncontrada
+		}
+
+		venta = &domain.Venta{}
+		if err := aplicarMergePatch(actual, parche, venta); err != nil {
+			return err
+		}
+
+		campos := parche.campos()
+		if parche.incluye("id_cliente") || parche.incluye("direccion_entrega") {
+			if !parche.incluye("direccion_entrega") {
+				venta.DireccionEntrega = ""
+				campos = append(campos, "direccion_entrega")
+			}
+			venta.DireccionEntrega, err = validarCliente(repos, venta.ClienteID, venta.DireccionEntrega)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := repos.Ventas.UpdateCampos(venta, campos); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadVenta, id, actual, venta)
+	})
+	if errors.Is(err, errVentaNoEncontrada) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venta no encontrada"})
+		return
+	}
+	if responderPatchInvalido(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Notificar la cancelación de la venta
+	c.JSON(http.StatusOK, venta)
+}
+
+// CancelVenta cancela una venta y devuelve al inventario las unidades vendidas
+func (vc *VentaController) CancelVenta(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	venta, movimientos, err := vc.cambiarEstado(c, id, domain.VentaCancelada, "")
+	if vc.responderErrorEstado(c, err) {
+		return
+	}
+
+	// Notificar la cancelación de la venta y los cambios de stock
 	vc.notificationService.NotifyCanceledVenta(id, venta.Total)
+	notificarMovimientos(vc.notificationService, vc.productoRepo, movimientos)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Venta cancelada correctamente",
@@ -143,6 +423,148 @@ func (vc *VentaController) CancelVenta(c *gin.Context) {
 	})
 }
 
+// CambiarEstado cambia el estado de una venta respetando las transiciones
+// permitidas. Responde 409 si la transición no es válida.
+func (vc *VentaController) CambiarEstado(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var estadoRequest struct {
+		Estado     domain.EstadoVenta `json:"estado" binding:"required"`
+		Comentario string             `json:"comentario"`
+	}
+	if err := c.ShouldBindJSON(&estadoRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	venta, movimientos, err := vc.cambiarEstado(c, id, estadoRequest.Estado, estadoRequest.Comentario)
+	if vc.responderErrorEstado(c, err) {
+		return
+	}
+
+	if venta.Estado == domain.VentaCancelada {
+		vc.notificationService.NotifyCanceledVenta(id, venta.Total)
+	}
+	notificarMovimientos(vc.notificationService, vc.productoRepo, movimientos)
+
+	c.JSON(http.StatusOK, venta)
+}
+
+// GetHistorial obtiene el historial de cambios de estado de una venta
+func (vc *VentaController) GetHistorial(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	historial, err := vc.historialRepo.GetByDocumento(domain.DocumentoVenta, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, historial)
+}
+
+// cambiarEstado valida y aplica un cambio de estado dentro de una transacción,
+// registrando el historial, la auditoría y los efectos sobre el inventario.
+// Retorna la venta con su nuevo estado y los movimientos de inventario
+// generados.
+func (vc *VentaController) cambiarEstado(c *gin.Context, id int, destino domain.EstadoVenta, comentario string) (*domain.Venta, []*domain.MovimientoInventario, error) {
+	var venta *domain.Venta
+	var movimientos []*domain.MovimientoInventario
+	usuario := usuarioActual(c)
+
+	err := vc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		venta, err = repos.Ventas.GetByIDForUpdate(id)
+		if err != nil {
+			return errVentaNoEncontrada
+		}
+		antes := *venta
+
+		if err := venta.Estado.ValidarTransicion(destino); err != nil {
+			return err
+		}
+
+		ok, err := repos.Ventas.UpdateEstadoDesde(id, venta.Estado, destino)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: la venta cambió de estado", domain.ErrTransicionInvalida)
+		}
+
+		historial := domain.NewHistorialEstado(id, string(venta.Estado), string(destino), usuario, comentario)
+		if _, err := repos.Historial.Create(domain.DocumentoVenta, historial); err != nil {
+			return err
+		}
+		venta.Estado = destino
+
+		// Al cancelar se devuelven al inventario las unidades vendidas; las que
+		// ya se devolvieron regresaron con su devolución
+		if destino == domain.VentaCancelada {
+			detalles, err := repos.DetallesVenta.GetByVentaID(id)
+			if err != nil {
+				return err
+			}
+
+			devuelto, err := repos.Devoluciones.GetDevueltoPorDetalle(id)
+			if err != nil {
+				return err
+			}
+
+			for _, detalle := range detalles {
+				cantidad := detalle.Cantidad - devuelto[detalle.ID]
+				if cantidad <= 0 {
+					continue
+				}
+				movimiento := domain.NewMovimientoInventario(detalle.ProductoID, venta.AlmacenID, cantidad,
+					domain.MotivoCancelacion, domain.DocumentoVenta, id)
+				if err := aplicarMovimiento(repos, movimiento); err != nil {
+					return err
+				}
+				movimientos = append(movimientos, movimiento)
+			}
+		}
+
+		accion := domain.AccionActualizar
+		if destino == domain.VentaCancelada {
+			accion = domain.AccionCancelar
+		}
+		return auditar(repos, c, accion, domain.EntidadVenta, id, &antes, venta)
+	})
+
+	return venta, movimientos, err
+}
+
+// responderErrorEstado traduce los errores de un cambio de estado a respuestas
+// HTTP. Retorna true si ya se envió una respuesta.
+func (vc *VentaController) responderErrorEstado(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, errVentaNoEncontrada) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venta no encontrada"})
+		return true
+	}
+
+	if responderTransicionInvalida(c, err) || responderStockInsuficiente(c, err) {
+		return true
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	return true
+}
+
 // GetDetallesVenta obtiene los detalles de una venta
 func (vc *VentaController) GetDetallesVenta(c *gin.Context) {
 	idParam := c.Param("id")
@@ -161,7 +583,9 @@ func (vc *VentaController) GetDetallesVenta(c *gin.Context) {
 	c.JSON(http.StatusOK, detalles)
 }
 
-// AddDetalleVenta añade un detalle a una venta
+// AddDetalleVenta añade un detalle a una venta y recalcula los importes de
+// todas sus líneas, ya que las promociones y el descuento del documento
+// dependen del conjunto de líneas. Responde 409 si la venta no está pendiente.
 func (vc *VentaController) AddDetalleVenta(c *gin.Context) {
 	idParam := c.Param("id")
 	ventaID, err := strconv.Atoi(idParam)
@@ -170,45 +594,108 @@ func (vc *VentaController) AddDetalleVenta(c *gin.Context) {
 		return
 	}
 
-	var detalle domain.DetallesVenta
-	if err := c.ShouldBindJSON(&detalle); err != nil {
+	var detalleRequest struct {
+		ProductoID     int               `json:"id_producto" binding:"required"`
+		Cantidad       int               `json:"cantidad" binding:"required,gt=0"`
+		DescuentoLinea *domain.Descuento `json:"descuento_linea"`
+	}
+	if err := c.ShouldBindJSON(&detalleRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	detalle.VentaID = ventaID
-	detalle.Subtotal = float64(detalle.Cantidad) * detalle.PrecioUnitario
-
-	id, err := vc.detallesRepo.Create(&detalle)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	descuentoLinea, err := normalizarDescuento(detalleRequest.DescuentoLinea)
+	if responderDescuentoInvalido(c, err) {
 		return
 	}
 
-	detalle.ID = id
+	detalle := domain.DetallesVenta{
+		VentaID:       ventaID,
+		ProductoID:    detalleRequest.ProductoID,
+		Cantidad:      detalleRequest.Cantidad,
+		ImportesLinea: domain.ImportesLinea{DescuentoLinea: descuentoLinea},
+	}
 
-	// Actualizar el stock del producto
-	producto, err := vc.productoRepo.GetByID(detalle.ProductoID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el producto"})
+	// Verificar y descontar el stock antes de insertar el detalle, todo en
+	// una sola transacción junto con los importes de la venta
+	var movimiento *domain.MovimientoInventario
+	var antes, venta *domain.Venta
+	err = vc.unitOfWork.Execute(func(repos *ports.TxRepositories) error {
+		var err error
+		venta, err = repos.Ventas.GetByIDForUpdate(ventaID)
+		if err != nil {
+			return errVentaNoEncontrada
+		}
+		copia := *venta
+		antes = &copia
+
+		// Una venta completada o cancelada ya no descuenta stock
+		if !venta.Estado.AdmiteDetalles() {
+			return fmt.Errorf("%w: no se pueden agregar productos a una venta %s", domain.ErrTransicionInvalida, venta.Estado)
+		}
+
+		producto, err := repos.Productos.GetByID(detalle.ProductoID)
+		if err != nil {
+			return fmt.Errorf("%w: %d", errProductoNoEncontrado, detalle.ProductoID)
+		}
+
+		movimiento = domain.NewMovimientoInventario(detalle.ProductoID, venta.AlmacenID, -detalle.Cantidad,
+			domain.MotivoVenta, domain.DocumentoVenta, ventaID)
+		if err := aplicarMovimiento(repos, movimiento); err != nil {
+			return err
+		}
+
+		detalle.PrecioUnitario = producto.Precio
+		detalle.CostoUnitario = producto.CostoPromedio
+		detalle.Subtotal = detalle.PrecioUnitario.Mul(detalle.Cantidad)
+
+		existentes, err := repos.DetallesVenta.GetByVentaID(ventaID)
+		if err != nil {
+			return err
+		}
+		err = calcularImportes(repos, venta.FechaVenta, lineasVenta(append(existentes, &detalle)),
+			&venta.ImportesDocumento, &venta.Total)
+		if err != nil {
+			return err
+		}
+
+		id, err := repos.DetallesVenta.Create(&detalle)
+		if err != nil {
+			return err
+		}
+		detalle.ID = id
+
+		for _, existente := range existentes {
+			if err := repos.DetallesVenta.Update(existente); err != nil {
+				return err
+			}
+		}
+		if err := repos.Ventas.Update(venta); err != nil {
+			return err
+		}
+
+		return auditar(repos, c, domain.AccionActualizar, domain.EntidadVenta, ventaID, antes,
+			conLineaAgregada(venta, detalle))
+	})
+	if responderStockInsuficiente(c, err) || responderTransicionInvalida(c, err) {
 		return
 	}
-
-	nuevoStock := producto.Existencia - detalle.Cantidad
-	if nuevoStock < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock insuficiente"})
+	if errors.Is(err, errVentaNoEncontrada) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Venta no encontrada"})
 		return
 	}
-
-	if err := vc.productoRepo.UpdateStock(detalle.ProductoID, nuevoStock); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el stock"})
+	if errors.Is(err, errProductoNoEncontrado) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Verificar si el stock es bajo y enviar notificación
-	if nuevoStock <= 5 {
-		vc.notificationService.NotifyLowStock(detalle.ProductoID, nuevoStock)
-	}
+	notificarMovimientos(vc.notificationService, vc.productoRepo, []*domain.MovimientoInventario{movimiento})
+	solicitarReabastecimiento(vc.reabastecimiento, []*domain.MovimientoInventario{movimiento})
 
 	c.JSON(http.StatusCreated, detalle)
 }
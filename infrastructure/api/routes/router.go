@@ -20,7 +20,23 @@ func SetupRouter(
 	detallesVentaRepo ports.DetallesVentaRepository,
 	ordenRepo ports.OrdenProveedorRepository,
 	detallesOrdenRepo ports.DetallesOrdenRepository,
+	movimientoRepo ports.MovimientoInventarioRepository,
+	historialRepo ports.HistorialEstadoRepository,
+	almacenRepo ports.AlmacenRepository,
+	transferenciaRepo ports.TransferenciaRepository,
+	loteRepo ports.LoteRepository,
+	categoriaRepo ports.CategoriaRepository,
+	catalogoRepo ports.CatalogoProveedorRepository,
+	reporteRepo ports.ReporteRepository,
+	clienteRepo ports.ClienteRepository,
+	devolucionRepo ports.DevolucionRepository,
+	promocionRepo ports.PromocionRepository,
+	auditoriaRepo ports.AuditoriaRepository,
+	unitOfWork ports.UnitOfWork,
 	notificationService ports.NotificationService,
+	reabastecimientoService ports.ReabastecimientoService,
+	generadorFactura ports.GeneradorFactura,
+	buscadorProductos ports.BuscadorProductos,
 	stockWS ports.WebSocketService,
 	ordersWS ports.WebSocketService,
 	cancellationsWS ports.WebSocketService,
@@ -35,7 +51,23 @@ func SetupRouter(
 		detallesVentaRepo,
 		ordenRepo,
 		detallesOrdenRepo,
+		movimientoRepo,
+		historialRepo,
+		almacenRepo,
+		transferenciaRepo,
+		loteRepo,
+		categoriaRepo,
+		catalogoRepo,
+		reporteRepo,
+		clienteRepo,
+		devolucionRepo,
+		promocionRepo,
+		auditoriaRepo,
+		unitOfWork,
 		notificationService,
+		reabastecimientoService,
+		generadorFactura,
+		buscadorProductos,
 	)
 
 	// Obtener controladores
@@ -44,6 +76,17 @@ func SetupRouter(
 	pedidoController := controllerFactory.GetPedidoController()
 	ventaController := controllerFactory.GetVentaController()
 	ordenController := controllerFactory.GetOrdenProveedorController()
+	almacenController := controllerFactory.GetAlmacenController()
+	transferenciaController := controllerFactory.GetTransferenciaController()
+	loteController := controllerFactory.GetLoteController()
+	categoriaController := controllerFactory.GetCategoriaController()
+	catalogoController := controllerFactory.GetCatalogoProveedorController()
+	reporteController := controllerFactory.GetReporteController()
+	clienteController := controllerFactory.GetClienteController()
+	devolucionController := controllerFactory.GetDevolucionController()
+	promocionController := controllerFactory.GetPromocionController()
+	facturaController := controllerFactory.GetFacturaController()
+	auditoriaController := controllerFactory.GetAuditoriaController()
 
 	// Type assertion para convertir de la interfaz a la implementación concreta
 	stockWSService, ok := stockWS.(*websocket.WebsocketService)
@@ -78,11 +121,32 @@ func SetupRouter(
 	// Rutas de productos
 	productos := api.Group("productos")
 	productos.GET("/", productoController.GetAll)
+	productos.GET("/stock-bajo", productoController.GetStockBajo)
+	productos.GET("/buscar", productoController.Buscar)
+	productos.GET("/barcode/:code", productoController.GetByCodigoBarras)
+	productos.GET("/sku/:sku", productoController.GetBySKU)
 	productos.GET("/:id", productoController.GetByID)
 	productos.POST("/", productoController.Create)
 	productos.PUT("/:id", productoController.Update)
+	productos.PATCH("/:id", productoController.Patch)
 	productos.PATCH("/:id/stock", productoController.UpdateStock)
+	productos.GET("/:id/movimientos", productoController.GetMovimientos)
+	productos.GET("/:id/existencias", productoController.GetExistencias)
+	productos.GET("/:id/lotes", loteController.GetByProducto)
+	productos.GET("/:id/atributos", productoController.GetAtributos)
+	productos.GET("/:id/proveedores", catalogoController.CompararCostos)
+	productos.PUT("/:id/atributos", productoController.SetAtributos)
 	productos.DELETE("/:id", productoController.Delete)
+	productos.POST("/:id/restaurar", productoController.Restore)
+
+	// Rutas de categorías de productos
+	categorias := api.Group("categorias")
+	categorias.GET("/", categoriaController.GetAll)
+	categorias.GET("/:id", categoriaController.GetByID)
+	categorias.GET("/:id/productos", categoriaController.GetProductos)
+	categorias.POST("/", categoriaController.Create)
+	categorias.PUT("/:id", categoriaController.Update)
+	categorias.DELETE("/:id", categoriaController.Delete)
 
 	// Rutas de proveedores
 	proveedores := api.Group("proveedores")
@@ -90,7 +154,21 @@ func SetupRouter(
 	proveedores.GET("/:id", proveedorController.GetByID)
 	proveedores.POST("/", proveedorController.Create)
 	proveedores.PUT("/:id", proveedorController.Update)
+	proveedores.PATCH("/:id", proveedorController.Patch)
 	proveedores.DELETE("/:id", proveedorController.Delete)
+	proveedores.POST("/:id/restaurar", proveedorController.Restore)
+	proveedores.GET("/:id/catalogo", catalogoController.GetByProveedor)
+	proveedores.PUT("/:id/catalogo/:id_producto", catalogoController.Upsert)
+	proveedores.DELETE("/:id/catalogo/:id_producto", catalogoController.Delete)
+
+	// Rutas de clientes
+	clientes := api.Group("clientes")
+	clientes.GET("/", clienteController.GetAll)
+	clientes.GET("/:id", clienteController.GetByID)
+	clientes.POST("/", clienteController.Create)
+	clientes.PUT("/:id", clienteController.Update)
+	clientes.DELETE("/:id", clienteController.Delete)
+	clientes.GET("/:id/historial", clienteController.GetHistorial)
 
 	// Rutas de pedidos
 	pedidos := api.Group("pedidos")
@@ -98,7 +176,12 @@ func SetupRouter(
 	pedidos.GET("/:id", pedidoController.GetByID)
 	pedidos.POST("/", pedidoController.Create)
 	pedidos.PUT("/:id", pedidoController.Update)
+	pedidos.PATCH("/:id", pedidoController.Patch)
 	pedidos.POST("/:id/cancelar", pedidoController.CancelPedido)
+	pedidos.POST("/:id/estado", pedidoController.CambiarEstado)
+	pedidos.POST("/:id/facturar", pedidoController.Facturar)
+	pedidos.GET("/:id/ventas", pedidoController.GetVentas)
+	pedidos.GET("/:id/historial", pedidoController.GetHistorial)
 	pedidos.GET("/:id/productos", pedidoController.GetDetallesPedido)
 	pedidos.POST("/:id/productos", pedidoController.AddDetallePedido)
 
@@ -108,9 +191,29 @@ func SetupRouter(
 	ventas.GET("/:id", ventaController.GetByID)
 	ventas.POST("/", ventaController.Create)
 	ventas.PUT("/:id", ventaController.Update)
+	ventas.PATCH("/:id", ventaController.Patch)
 	ventas.POST("/:id/cancelar", ventaController.CancelVenta)
+	ventas.POST("/:id/estado", ventaController.CambiarEstado)
+	ventas.GET("/:id/historial", ventaController.GetHistorial)
+	ventas.GET("/:id/factura", facturaController.GetByVenta)
+	ventas.POST("/:id/factura", facturaController.Emitir)
 	ventas.GET("/:id/productos", ventaController.GetDetallesVenta)
 	ventas.POST("/:id/productos", ventaController.AddDetalleVenta)
+	ventas.GET("/:id/devoluciones", devolucionController.GetByVenta)
+	ventas.POST("/:id/devoluciones", devolucionController.Create)
+
+	// Rutas de devoluciones
+	devoluciones := api.Group("devoluciones")
+	devoluciones.GET("/", devolucionController.GetAll)
+	devoluciones.GET("/:id", devolucionController.GetByID)
+
+	// Rutas de promociones
+	promociones := api.Group("promociones")
+	promociones.GET("/", promocionController.GetAll)
+	promociones.GET("/:id", promocionController.GetByID)
+	promociones.POST("/", promocionController.Create)
+	promociones.PUT("/:id", promocionController.Update)
+	promociones.DELETE("/:id", promocionController.Delete)
 
 	// Rutas de órdenes de proveedor
 	ordenes := api.Group("ordenes")
@@ -118,8 +221,39 @@ func SetupRouter(
 	ordenes.GET("/:id", ordenController.GetByID)
 	ordenes.POST("/", ordenController.Create)
 	ordenes.PUT("/:id", ordenController.Update)
+	ordenes.PATCH("/:id", ordenController.Patch)
 	ordenes.POST("/:id/cancelar", ordenController.CancelOrden)
 	ordenes.POST("/:id/recibir", ordenController.RecibirOrden)
+	ordenes.POST("/:id/cerrar", ordenController.CerrarPendientes)
+	ordenes.POST("/:id/estado", ordenController.CambiarEstado)
+	ordenes.GET("/:id/historial", ordenController.GetHistorial)
 	ordenes.GET("/:id/productos", ordenController.GetDetallesOrden)
 	ordenes.POST("/:id/productos", ordenController.AddDetalleOrden)
+
+	// Rutas de almacenes
+	almacenes := api.Group("almacenes")
+	almacenes.GET("/", almacenController.GetAll)
+	almacenes.GET("/:id", almacenController.GetByID)
+	almacenes.POST("/", almacenController.Create)
+	almacenes.PUT("/:id", almacenController.Update)
+	almacenes.GET("/:id/existencias", almacenController.GetExistencias)
+
+	// Rutas de transferencias entre almacenes
+	transferencias := api.Group("transferencias")
+	transferencias.GET("/", transferenciaController.GetAll)
+	transferencias.GET("/:id", transferenciaController.GetByID)
+	transferencias.POST("/", transferenciaController.Create)
+
+	// Rutas de lotes y caducidades
+	lotes := api.Group("lotes")
+	lotes.GET("/por-caducar", loteController.GetPorCaducar)
+	lotes.GET("/:id", loteController.GetByID)
+
+	// Rutas de reportes
+	reportes := api.Group("reportes")
+	reportes.GET("/margen", reporteController.GetMargen)
+
+	// Rutas de la bitácora de auditoría
+	auditoria := api.Group("auditoria")
+	auditoria.GET("/", auditoriaController.GetAll)
 }
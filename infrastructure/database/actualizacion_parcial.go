@@ -0,0 +1,39 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// asignacion es la parte SET de un UPDATE que escribe un campo de la API, con
+// sus valores. Un campo puede escribir varias columnas, como los importes que
+// se recalculan al cambiar un descuento.
+type asignacion struct {
+	set  string
+	args []interface{}
+}
+
+// actualizarCampos actualiza en una fila sólo las columnas de los campos
+// indicados. asignaciones son los campos que la tabla permite modificar; un
+// campo fuera de ellas es un error de programación y no se ejecuta nada. Sin
+// campos no hace nada.
+func actualizarCampos(db dbExecutor, tabla, columnaID string, id int, asignaciones map[string]asignacion, campos []string) error {
+	if len(campos) == 0 {
+		return nil
+	}
+
+	sets := make([]string, 0, len(campos))
+	var args []interface{}
+	for _, campo := range campos {
+		a, ok := asignaciones[campo]
+		if !ok {
+			return fmt.Errorf("el campo %s de %s no se puede actualizar", campo, tabla)
+		}
+		sets = append(sets, a.set)
+		args = append(args, a.args...)
+	}
+
+	query := `UPDATE ` + tabla + ` SET ` + strings.Join(sets, `, `) + ` WHERE ` + columnaID + ` = ?`
+	_, err := db.Exec(query, append(args, id)...)
+	return err
+}
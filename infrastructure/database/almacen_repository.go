@@ -0,0 +1,149 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"time"
+)
+
+// SQLAlmacenRepository implementa la interfaz AlmacenRepository usando MySQL
+type SQLAlmacenRepository struct {
+	db dbExecutor
+}
+
+// NewSQLAlmacenRepository crea un nuevo repositorio de almacenes SQL
+func NewSQLAlmacenRepository(db *sql.DB) ports.AlmacenRepository {
+	return &SQLAlmacenRepository{
+		db: db,
+	}
+}
+
+// GetByID obtiene un almacén por su ID
+func (r *SQLAlmacenRepository) GetByID(id int) (*domain.Almacen, error) {
+	query := `SELECT id_almacen, nombre, direccion, fecha_registro FROM Almacen WHERE id_almacen = ?`
+
+	almacen := &domain.Almacen{}
+	var direccion sql.NullString
+	err := r.db.QueryRow(query, id).Scan(
+		&almacen.ID, &almacen.Nombre, &direccion, &almacen.FechaRegistro,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	almacen.Direccion = direccion.String
+	return almacen, nil
+}
+
+// ordenAlmacenes son los campos por los que se pueden ordenar los almacenes
+var ordenAlmacenes = ordenListado{
+	campos: map[string]string{
+		"id":             "id_almacen",
+		"nombre":         "nombre",
+		"fecha_registro": "fecha_registro",
+	},
+	predeterminado: "id",
+	desempate:      "id_almacen",
+}
+
+// GetAll obtiene una página de los almacenes. Filtra por fecha de registro.
+func (r *SQLAlmacenRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Almacen, int, error) {
+	l := &listado{}
+	l.rangoFechas(`fecha_registro`, opciones.Filtros.Desde, opciones.Filtros.Hasta)
+
+	return paginar(r.db, l, `id_almacen, nombre, direccion, fecha_registro`, `FROM Almacen`,
+		ordenAlmacenes, opciones, r.queryAlmacenes)
+}
+
+// queryAlmacenes ejecuta una consulta que retorna almacenes
+func (r *SQLAlmacenRepository) queryAlmacenes(query string, args ...interface{}) ([]*domain.Almacen, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	almacenes := []*domain.Almacen{}
+	for rows.Next() {
+		almacen := &domain.Almacen{}
+		var direccion sql.NullString
+		err := rows.Scan(&almacen.ID, &almacen.Nombre, &direccion, &almacen.FechaRegistro)
+		if err != nil {
+			return nil, err
+		}
+		almacen.Direccion = direccion.String
+		almacenes = append(almacenes, almacen)
+	}
+
+	return almacenes, rows.Err()
+}
+
+// Create crea un nuevo almacén
+func (r *SQLAlmacenRepository) Create(almacen *domain.Almacen) (int, error) {
+	query := `INSERT INTO Almacen (nombre, direccion, fecha_registro) VALUES (?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		almacen.Nombre, almacen.Direccion, time.Now().Format("2006-01-02 15:04:05"),
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Update actualiza un almacén existente
+func (r *SQLAlmacenRepository) Update(almacen *domain.Almacen) error {
+	query := `UPDATE Almacen SET nombre = ?, direccion = ? WHERE id_almacen = ?`
+
+	_, err := r.db.Exec(query, almacen.Nombre, almacen.Direccion, almacen.ID)
+
+	return err
+}
+
+// GetExistenciasByAlmacen obtiene la existencia de cada producto en un almacén
+func (r *SQLAlmacenRepository) GetExistenciasByAlmacen(almacenID int) ([]*domain.ExistenciaAlmacen, error) {
+	query := `SELECT id_producto, id_almacen, existencia, existencia_danada FROM Existencia_Almacen
+              WHERE id_almacen = ? ORDER BY id_producto`
+
+	return r.queryExistencias(query, almacenID)
+}
+
+// GetExistenciasByProducto obtiene la existencia de un producto en cada almacén
+func (r *SQLAlmacenRepository) GetExistenciasByProducto(productoID int) ([]*domain.ExistenciaAlmacen, error) {
+	query := `SELECT id_producto, id_almacen, existencia, existencia_danada FROM Existencia_Almacen
+              WHERE id_producto = ? ORDER BY id_almacen`
+
+	return r.queryExistencias(query, productoID)
+}
+
+// queryExistencias ejecuta una consulta sobre Existencia_Almacen
+func (r *SQLAlmacenRepository) queryExistencias(query string, args ...interface{}) ([]*domain.ExistenciaAlmacen, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existencias := []*domain.ExistenciaAlmacen{}
+	for rows.Next() {
+		existencia := &domain.ExistenciaAlmacen{}
+		err := rows.Scan(
+			&existencia.ProductoID, &existencia.AlmacenID, &existencia.Existencia, &existencia.ExistenciaDanada,
+		)
+		if err != nil {
+			return nil, err
+		}
+		existencias = append(existencias, existencia)
+	}
+
+	return existencias, nil
+}
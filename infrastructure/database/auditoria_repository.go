@@ -0,0 +1,106 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+)
+
+// SQLAuditoriaRepository implementa la interfaz AuditoriaRepository usando MySQL
+type SQLAuditoriaRepository struct {
+	db dbExecutor
+}
+
+// NewSQLAuditoriaRepository crea un nuevo repositorio de auditoría SQL
+func NewSQLAuditoriaRepository(db *sql.DB) ports.AuditoriaRepository {
+	return &SQLAuditoriaRepository{
+		db: db,
+	}
+}
+
+// Create registra una operación en la bitácora de auditoría
+func (r *SQLAuditoriaRepository) Create(registro *domain.RegistroAuditoria) (int, error) {
+	query := `INSERT INTO Auditoria (actor, metodo, ruta, accion, entidad, id_entidad, antes, despues, fecha)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		registro.Actor, registro.Metodo, registro.Ruta, registro.Accion, registro.Entidad, registro.EntidadID,
+		jsonNulo(registro.Antes), jsonNulo(registro.Despues), registro.Fecha,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// ordenAuditoria son los campos por los que se puede ordenar la bitácora;
+// por omisión, del registro más reciente al más antiguo
+var ordenAuditoria = ordenListado{
+	campos: map[string]string{
+		"id":    "id_auditoria",
+		"fecha": "fecha",
+	},
+	predeterminado: "fecha",
+	descendente:    true,
+	desempate:      "id_auditoria",
+}
+
+// GetAll obtiene una página de los registros de auditoría que cumplen el
+// filtro. Las opciones filtran además por rango de fechas.
+func (r *SQLAuditoriaRepository) GetAll(filtro domain.FiltroAuditoria, opciones ports.QueryOptions) ([]*domain.RegistroAuditoria, int, error) {
+	l := &listado{}
+	if filtro.Entidad != "" {
+		l.donde(`entidad = ?`, filtro.Entidad)
+	}
+	if filtro.EntidadID != 0 {
+		l.donde(`id_entidad = ?`, filtro.EntidadID)
+	}
+	if filtro.Actor != "" {
+		l.donde(`actor = ?`, filtro.Actor)
+	}
+	l.rangoFechas(`fecha`, opciones.Filtros.Desde, opciones.Filtros.Hasta)
+
+	return paginar(r.db, l, `id_auditoria, actor, metodo, ruta, accion, entidad, id_entidad, antes, despues, fecha`,
+		`FROM Auditoria`, ordenAuditoria, opciones, r.queryRegistros)
+}
+
+// queryRegistros ejecuta una consulta que retorna registros de auditoría
+func (r *SQLAuditoriaRepository) queryRegistros(query string, args ...interface{}) ([]*domain.RegistroAuditoria, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	registros := []*domain.RegistroAuditoria{}
+	for rows.Next() {
+		registro := &domain.RegistroAuditoria{}
+		var antes, despues []byte
+		err := rows.Scan(
+			&registro.ID, &registro.Actor, &registro.Metodo, &registro.Ruta, &registro.Accion,
+			&registro.Entidad, &registro.EntidadID, &antes, &despues, &registro.Fecha,
+		)
+		if err != nil {
+			return nil, err
+		}
+		registro.Antes = antes
+		registro.Despues = despues
+		registros = append(registros, registro)
+	}
+
+	return registros, rows.Err()
+}
+
+// jsonNulo convierte un documento JSON vacío en NULL
+func jsonNulo(contenido []byte) interface{} {
+	if len(contenido) == 0 {
+		return nil
+	}
+	return string(contenido)
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlErrSinIndiceFullText es el código de MySQL para un MATCH sin un índice
+// FULLTEXT sobre las columnas indicadas
+const mysqlErrSinIndiceFullText = 1191
+
+// longitudMinimaFullText es el valor predeterminado de
+// innodb_ft_min_token_size: InnoDB no indexa palabras más cortas
+const longitudMinimaFullText = 3
+
+// SQLBuscadorProductos implementa la interfaz BuscadorProductos con el índice
+// FULLTEXT de Producto (nombre, descripcion). Que "electronica" coincida con
+// "Electrónica" depende de la intercalación de las columnas, que en MySQL 8 es
+// utf8mb4_0900_ai_ci, insensible a acentos. Las búsquedas que el índice no
+// puede resolver se delegan al buscador de respaldo.
+type SQLBuscadorProductos struct {
+	productos *SQLProductoRepository
+	respaldo  ports.BuscadorProductos
+}
+
+// NewSQLBuscadorProductos crea un nuevo buscador de productos SQL
+func NewSQLBuscadorProductos(db *sql.DB, respaldo ports.BuscadorProductos) ports.BuscadorProductos {
+	return &SQLBuscadorProductos{
+		productos: &SQLProductoRepository{db: db},
+		respaldo:  respaldo,
+	}
+}
+
+// Buscar consulta el índice en modo booleano exigiendo cada término como
+// prefijo de una palabra (+termino*) y ordena por la relevancia que calcula
+// MySQL. Los términos más cortos que longitudMinimaFullText no están en el
+// índice, así que esas búsquedas y las hechas sin el índice creado usan el
+// respaldo.
+func (b *SQLBuscadorProductos) Buscar(terminos []string, limite int) ([]*domain.ResultadoBusqueda, error) {
+	expresion := make([]string, len(terminos))
+	for i, termino := range terminos {
+		if utf8.RuneCountInString(termino) < longitudMinimaFullText {
+			return b.respaldo.Buscar(terminos, limite)
+		}
+		// Los términos sólo tienen letras y dígitos, no operadores del modo booleano
+		expresion[i] = "+" + termino + "*"
+	}
+
+	relevancias, ids, err := b.buscarIDs(strings.Join(expresion, " "), limite)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrSinIndiceFullText {
+		return b.respaldo.Buscar(terminos, limite)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []*domain.ResultadoBusqueda{}, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	productos, err := b.productos.queryProductos(`SELECT `+columnasProducto+` FROM Producto p
+              WHERE p.id_producto IN (`+marcadores(len(ids))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	porID := make(map[int]*domain.Producto, len(productos))
+	for _, producto := range productos {
+		porID[producto.ID] = producto
+	}
+
+	// Se conserva el orden por relevancia de la primera consulta
+	resultados := make([]*domain.ResultadoBusqueda, 0, len(ids))
+	for _, id := range ids {
+		if producto, ok := porID[id]; ok {
+			resultados = append(resultados, domain.NewResultadoBusqueda(producto, terminos, relevancias[id]))
+		}
+	}
+	return resultados, nil
+}
+
+// buscarIDs obtiene los IDs de los productos no eliminados que cumplen la
+// expresión, del más relevante al menos relevante, junto con su relevancia
+func (b *SQLBuscadorProductos) buscarIDs(expresion string, limite int) (map[int]float64, []int, error) {
+	query := `SELECT id_producto, MATCH(nombre, descripcion) AGAINST (? IN BOOLEAN MODE) AS relevancia
+              FROM Producto
+              WHERE deleted_at IS NULL AND MATCH(nombre, descripcion) AGAINST (? IN BOOLEAN MODE)
+              ORDER BY relevancia DESC, nombre, id_producto`
+	args := []interface{}{expresion, expresion}
+	if limite > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limite)
+	}
+
+	rows, err := b.productos.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	relevancias := make(map[int]float64)
+	var ids []int
+	for rows.Next() {
+		var id int
+		var relevancia float64
+		if err := rows.Scan(&id, &relevancia); err != nil {
+			return nil, nil, err
+		}
+		relevancias[id] = relevancia
+		ids = append(ids, id)
+	}
+
+	return relevancias, ids, rows.Err()
+}
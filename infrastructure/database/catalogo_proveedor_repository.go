@@ -0,0 +1,138 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"time"
+)
+
+// SQLCatalogoProveedorRepository implementa la interfaz CatalogoProveedorRepository usando MySQL
+type SQLCatalogoProveedorRepository struct {
+	db dbExecutor
+}
+
+// NewSQLCatalogoProveedorRepository crea un nuevo repositorio del catálogo de proveedores SQL
+func NewSQLCatalogoProveedorRepository(db *sql.DB) ports.CatalogoProveedorRepository {
+	return &SQLCatalogoProveedorRepository{
+		db: db,
+	}
+}
+
+// consultaCatalogo selecciona las entradas del catálogo con los nombres del
+// proveedor y del producto
+const consultaCatalogo = `SELECT pp.id_proveedor, pp.id_producto, pp.sku_proveedor, pp.costo,
+              pp.cantidad_minima, pp.tiempo_entrega_dias, pp.preferido, pp.fecha_actualizacion,
+              pv.nombre, p.nombre
+              FROM Proveedor_Producto pp
+              JOIN Proveedor pv ON pv.id_proveedor = pp.id_proveedor
+              JOIN Producto p ON p.id_producto = pp.id_producto`
+
+// scanEntradaCatalogo lee una entrada del catálogo de una fila de consultaCatalogo
+func scanEntradaCatalogo(fila scanner) (*domain.ProveedorProducto, error) {
+	entrada := &domain.ProveedorProducto{}
+	err := fila.Scan(
+		&entrada.ProveedorID, &entrada.ProductoID, &entrada.SKUProveedor, &entrada.Costo,
+		&entrada.CantidadMinima, &entrada.TiempoEntregaDias, &entrada.Preferido, &entrada.FechaActualizacion,
+		&entrada.NombreProveedor, &entrada.NombreProducto,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entrada, nil
+}
+
+// getEntrada obtiene la entrada que cumple la condición. Retorna nil sin
+// error si no existe.
+func (r *SQLCatalogoProveedorRepository) getEntrada(condicion string, args ...interface{}) (*domain.ProveedorProducto, error) {
+	entrada, err := scanEntradaCatalogo(r.db.QueryRow(consultaCatalogo+` WHERE `+condicion, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return entrada, err
+}
+
+// queryEntradas ejecuta una consulta que retorna entradas del catálogo
+func (r *SQLCatalogoProveedorRepository) queryEntradas(query string, args ...interface{}) ([]*domain.ProveedorProducto, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entradas := []*domain.ProveedorProducto{}
+	for rows.Next() {
+		entrada, err := scanEntradaCatalogo(rows)
+		if err != nil {
+			return nil, err
+		}
+		entradas = append(entradas, entrada)
+	}
+
+	return entradas, rows.Err()
+}
+
+// Get obtiene la entrada de un producto en el catálogo de un proveedor.
+// Retorna nil sin error si el proveedor no ofrece el producto.
+func (r *SQLCatalogoProveedorRepository) Get(proveedorID, productoID int) (*domain.ProveedorProducto, error) {
+	return r.getEntrada(`pp.id_proveedor = ? AND pp.id_producto = ?`, proveedorID, productoID)
+}
+
+// GetByProveedor obtiene el catálogo de un proveedor sin los productos eliminados
+func (r *SQLCatalogoProveedorRepository) GetByProveedor(proveedorID int) ([]*domain.ProveedorProducto, error) {
+	return r.queryEntradas(consultaCatalogo+` WHERE pp.id_proveedor = ? AND p.deleted_at IS NULL
+              ORDER BY pp.id_producto`, proveedorID)
+}
+
+// GetByProducto obtiene las entradas de los proveedores no eliminados que
+// ofrecen un producto, de menor a mayor costo y, a igual costo, de menor
+// tiempo de entrega
+func (r *SQLCatalogoProveedorRepository) GetByProducto(productoID int) ([]*domain.ProveedorProducto, error) {
+	return r.queryEntradas(consultaCatalogo+` WHERE pp.id_producto = ? AND pv.deleted_at IS NULL
+              ORDER BY pp.costo, pp.tiempo_entrega_dias, pp.id_proveedor`, productoID)
+}
+
+// GetPreferido obtiene la entrada del proveedor preferido de un producto.
+// Retorna nil sin error si el producto no tiene proveedor preferido o si el
+// preferido está eliminado.
+func (r *SQLCatalogoProveedorRepository) GetPreferido(productoID int) (*domain.ProveedorProducto, error) {
+	return r.getEntrada(`pp.id_producto = ? AND pp.preferido AND pv.deleted_at IS NULL`, productoID)
+}
+
+// Upsert crea o reemplaza la entrada de un producto en el catálogo de un proveedor
+func (r *SQLCatalogoProveedorRepository) Upsert(entrada *domain.ProveedorProducto) error {
+	query := `INSERT INTO Proveedor_Producto (id_proveedor, id_producto, sku_proveedor, costo,
+              cantidad_minima, tiempo_entrega_dias, preferido, fecha_actualizacion)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+              ON DUPLICATE KEY UPDATE sku_proveedor = VALUES(sku_proveedor), costo = VALUES(costo),
+              cantidad_minima = VALUES(cantidad_minima), tiempo_entrega_dias = VALUES(tiempo_entrega_dias),
+              preferido = VALUES(preferido), fecha_actualizacion = VALUES(fecha_actualizacion)`
+
+	entrada.FechaActualizacion = time.Now().Format("2006-01-02 15:04:05")
+	_, err := r.db.Exec(query,
+		entrada.ProveedorID, entrada.ProductoID, entrada.SKUProveedor, entrada.Costo,
+		entrada.CantidadMinima, entrada.TiempoEntregaDias, entrada.Preferido, entrada.FechaActualizacion,
+	)
+
+	return err
+}
+
+// QuitarPreferido desmarca como preferidos a los proveedores de un producto
+// salvo al indicado
+func (r *SQLCatalogoProveedorRepository) QuitarPreferido(productoID, exceptoProveedorID int) error {
+	query := `UPDATE Proveedor_Producto SET preferido = FALSE
+              WHERE id_producto = ? AND id_proveedor <> ? AND preferido`
+
+	_, err := r.db.Exec(query, productoID, exceptoProveedorID)
+
+	return err
+}
+
+// Delete quita un producto del catálogo de un proveedor
+func (r *SQLCatalogoProveedorRepository) Delete(proveedorID, productoID int) error {
+	query := `DELETE FROM Proveedor_Producto WHERE id_proveedor = ? AND id_producto = ?`
+
+	_, err := r.db.Exec(query, proveedorID, productoID)
+
+	return err
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlErrEntradaDuplicada es el código de MySQL para una violación de clave única
+const mysqlErrEntradaDuplicada = 1062
+
+// errorDuplicado traduce una violación de clave única de MySQL a
+// domain.ErrDuplicado; cualquier otro error se retorna sin cambios
+func errorDuplicado(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrEntradaDuplicada {
+		return fmt.Errorf("%w: %s", domain.ErrDuplicado, mysqlErr.Message)
+	}
+	return err
+}
+
+// mysqlErrFilaReferenciada es el código de MySQL para el borrado de una fila
+// que otra tabla sigue referenciando
+const mysqlErrFilaReferenciada = 1451
+
+// errorReferenciado traduce una violación de llave foránea al borrar a
+// domain.ErrTieneDependencias; cualquier otro error se retorna sin cambios
+func errorReferenciado(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrFilaReferenciada {
+		return fmt.Errorf("%w: %s", domain.ErrTieneDependencias, mysqlErr.Message)
+	}
+	return err
+}
+
+// SQLCategoriaRepository implementa la interfaz CategoriaRepository usando MySQL
+type SQLCategoriaRepository struct {
+	db dbExecutor
+}
+
+// NewSQLCategoriaRepository crea un nuevo repositorio de categorías SQL
+func NewSQLCategoriaRepository(db *sql.DB) ports.CategoriaRepository {
+	return &SQLCategoriaRepository{
+		db: db,
+	}
+}
+
+// scanCategoria lee una categoría de una fila
+func scanCategoria(fila scanner) (*domain.Categoria, error) {
+	categoria := &domain.Categoria{}
+	var padreID sql.NullInt64
+	var tasaIVA sql.Null[domain.Porcentaje]
+
+	err := fila.Scan(&categoria.ID, &categoria.Nombre, &categoria.Descripcion, &padreID, &tasaIVA)
+	if err != nil {
+		return nil, err
+	}
+
+	if padreID.Valid {
+		id := int(padreID.Int64)
+		categoria.CategoriaPadreID = &id
+	}
+	if tasaIVA.Valid {
+		categoria.TasaIVA = &tasaIVA.V
+	}
+	return categoria, nil
+}
+
+// GetByID obtiene una categoría por su ID
+func (r *SQLCategoriaRepository) GetByID(id int) (*domain.Categoria, error) {
+	query := `SELECT id_categoria, nombre, descripcion, id_categoria_padre, tasa_iva FROM Categoria WHERE id_categoria = ?`
+
+	return scanCategoria(r.db.QueryRow(query, id))
+}
+
+// GetAll obtiene todas las categorías
+func (r *SQLCategoriaRepository) GetAll() ([]*domain.Categoria, error) {
+	query := `SELECT id_categoria, nombre, descripcion, id_categoria_padre, tasa_iva FROM Categoria ORDER BY id_categoria`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categorias := []*domain.Categoria{}
+	for rows.Next() {
+		categoria, err := scanCategoria(rows)
+		if err != nil {
+			return nil, err
+		}
+		categorias = append(categorias, categoria)
+	}
+
+	return categorias, rows.Err()
+}
+
+// Create crea una nueva categoría
+func (r *SQLCategoriaRepository) Create(categoria *domain.Categoria) (int, error) {
+	query := `INSERT INTO Categoria (nombre, descripcion, id_categoria_padre, tasa_iva) VALUES (?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, categoria.Nombre, categoria.Descripcion, categoria.CategoriaPadreID, categoria.TasaIVA)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Update actualiza una categoría existente
+func (r *SQLCategoriaRepository) Update(categoria *domain.Categoria) error {
+	query := `UPDATE Categoria SET nombre = ?, descripcion = ?, id_categoria_padre = ?, tasa_iva = ?
+              WHERE id_categoria = ?`
+
+	_, err := r.db.Exec(query,
+		categoria.Nombre, categoria.Descripcion, categoria.CategoriaPadreID, categoria.TasaIVA, categoria.ID,
+	)
+
+	return err
+}
+
+// CountProductos cuenta los productos asignados directamente a una categoría
+func (r *SQLCategoriaRepository) CountProductos(id int) (int, error) {
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM Producto WHERE id_categoria = ?`, id).Scan(&total)
+
+	return total, err
+}
+
+// Delete elimina una categoría
+func (r *SQLCategoriaRepository) Delete(id int) error {
+	query := `DELETE FROM Categoria WHERE id_categoria = ?`
+
+	_, err := r.db.Exec(query, id)
+
+	return err
+}
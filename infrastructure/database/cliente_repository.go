@@ -0,0 +1,125 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"time"
+)
+
+// SQLClienteRepository implementa la interfaz ClienteRepository usando MySQL
+type SQLClienteRepository struct {
+	db dbExecutor
+}
+
+// NewSQLClienteRepository crea un nuevo repositorio de clientes SQL
+func NewSQLClienteRepository(db *sql.DB) ports.ClienteRepository {
+	return &SQLClienteRepository{
+		db: db,
+	}
+}
+
+// GetByID obtiene un cliente por su ID
+func (r *SQLClienteRepository) GetByID(id int) (*domain.Cliente, error) {
+	query := `SELECT id_cliente, nombre, rfc, email, telefono, direccion, fecha_registro 
+              FROM Cliente WHERE id_cliente = ?`
+
+	cliente := &domain.Cliente{}
+	err := r.db.QueryRow(query, id).Scan(
+		&cliente.ID, &cliente.Nombre, &cliente.RFC, &cliente.Email,
+		&cliente.Telefono, &cliente.Direccion, &cliente.FechaRegistro,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cliente, nil
+}
+
+// ordenClientes son los campos por los que se pueden ordenar los clientes
+var ordenClientes = ordenListado{
+	campos: map[string]string{
+		"id":             "id_cliente",
+		"nombre":         "nombre",
+		"fecha_registro": "fecha_registro",
+	},
+	predeterminado: "id",
+	desempate:      "id_cliente",
+}
+
+// GetAll obtiene una página de los clientes. Filtra por fecha de registro.
+func (r *SQLClienteRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Cliente, int, error) {
+	l := &listado{}
+	l.rangoFechas(`fecha_registro`, opciones.Filtros.Desde, opciones.Filtros.Hasta)
+
+	return paginar(r.db, l, `id_cliente, nombre, rfc, email, telefono, direccion, fecha_registro`,
+		`FROM Cliente`, ordenClientes, opciones, r.queryClientes)
+}
+
+// queryClientes ejecuta una consulta que retorna clientes
+func (r *SQLClienteRepository) queryClientes(query string, args ...interface{}) ([]*domain.Cliente, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clientes := []*domain.Cliente{}
+	for rows.Next() {
+		cliente := &domain.Cliente{}
+		err := rows.Scan(
+			&cliente.ID, &cliente.Nombre, &cliente.RFC, &cliente.Email,
+			&cliente.Telefono, &cliente.Direccion, &cliente.FechaRegistro,
+		)
+		if err != nil {
+			return nil, err
+		}
+		clientes = append(clientes, cliente)
+	}
+
+	return clientes, rows.Err()
+}
+
+// Create crea un nuevo cliente
+func (r *SQLClienteRepository) Create(cliente *domain.Cliente) (int, error) {
+	query := `INSERT INTO Cliente (nombre, rfc, email, telefono, direccion, fecha_registro) 
+              VALUES (?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		cliente.Nombre, cliente.RFC, cliente.Email, cliente.Telefono,
+		cliente.Direccion, time.Now().Format("2006-01-02 15:04:05"),
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Update actualiza un cliente existente
+func (r *SQLClienteRepository) Update(cliente *domain.Cliente) error {
+	query := `UPDATE Cliente SET nombre = ?, rfc = ?, email = ?, telefono = ?, direccion = ? 
+              WHERE id_cliente = ?`
+
+	_, err := r.db.Exec(query,
+		cliente.Nombre, cliente.RFC, cliente.Email, cliente.Telefono, cliente.Direccion, cliente.ID,
+	)
+
+	return err
+}
+
+// Delete elimina un cliente
+func (r *SQLClienteRepository) Delete(id int) error {
+	query := `DELETE FROM Cliente WHERE id_cliente = ?`
+
+	_, err := r.db.Exec(query, id)
+
+	return err
+}
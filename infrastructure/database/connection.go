@@ -1,6 +1,7 @@
 package database
 
 import (
+	"ActividadDesempenioAPIz/core/domain"
 	"database/sql"
 	"fmt"
 	"log"
@@ -82,6 +83,7 @@ func InitDB() {
 
 	// Creamos las tablas si no existen
 	createTables()
+	migrateTables()
 }
 
 // GetDB returns the database connection
@@ -99,37 +101,139 @@ func createTables() {
 		direccion VARCHAR(200),
 		telefono VARCHAR(20),
 		email VARCHAR(100),
-		fecha_registro DATETIME
+		fecha_registro DATETIME,
+		deleted_at DATETIME
 	)`)
 
 	if err != nil {
 		log.Printf("Error al crear tabla Proveedor: %v", err)
 	}
 
+	// Tabla Cliente
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Cliente (
+		id_cliente INT AUTO_INCREMENT PRIMARY KEY,
+		nombre VARCHAR(100) NOT NULL,
+		rfc VARCHAR(13) NOT NULL DEFAULT '',
+		email VARCHAR(100) NOT NULL DEFAULT '',
+		telefono VARCHAR(20) NOT NULL DEFAULT '',
+		direccion VARCHAR(255) NOT NULL DEFAULT '',
+		fecha_registro DATETIME
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Cliente: %v", err)
+	}
+
+	// Tabla Almacen
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Almacen (
+		id_almacen INT AUTO_INCREMENT PRIMARY KEY,
+		nombre VARCHAR(100) NOT NULL,
+		direccion VARCHAR(200),
+		fecha_registro DATETIME
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Almacen: %v", err)
+	}
+
+	// Tabla Categoria: árbol de categorías de producto
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Categoria (
+		id_categoria INT AUTO_INCREMENT PRIMARY KEY,
+		nombre VARCHAR(100) NOT NULL,
+		descripcion VARCHAR(255) NOT NULL DEFAULT '',
+		id_categoria_padre INT,
+		tasa_iva DECIMAL(5,2),
+		FOREIGN KEY (id_categoria_padre) REFERENCES Categoria(id_categoria)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Categoria: %v", err)
+	}
+
 	// Tabla Producto
 	_, err = DB.Exec(`
 	CREATE TABLE IF NOT EXISTS Producto (
 		id_producto INT AUTO_INCREMENT PRIMARY KEY,
+		sku VARCHAR(50) UNIQUE,
+		codigo_barras VARCHAR(13) UNIQUE,
 		nombre VARCHAR(100) NOT NULL,
 		descripcion TEXT,
-		precio INT NOT NULL,
+		precio DECIMAL(12,2) NOT NULL,
+		costo_promedio DECIMAL(12,2) NOT NULL DEFAULT 0,
 		existencia INT NOT NULL DEFAULT 0,
+		stock_minimo INT NOT NULL DEFAULT 5,
+		punto_reorden INT NOT NULL DEFAULT 0,
+		cantidad_reorden INT NOT NULL DEFAULT 0,
 		id_proveedor INT,
+		id_categoria INT,
+		tasa_iva DECIMAL(5,2),
 		fecha_creacion DATETIME,
-		FOREIGN KEY (id_proveedor) REFERENCES Proveedor(id_proveedor)
+		deleted_at DATETIME,
+		FULLTEXT INDEX idx_producto_busqueda (nombre, descripcion),
+		FOREIGN KEY (id_proveedor) REFERENCES Proveedor(id_proveedor),
+		FOREIGN KEY (id_categoria) REFERENCES Categoria(id_categoria)
 	)`)
 
 	if err != nil {
 		log.Printf("Error al crear tabla Producto: %v", err)
 	}
 
+	// Tabla Producto_Atributo: atributos libres clave/valor de cada producto
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Producto_Atributo (
+		id_producto INT NOT NULL,
+		clave VARCHAR(50) NOT NULL,
+		valor VARCHAR(255) NOT NULL,
+		PRIMARY KEY (id_producto, clave),
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto) ON DELETE CASCADE
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Producto_Atributo: %v", err)
+	}
+
+	// Tabla Promocion: descuentos automáticos por producto o por categoría
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Promocion (
+		id_promocion INT AUTO_INCREMENT PRIMARY KEY,
+		nombre VARCHAR(100) NOT NULL,
+		tipo VARCHAR(30) NOT NULL,
+		id_producto INT,
+		id_categoria INT,
+		lleva INT NOT NULL DEFAULT 0,
+		paga INT NOT NULL DEFAULT 0,
+		porcentaje DECIMAL(5,2) NOT NULL DEFAULT 0,
+		activa BOOLEAN NOT NULL DEFAULT TRUE,
+		fecha_inicio DATETIME,
+		fecha_fin DATETIME,
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto),
+		FOREIGN KEY (id_categoria) REFERENCES Categoria(id_categoria)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Promocion: %v", err)
+	}
+
 	// Tabla Pedido
 	_, err = DB.Exec(`
 	CREATE TABLE IF NOT EXISTS Pedido (
 		id_pedido INT AUTO_INCREMENT PRIMARY KEY,
 		fecha_pedido DATETIME,
-		estado VARCHAR(20) NOT NULL,
-		total FLOAT NOT NULL
+		estado VARCHAR(30) NOT NULL,
+		id_almacen INT NOT NULL DEFAULT 1,
+		id_cliente INT,
+		direccion_entrega VARCHAR(255),
+		subtotal DECIMAL(12,2) NOT NULL DEFAULT 0,
+		descuento_porcentaje DECIMAL(5,2),
+		descuento_monto DECIMAL(12,2),
+		descuento DECIMAL(12,2) NOT NULL DEFAULT 0,
+		impuesto DECIMAL(12,2) NOT NULL DEFAULT 0,
+		total DECIMAL(12,2) NOT NULL,
+		FOREIGN KEY (id_almacen) REFERENCES Almacen(id_almacen),
+		FOREIGN KEY (id_cliente) REFERENCES Cliente(id_cliente)
 	)`)
 
 	if err != nil {
@@ -143,10 +247,19 @@ func createTables() {
 		id_pedido INT,
 		id_producto INT,
 		cantidad INT NOT NULL,
-		precio_unitario FLOAT NOT NULL,
-		subtotal FLOAT,
+		cantidad_facturada INT NOT NULL DEFAULT 0,
+		precio_unitario DECIMAL(12,2) NOT NULL,
+		subtotal DECIMAL(12,2) NOT NULL,
+		descuento_porcentaje DECIMAL(5,2),
+		descuento_monto DECIMAL(12,2),
+		id_promocion INT,
+		descuento DECIMAL(12,2) NOT NULL DEFAULT 0,
+		tasa_iva DECIMAL(5,2) NOT NULL DEFAULT 0,
+		impuesto DECIMAL(12,2) NOT NULL DEFAULT 0,
+		total DECIMAL(12,2) NOT NULL DEFAULT 0,
 		FOREIGN KEY (id_pedido) REFERENCES Pedido(id_pedido),
-		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto)
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto),
+		FOREIGN KEY (id_promocion) REFERENCES Promocion(id_promocion) ON DELETE SET NULL
 	)`)
 
 	if err != nil {
@@ -159,7 +272,19 @@ func createTables() {
 		id_venta INT AUTO_INCREMENT PRIMARY KEY,
 		fecha_venta DATETIME,
 		estado VARCHAR(20) NOT NULL,
-		total FLOAT NOT NULL
+		id_almacen INT NOT NULL DEFAULT 1,
+		id_cliente INT,
+		direccion_entrega VARCHAR(255),
+		id_pedido INT,
+		subtotal DECIMAL(12,2) NOT NULL DEFAULT 0,
+		descuento_porcentaje DECIMAL(5,2),
+		descuento_monto DECIMAL(12,2),
+		descuento DECIMAL(12,2) NOT NULL DEFAULT 0,
+		impuesto DECIMAL(12,2) NOT NULL DEFAULT 0,
+		total DECIMAL(12,2) NOT NULL,
+		FOREIGN KEY (id_almacen) REFERENCES Almacen(id_almacen),
+		FOREIGN KEY (id_cliente) REFERENCES Cliente(id_cliente),
+		FOREIGN KEY (id_pedido) REFERENCES Pedido(id_pedido)
 	)`)
 
 	if err != nil {
@@ -173,10 +298,19 @@ func createTables() {
 		id_venta INT,
 		id_producto INT,
 		cantidad INT NOT NULL,
-		precio_unitario FLOAT NOT NULL,
-		subtotal FLOAT,
+		precio_unitario DECIMAL(12,2) NOT NULL,
+		costo_unitario DECIMAL(12,2) NOT NULL DEFAULT 0,
+		subtotal DECIMAL(12,2) NOT NULL,
+		descuento_porcentaje DECIMAL(5,2),
+		descuento_monto DECIMAL(12,2),
+		id_promocion INT,
+		descuento DECIMAL(12,2) NOT NULL DEFAULT 0,
+		tasa_iva DECIMAL(5,2) NOT NULL DEFAULT 0,
+		impuesto DECIMAL(12,2) NOT NULL DEFAULT 0,
+		total DECIMAL(12,2) NOT NULL DEFAULT 0,
 		FOREIGN KEY (id_venta) REFERENCES Venta(id_venta),
-		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto)
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto),
+		FOREIGN KEY (id_promocion) REFERENCES Promocion(id_promocion) ON DELETE SET NULL
 	)`)
 
 	if err != nil {
@@ -189,9 +323,11 @@ func createTables() {
 		id_orden_proveedor INT AUTO_INCREMENT PRIMARY KEY,
 		id_proveedor INT,
 		fecha_orden DATETIME,
-		estado VARCHAR(20) NOT NULL,
-		total INT NOT NULL,
-		FOREIGN KEY (id_proveedor) REFERENCES Proveedor(id_proveedor)
+		estado VARCHAR(30) NOT NULL,
+		id_almacen INT NOT NULL DEFAULT 1,
+		total DECIMAL(12,2) NOT NULL,
+		FOREIGN KEY (id_proveedor) REFERENCES Proveedor(id_proveedor),
+		FOREIGN KEY (id_almacen) REFERENCES Almacen(id_almacen)
 	)`)
 
 	if err != nil {
@@ -205,8 +341,10 @@ func createTables() {
 		id_orden_proveedor INT,
 		id_producto INT,
 		cantidad INT NOT NULL,
-		precio_unitario FLOAT NOT NULL,
-		subtotal FLOAT,
+		cantidad_recibida INT NOT NULL DEFAULT 0,
+		cantidad_cancelada INT NOT NULL DEFAULT 0,
+		precio_unitario DECIMAL(12,2) NOT NULL,
+		subtotal DECIMAL(12,2) NOT NULL,
 		FOREIGN KEY (id_orden_proveedor) REFERENCES Orden_Proveedor(id_orden_proveedor),
 		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto)
 	)`)
@@ -214,4 +352,493 @@ func createTables() {
 	if err != nil {
 		log.Printf("Error al crear tabla Detalles_Orden: %v", err)
 	}
+
+	// Tabla Proveedor_Producto: catálogo de costos y condiciones de cada proveedor
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Proveedor_Producto (
+		id_proveedor INT NOT NULL,
+		id_producto INT NOT NULL,
+		sku_proveedor VARCHAR(50) NOT NULL DEFAULT '',
+		costo DECIMAL(12,2) NOT NULL,
+		cantidad_minima INT NOT NULL DEFAULT 1,
+		tiempo_entrega_dias INT NOT NULL DEFAULT 0,
+		preferido BOOLEAN NOT NULL DEFAULT FALSE,
+		fecha_actualizacion DATETIME NOT NULL,
+		PRIMARY KEY (id_proveedor, id_producto),
+		FOREIGN KEY (id_proveedor) REFERENCES Proveedor(id_proveedor),
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto) ON DELETE CASCADE
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Proveedor_Producto: %v", err)
+	}
+
+	// Tabla Existencia_Almacen: existencia de cada producto por almacén
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Existencia_Almacen (
+		id_producto INT NOT NULL,
+		id_almacen INT NOT NULL,
+		existencia INT NOT NULL DEFAULT 0,
+		existencia_danada INT NOT NULL DEFAULT 0,
+		PRIMARY KEY (id_producto, id_almacen),
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto),
+		FOREIGN KEY (id_almacen) REFERENCES Almacen(id_almacen)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Existencia_Almacen: %v", err)
+	}
+
+	// Tabla Movimiento_Inventario (kardex)
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Movimiento_Inventario (
+		id_movimiento INT AUTO_INCREMENT PRIMARY KEY,
+		id_producto INT NOT NULL,
+		id_almacen INT NOT NULL DEFAULT 1,
+		cantidad INT NOT NULL,
+		saldo_resultante INT NOT NULL,
+		saldo_almacen INT NOT NULL DEFAULT 0,
+		motivo VARCHAR(30) NOT NULL,
+		tipo_documento VARCHAR(30),
+		id_documento INT,
+		fecha DATETIME NOT NULL,
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto),
+		FOREIGN KEY (id_almacen) REFERENCES Almacen(id_almacen),
+		INDEX idx_movimiento_producto_fecha (id_producto, fecha)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Movimiento_Inventario: %v", err)
+	}
+
+	// Tablas Transferencia y Detalles_Transferencia
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Transferencia (
+		id_transferencia INT AUTO_INCREMENT PRIMARY KEY,
+		id_almacen_origen INT NOT NULL,
+		id_almacen_destino INT NOT NULL,
+		usuario VARCHAR(100) NOT NULL,
+		comentario VARCHAR(255) NOT NULL DEFAULT '',
+		fecha DATETIME NOT NULL,
+		FOREIGN KEY (id_almacen_origen) REFERENCES Almacen(id_almacen),
+		FOREIGN KEY (id_almacen_destino) REFERENCES Almacen(id_almacen)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Transferencia: %v", err)
+	}
+
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Detalles_Transferencia (
+		id_detalle_transferencia INT AUTO_INCREMENT PRIMARY KEY,
+		id_transferencia INT NOT NULL,
+		id_producto INT NOT NULL,
+		cantidad INT NOT NULL,
+		FOREIGN KEY (id_transferencia) REFERENCES Transferencia(id_transferencia),
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Detalles_Transferencia: %v", err)
+	}
+
+	// Tablas Devolucion y Detalles_Devolucion
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Devolucion (
+		id_devolucion INT AUTO_INCREMENT PRIMARY KEY,
+		id_venta INT NOT NULL,
+		id_almacen INT NOT NULL,
+		motivo VARCHAR(255) NOT NULL DEFAULT '',
+		usuario VARCHAR(100) NOT NULL,
+		fecha DATETIME NOT NULL,
+		reembolso DECIMAL(12,2) NOT NULL,
+		FOREIGN KEY (id_venta) REFERENCES Venta(id_venta),
+		FOREIGN KEY (id_almacen) REFERENCES Almacen(id_almacen)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Devolucion: %v", err)
+	}
+
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Detalles_Devolucion (
+		id_detalle_devolucion INT AUTO_INCREMENT PRIMARY KEY,
+		id_devolucion INT NOT NULL,
+		id_detalle_venta INT NOT NULL,
+		id_producto INT NOT NULL,
+		cantidad INT NOT NULL,
+		destino VARCHAR(20) NOT NULL,
+		precio_unitario DECIMAL(12,2) NOT NULL,
+		reembolso DECIMAL(12,2) NOT NULL,
+		FOREIGN KEY (id_devolucion) REFERENCES Devolucion(id_devolucion),
+		FOREIGN KEY (id_detalle_venta) REFERENCES Detalles_Venta(id_detalle_venta),
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Detalles_Devolucion: %v", err)
+	}
+
+	// Tabla Serie_Folio: último folio asignado de cada serie de comprobantes
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Serie_Folio (
+		serie VARCHAR(10) PRIMARY KEY,
+		ultimo_folio INT NOT NULL DEFAULT 0
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Serie_Folio: %v", err)
+	}
+
+	// Tabla Documento_Fiscal: facturas y cancelaciones emitidas para las ventas
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Documento_Fiscal (
+		id_documento_fiscal INT AUTO_INCREMENT PRIMARY KEY,
+		id_venta INT NOT NULL,
+		tipo VARCHAR(20) NOT NULL,
+		serie VARCHAR(10) NOT NULL,
+		folio INT NOT NULL,
+		fecha_emision DATETIME NOT NULL,
+		id_documento_relacionado INT,
+		UNIQUE KEY uk_documento_folio (serie, folio),
+		UNIQUE KEY uk_documento_venta (id_venta, tipo),
+		FOREIGN KEY (id_venta) REFERENCES Venta(id_venta),
+		FOREIGN KEY (id_documento_relacionado) REFERENCES Documento_Fiscal(id_documento_fiscal)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Documento_Fiscal: %v", err)
+	}
+
+	// Tabla Auditoria: bitácora de las operaciones que modifican registros. No
+	// referencia a las entidades para conservar la bitácora de las que se
+	// eliminan definitivamente.
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Auditoria (
+		id_auditoria INT AUTO_INCREMENT PRIMARY KEY,
+		actor VARCHAR(100) NOT NULL,
+		metodo VARCHAR(10) NOT NULL,
+		ruta VARCHAR(255) NOT NULL,
+		accion VARCHAR(20) NOT NULL,
+		entidad VARCHAR(30) NOT NULL,
+		id_entidad INT NOT NULL,
+		antes JSON,
+		despues JSON,
+		fecha DATETIME NOT NULL,
+		INDEX idx_auditoria_entidad (entidad, id_entidad),
+		INDEX idx_auditoria_actor (actor),
+		INDEX idx_auditoria_fecha (fecha)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Auditoria: %v", err)
+	}
+
+	// Tabla Lote: lotes de producto por almacén con su caducidad
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Lote (
+		id_lote INT AUTO_INCREMENT PRIMARY KEY,
+		id_producto INT NOT NULL,
+		id_almacen INT NOT NULL,
+		numero_lote VARCHAR(50) NOT NULL,
+		fecha_caducidad DATE,
+		cantidad_inicial INT NOT NULL,
+		existencia INT NOT NULL,
+		id_orden_proveedor INT,
+		fecha_recepcion DATETIME NOT NULL,
+		FOREIGN KEY (id_producto) REFERENCES Producto(id_producto),
+		FOREIGN KEY (id_almacen) REFERENCES Almacen(id_almacen),
+		FOREIGN KEY (id_orden_proveedor) REFERENCES Orden_Proveedor(id_orden_proveedor),
+		INDEX idx_lote_producto_almacen_caducidad (id_producto, id_almacen, fecha_caducidad),
+		INDEX idx_lote_caducidad (fecha_caducidad)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Lote: %v", err)
+	}
+
+	// Tabla Movimiento_Lote: parte de cada movimiento del kardex que afectó a un lote
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS Movimiento_Lote (
+		id_movimiento_lote INT AUTO_INCREMENT PRIMARY KEY,
+		id_lote INT NOT NULL,
+		id_movimiento INT NOT NULL,
+		cantidad INT NOT NULL,
+		FOREIGN KEY (id_lote) REFERENCES Lote(id_lote),
+		FOREIGN KEY (id_movimiento) REFERENCES Movimiento_Inventario(id_movimiento)
+	)`)
+
+	if err != nil {
+		log.Printf("Error al crear tabla Movimiento_Lote: %v", err)
+	}
+
+	// Tablas de historial de estados, una por tipo de documento
+	historiales := []struct {
+		tabla      string
+		referencia string
+	}{
+		{"Historial_Estado_Pedido", "Pedido(id_pedido)"},
+		{"Historial_Estado_Venta", "Venta(id_venta)"},
+		{"Historial_Estado_Orden", "Orden_Proveedor(id_orden_proveedor)"},
+	}
+
+	for _, h := range historiales {
+		_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS ` + h.tabla + ` (
+			id_historial INT AUTO_INCREMENT PRIMARY KEY,
+			id_documento INT NOT NULL,
+			estado_anterior VARCHAR(30) NOT NULL DEFAULT '',
+			estado_nuevo VARCHAR(30) NOT NULL,
+			usuario VARCHAR(100) NOT NULL,
+			comentario VARCHAR(255) NOT NULL DEFAULT '',
+			fecha DATETIME NOT NULL,
+			FOREIGN KEY (id_documento) REFERENCES ` + h.referencia + `
+		)`)
+
+		if err != nil {
+			log.Printf("Error al crear tabla %s: %v", h.tabla, err)
+		}
+	}
+}
+
+// migrateTables actualiza las tablas creadas por versiones anteriores de la API
+func migrateTables() {
+	// Los estados como "parcialmente_recibida" o "parcialmente_facturado" no
+	// caben en VARCHAR(20)
+	var err error
+	for _, tabla := range []string{"Orden_Proveedor", "Pedido"} {
+		_, err = DB.Exec(`ALTER TABLE ` + tabla + ` MODIFY estado VARCHAR(30) NOT NULL`)
+		if err != nil {
+			log.Printf("Error al ampliar columna %s.estado: %v", tabla, err)
+		}
+	}
+
+	// Los montos se guardan como DECIMAL exacto en lugar de INT o FLOAT
+	columnasMonto := []struct {
+		tabla   string
+		columna string
+	}{
+		{"Producto", "precio"},
+		{"Pedido", "total"},
+		{"Detalles_Pedido", "precio_unitario"},
+		{"Detalles_Pedido", "subtotal"},
+		{"Venta", "total"},
+		{"Detalles_Venta", "precio_unitario"},
+		{"Detalles_Venta", "subtotal"},
+		{"Orden_Proveedor", "total"},
+		{"Detalles_Orden", "precio_unitario"},
+		{"Detalles_Orden", "subtotal"},
+	}
+	for _, m := range columnasMonto {
+		if m.columna == "subtotal" {
+			// Los subtotales de versiones anteriores podían quedar en NULL
+			_, err = DB.Exec(`UPDATE ` + m.tabla + ` SET subtotal = cantidad * precio_unitario WHERE subtotal IS NULL`)
+			if err != nil {
+				log.Printf("Error al calcular subtotales de %s: %v", m.tabla, err)
+			}
+		}
+
+		_, err = DB.Exec(`ALTER TABLE ` + m.tabla + ` MODIFY ` + m.columna + ` DECIMAL(12,2) NOT NULL`)
+		if err != nil {
+			log.Printf("Error al convertir columna %s.%s a DECIMAL: %v", m.tabla, m.columna, err)
+		}
+	}
+
+	if ensureColumn("Detalles_Orden", "cantidad_recibida", "INT NOT NULL DEFAULT 0 AFTER cantidad") {
+		// Las órdenes ya recibidas antes de la migración se recibieron completas
+		_, err = DB.Exec(`
+		UPDATE Detalles_Orden d
+		JOIN Orden_Proveedor o ON o.id_orden_proveedor = d.id_orden_proveedor
+		SET d.cantidad_recibida = d.cantidad
+		WHERE o.estado = 'recibida'`)
+		if err != nil {
+			log.Printf("Error al inicializar Detalles_Orden.cantidad_recibida: %v", err)
+		}
+	}
+	ensureColumn("Detalles_Orden", "cantidad_cancelada", "INT NOT NULL DEFAULT 0 AFTER cantidad_recibida")
+
+	// Umbrales de stock por producto
+	ensureColumn("Producto", "stock_minimo", "INT NOT NULL DEFAULT 5 AFTER existencia")
+	ensureColumn("Producto", "punto_reorden", "INT NOT NULL DEFAULT 0 AFTER stock_minimo")
+	ensureColumn("Producto", "cantidad_reorden", "INT NOT NULL DEFAULT 0 AFTER punto_reorden")
+
+	migrateAlmacenes()
+
+	// Catálogo: SKU y código de barras únicos (NULL si el producto no los tiene) y categoría
+	ensureColumn("Producto", "sku", "VARCHAR(50) UNIQUE AFTER id_producto")
+	ensureColumn("Producto", "codigo_barras", "VARCHAR(13) UNIQUE AFTER sku")
+	if ensureColumn("Producto", "id_categoria", "INT AFTER id_proveedor") {
+		_, err = DB.Exec(`ALTER TABLE Producto ADD FOREIGN KEY (id_categoria) REFERENCES Categoria(id_categoria)`)
+		if err != nil {
+			log.Printf("Error al agregar llave foránea Producto.id_categoria: %v", err)
+		}
+	}
+
+	// Costo promedio: se inicializa con el último precio recibido de cada
+	// producto y las ventas anteriores toman ese costo
+	if ensureColumn("Producto", "costo_promedio", "DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER precio") {
+		_, err = DB.Exec(`
+		UPDATE Producto p SET p.costo_promedio = COALESCE((
+			SELECT d.precio_unitario FROM Detalles_Orden d
+			WHERE d.id_producto = p.id_producto AND d.cantidad_recibida > 0
+			ORDER BY d.id_detalle_orden DESC LIMIT 1), 0)`)
+		if err != nil {
+			log.Printf("Error al inicializar Producto.costo_promedio: %v", err)
+		}
+	}
+	if ensureColumn("Detalles_Venta", "costo_unitario", "DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER precio_unitario") {
+		_, err = DB.Exec(`
+		UPDATE Detalles_Venta d JOIN Producto p ON p.id_producto = d.id_producto
+		SET d.costo_unitario = p.costo_promedio`)
+		if err != nil {
+			log.Printf("Error al inicializar Detalles_Venta.costo_unitario: %v", err)
+		}
+	}
+
+	// Cliente y dirección de entrega de pedidos y ventas
+	for _, tabla := range []string{"Pedido", "Venta"} {
+		if ensureColumn(tabla, "id_cliente", "INT AFTER id_almacen") {
+			_, err = DB.Exec(`ALTER TABLE ` + tabla + ` ADD FOREIGN KEY (id_cliente) REFERENCES Cliente(id_cliente)`)
+			if err != nil {
+				log.Printf("Error al agregar llave foránea %s.id_cliente: %v", tabla, err)
+			}
+		}
+		ensureColumn(tabla, "direccion_entrega", "VARCHAR(255) AFTER id_cliente")
+	}
+
+	// Facturación de pedidos: lo facturado por línea y el pedido de origen de la venta
+	ensureColumn("Detalles_Pedido", "cantidad_facturada", "INT NOT NULL DEFAULT 0 AFTER cantidad")
+	if ensureColumn("Venta", "id_pedido", "INT AFTER direccion_entrega") {
+		_, err = DB.Exec(`ALTER TABLE Venta ADD FOREIGN KEY (id_pedido) REFERENCES Pedido(id_pedido)`)
+		if err != nil {
+			log.Printf("Error al agregar llave foránea Venta.id_pedido: %v", err)
+		}
+	}
+
+	// Mercancía devuelta dañada, separada de la existencia vendible
+	ensureColumn("Existencia_Almacen", "existencia_danada", "INT NOT NULL DEFAULT 0 AFTER existencia")
+
+	// IVA y descuentos. Los documentos anteriores no tenían descuentos ni
+	// impuesto desglosado: su subtotal y el total de cada línea son lo que ya
+	// estaba registrado.
+	ensureColumn("Categoria", "tasa_iva", "DECIMAL(5,2) AFTER id_categoria_padre")
+	ensureColumn("Producto", "tasa_iva", "DECIMAL(5,2) AFTER id_categoria")
+	for _, tabla := range []string{"Pedido", "Venta"} {
+		if ensureColumn(tabla, "subtotal", "DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER direccion_entrega") {
+			_, err = DB.Exec(`UPDATE ` + tabla + ` SET subtotal = total`)
+			if err != nil {
+				log.Printf("Error al inicializar %s.subtotal: %v", tabla, err)
+			}
+		}
+		ensureColumn(tabla, "descuento_porcentaje", "DECIMAL(5,2) AFTER subtotal")
+		ensureColumn(tabla, "descuento_monto", "DECIMAL(12,2) AFTER descuento_porcentaje")
+		ensureColumn(tabla, "descuento", "DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER descuento_monto")
+		ensureColumn(tabla, "impuesto", "DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER descuento")
+	}
+	for _, tabla := range []string{"Detalles_Pedido", "Detalles_Venta"} {
+		ensureColumn(tabla, "descuento_porcentaje", "DECIMAL(5,2) AFTER subtotal")
+		ensureColumn(tabla, "descuento_monto", "DECIMAL(12,2) AFTER descuento_porcentaje")
+		if ensureColumn(tabla, "id_promocion", "INT AFTER descuento_monto") {
+			_, err = DB.Exec(`ALTER TABLE ` + tabla + ` ADD FOREIGN KEY (id_promocion)
+				REFERENCES Promocion(id_promocion) ON DELETE SET NULL`)
+			if err != nil {
+				log.Printf("Error al agregar llave foránea %s.id_promocion: %v", tabla, err)
+			}
+		}
+		ensureColumn(tabla, "descuento", "DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER id_promocion")
+		ensureColumn(tabla, "tasa_iva", "DECIMAL(5,2) NOT NULL DEFAULT 0 AFTER descuento")
+		ensureColumn(tabla, "impuesto", "DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER tasa_iva")
+		if ensureColumn(tabla, "total", "DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER impuesto") {
+			_, err = DB.Exec(`UPDATE ` + tabla + ` SET total = subtotal`)
+			if err != nil {
+				log.Printf("Error al inicializar %s.total: %v", tabla, err)
+			}
+		}
+	}
+
+	// Eliminación lógica de productos y proveedores
+	ensureColumn("Producto", "deleted_at", "DATETIME AFTER fecha_creacion")
+	ensureColumn("Proveedor", "deleted_at", "DATETIME AFTER fecha_registro")
+
+	// Búsqueda de productos por texto completo
+	ensureIndex("Producto", "idx_producto_busqueda", "FULLTEXT INDEX idx_producto_busqueda (nombre, descripcion)")
+}
+
+// migrateAlmacenes crea el almacén principal y traslada a él la existencia y
+// los documentos anteriores a la separación del inventario por almacén
+func migrateAlmacenes() {
+	_, err := DB.Exec(`INSERT IGNORE INTO Almacen (id_almacen, nombre, direccion, fecha_registro)
+		VALUES (?, 'Principal', '', NOW())`, domain.AlmacenPrincipalID)
+	if err != nil {
+		log.Printf("Error al crear el almacén principal: %v", err)
+	}
+
+	for _, tabla := range []string{"Pedido", "Venta", "Orden_Proveedor"} {
+		ensureColumn(tabla, "id_almacen", "INT NOT NULL DEFAULT 1 AFTER estado")
+	}
+
+	ensureColumn("Movimiento_Inventario", "id_almacen", "INT NOT NULL DEFAULT 1 AFTER id_producto")
+	if ensureColumn("Movimiento_Inventario", "saldo_almacen", "INT NOT NULL DEFAULT 0 AFTER saldo_resultante") {
+		// Antes de los almacenes todo el inventario estaba en el principal
+		_, err = DB.Exec(`UPDATE Movimiento_Inventario SET saldo_almacen = saldo_resultante`)
+		if err != nil {
+			log.Printf("Error al inicializar Movimiento_Inventario.saldo_almacen: %v", err)
+		}
+	}
+
+	// Los productos sin existencias por almacén conservan su existencia en el principal
+	_, err = DB.Exec(`
+	INSERT INTO Existencia_Almacen (id_producto, id_almacen, existencia)
+	SELECT p.id_producto, ?, p.existencia FROM Producto p
+	WHERE NOT EXISTS (SELECT 1 FROM Existencia_Almacen e WHERE e.id_producto = p.id_producto)`,
+		domain.AlmacenPrincipalID)
+	if err != nil {
+		log.Printf("Error al inicializar Existencia_Almacen: %v", err)
+	}
+}
+
+// ensureIndex agrega un índice a una tabla existente si aún no existe
+func ensureIndex(tabla, indice, definicion string) {
+	var existe int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?`,
+		tabla, indice,
+	).Scan(&existe)
+	if err != nil {
+		log.Printf("Error al consultar índice %s.%s: %v", tabla, indice, err)
+		return
+	}
+	if existe > 0 {
+		return
+	}
+
+	_, err = DB.Exec(`ALTER TABLE ` + tabla + ` ADD ` + definicion)
+	if err != nil {
+		log.Printf("Error al agregar índice %s.%s: %v", tabla, indice, err)
+	}
+}
+
+// ensureColumn agrega una columna a una tabla existente si aún no existe.
+// Retorna true sólo si la columna se agregó en esta llamada.
+func ensureColumn(tabla, columna, definicion string) bool {
+	var existe int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`,
+		tabla, columna,
+	).Scan(&existe)
+	if err != nil {
+		log.Printf("Error al consultar columna %s.%s: %v", tabla, columna, err)
+		return false
+	}
+	if existe > 0 {
+		return false
+	}
+
+	_, err = DB.Exec(`ALTER TABLE ` + tabla + ` ADD COLUMN ` + columna + ` ` + definicion)
+	if err != nil {
+		log.Printf("Error al agregar columna %s.%s: %v", tabla, columna, err)
+		return false
+	}
+
+	return true
 }
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+)
+
+// SQLDevolucionRepository implementa la interfaz DevolucionRepository usando MySQL
+type SQLDevolucionRepository struct {
+	db dbExecutor
+}
+
+// NewSQLDevolucionRepository crea un nuevo repositorio de devoluciones SQL
+func NewSQLDevolucionRepository(db *sql.DB) ports.DevolucionRepository {
+	return &SQLDevolucionRepository{
+		db: db,
+	}
+}
+
+// columnasDevolucion son las columnas que se leen de Devolucion, en el orden
+// de scanDevolucion
+const columnasDevolucion = `id_devolucion, id_venta, id_almacen, motivo, usuario, fecha, reembolso`
+
+// scanDevolucion lee una devolución de una fila con las columnas de columnasDevolucion
+func scanDevolucion(fila scanner) (*domain.Devolucion, error) {
+	devolucion := &domain.Devolucion{}
+	err := fila.Scan(
+		&devolucion.ID, &devolucion.VentaID, &devolucion.AlmacenID, &devolucion.Motivo,
+		&devolucion.Usuario, &devolucion.Fecha, &devolucion.Reembolso,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return devolucion, nil
+}
+
+// queryDevoluciones ejecuta una consulta que retorna devoluciones
+func (r *SQLDevolucionRepository) queryDevoluciones(query string, args ...interface{}) ([]*domain.Devolucion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devoluciones := []*domain.Devolucion{}
+	for rows.Next() {
+		devolucion, err := scanDevolucion(rows)
+		if err != nil {
+			return nil, err
+		}
+		devoluciones = append(devoluciones, devolucion)
+	}
+
+	return devoluciones, rows.Err()
+}
+
+// GetByID obtiene una devolución por su ID
+func (r *SQLDevolucionRepository) GetByID(id int) (*domain.Devolucion, error) {
+	query := `SELECT ` + columnasDevolucion + ` FROM Devolucion WHERE id_devolucion = ?`
+
+	return scanDevolucion(r.db.QueryRow(query, id))
+}
+
+// ordenDevoluciones son los campos por los que se pueden ordenar las
+// devoluciones; por omisión, de la más reciente a la más antigua
+var ordenDevoluciones = ordenListado{
+	campos: map[string]string{
+		"id":        "id_devolucion",
+		"fecha":     "fecha",
+		"reembolso": "reembolso",
+	},
+	predeterminado: "fecha",
+	descendente:    true,
+	desempate:      "id_devolucion",
+}
+
+// GetAll obtiene una página de las devoluciones. Filtra por fecha, almacén y
+// rango del reembolso.
+func (r *SQLDevolucionRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Devolucion, int, error) {
+	l := &listado{}
+	l.rangoFechas(`fecha`, opciones.Filtros.Desde, opciones.Filtros.Hasta)
+	if opciones.Filtros.AlmacenID != 0 {
+		l.donde(`id_almacen = ?`, opciones.Filtros.AlmacenID)
+	}
+	l.rangoMontos(`reembolso`, opciones.Filtros.PrecioMin, opciones.Filtros.PrecioMax)
+
+	return paginar(r.db, l, columnasDevolucion, `FROM Devolucion`, ordenDevoluciones, opciones, r.queryDevoluciones)
+}
+
+// GetByVenta obtiene las devoluciones de una venta, en el orden en que se registraron
+func (r *SQLDevolucionRepository) GetByVenta(ventaID int) ([]*domain.Devolucion, error) {
+	query := `SELECT ` + columnasDevolucion + ` FROM Devolucion WHERE id_venta = ? ORDER BY id_devolucion`
+
+	return r.queryDevoluciones(query, ventaID)
+}
+
+// Create registra una nueva devolución
+func (r *SQLDevolucionRepository) Create(devolucion *domain.Devolucion) (int, error) {
+	query := `INSERT INTO Devolucion (id_venta, id_almacen, motivo, usuario, fecha, reembolso)
+              VALUES (?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		devolucion.VentaID, devolucion.AlmacenID, devolucion.Motivo,
+		devolucion.Usuario, devolucion.Fecha, devolucion.Reembolso,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// CreateDetalle registra una línea de una devolución
+func (r *SQLDevolucionRepository) CreateDetalle(detalle *domain.DetallesDevolucion) (int, error) {
+	query := `INSERT INTO Detalles_Devolucion (id_devolucion, id_detalle_venta, id_producto, cantidad,
+              destino, precio_unitario, reembolso) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		detalle.DevolucionID, detalle.DetalleVentaID, detalle.ProductoID, detalle.Cantidad,
+		detalle.Destino, detalle.PrecioUnitario, detalle.Reembolso,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// GetDetalles obtiene las líneas de una devolución
+func (r *SQLDevolucionRepository) GetDetalles(devolucionID int) ([]*domain.DetallesDevolucion, error) {
+	query := `SELECT id_detalle_devolucion, id_devolucion, id_detalle_venta, id_producto, cantidad,
+              destino, precio_unitario, reembolso
+              FROM Detalles_Devolucion WHERE id_devolucion = ? ORDER BY id_detalle_devolucion`
+
+	rows, err := r.db.Query(query, devolucionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	detalles := []*domain.DetallesDevolucion{}
+	for rows.Next() {
+		detalle := &domain.DetallesDevolucion{}
+		err := rows.Scan(
+			&detalle.ID, &detalle.DevolucionID, &detalle.DetalleVentaID, &detalle.ProductoID, &detalle.Cantidad,
+			&detalle.Destino, &detalle.PrecioUnitario, &detalle.Reembolso,
+		)
+		if err != nil {
+			return nil, err
+		}
+		detalles = append(detalles, detalle)
+	}
+
+	return detalles, rows.Err()
+}
+
+// GetDevueltoPorDetalle obtiene, por id de línea de venta, las unidades ya
+// devueltas de una venta. Las líneas sin devoluciones no aparecen.
+func (r *SQLDevolucionRepository) GetDevueltoPorDetalle(ventaID int) (map[int]int, error) {
+	query := `SELECT dd.id_detalle_venta, SUM(dd.cantidad)
+              FROM Detalles_Devolucion dd
+              JOIN Devolucion d ON d.id_devolucion = dd.id_devolucion
+              WHERE d.id_venta = ?
+              GROUP BY dd.id_detalle_venta`
+
+	rows, err := r.db.Query(query, ventaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devuelto := make(map[int]int)
+	for rows.Next() {
+		var detalleID, cantidad int
+		if err := rows.Scan(&detalleID, &cantidad); err != nil {
+			return nil, err
+		}
+		devuelto[detalleID] = cantidad
+	}
+
+	return devuelto, rows.Err()
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+)
+
+// SQLFacturaRepository implementa la interfaz FacturaRepository usando MySQL
+type SQLFacturaRepository struct {
+	db dbExecutor
+}
+
+// NewSQLFacturaRepository crea un nuevo repositorio de comprobantes fiscales SQL
+func NewSQLFacturaRepository(db *sql.DB) ports.FacturaRepository {
+	return &SQLFacturaRepository{
+		db: db,
+	}
+}
+
+// GetByVenta obtiene el comprobante del tipo indicado emitido para una venta.
+// Retorna sql.ErrNoRows si no se ha emitido.
+func (r *SQLFacturaRepository) GetByVenta(ventaID int, tipo domain.TipoComprobante) (*domain.DocumentoFiscal, error) {
+	query := `SELECT id_documento_fiscal, id_venta, tipo, serie, folio, fecha_emision, id_documento_relacionado
+              FROM Documento_Fiscal WHERE id_venta = ? AND tipo = ?`
+
+	documento := &domain.DocumentoFiscal{}
+	var relacionadoID sql.NullInt64
+	err := r.db.QueryRow(query, ventaID, tipo).Scan(
+		&documento.ID, &documento.VentaID, &documento.Tipo, &documento.Serie, &documento.Folio,
+		&documento.FechaEmision, &relacionadoID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if relacionadoID.Valid {
+		id := int(relacionadoID.Int64)
+		documento.DocumentoRelacionadoID = &id
+	}
+	return documento, nil
+}
+
+// SiguienteFolio reserva el siguiente folio de una serie. El renglón de la
+// serie queda bloqueado hasta que termina la transacción, así que los folios
+// son consecutivos y no se saltan aunque otra transacción se revierta.
+func (r *SQLFacturaRepository) SiguienteFolio(serie string) (int, error) {
+	if _, err := r.db.Exec(`INSERT IGNORE INTO Serie_Folio (serie, ultimo_folio) VALUES (?, 0)`, serie); err != nil {
+		return 0, err
+	}
+
+	query := `UPDATE Serie_Folio SET ultimo_folio = LAST_INSERT_ID(ultimo_folio + 1) WHERE serie = ?`
+	result, err := r.db.Exec(query, serie)
+	if err != nil {
+		return 0, err
+	}
+
+	folio, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(folio), nil
+}
+
+// Create registra un comprobante emitido
+func (r *SQLFacturaRepository) Create(documento *domain.DocumentoFiscal) (int, error) {
+	query := `INSERT INTO Documento_Fiscal (id_venta, tipo, serie, folio, fecha_emision, id_documento_relacionado)
+              VALUES (?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		documento.VentaID, documento.Tipo, documento.Serie, documento.Folio, documento.FechaEmision,
+		documento.DocumentoRelacionadoID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
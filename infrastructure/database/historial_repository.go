@@ -0,0 +1,95 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"fmt"
+)
+
+// tablasHistorial asocia cada tipo de documento con su tabla de historial
+var tablasHistorial = map[domain.TipoDocumento]string{
+	domain.DocumentoPedido:         "Historial_Estado_Pedido",
+	domain.DocumentoVenta:          "Historial_Estado_Venta",
+	domain.DocumentoOrdenProveedor: "Historial_Estado_Orden",
+}
+
+// SQLHistorialEstadoRepository implementa la interfaz HistorialEstadoRepository usando MySQL
+type SQLHistorialEstadoRepository struct {
+	db dbExecutor
+}
+
+// NewSQLHistorialEstadoRepository crea un nuevo repositorio de historial de estados SQL
+func NewSQLHistorialEstadoRepository(db *sql.DB) ports.HistorialEstadoRepository {
+	return &SQLHistorialEstadoRepository{
+		db: db,
+	}
+}
+
+// tabla retorna la tabla de historial del tipo de documento
+func (r *SQLHistorialEstadoRepository) tabla(tipo domain.TipoDocumento) (string, error) {
+	tabla, ok := tablasHistorial[tipo]
+	if !ok {
+		return "", fmt.Errorf("tipo de documento sin historial: %s", tipo)
+	}
+	return tabla, nil
+}
+
+// Create registra un cambio de estado de un documento
+func (r *SQLHistorialEstadoRepository) Create(tipo domain.TipoDocumento, historial *domain.HistorialEstado) (int, error) {
+	tabla, err := r.tabla(tipo)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO ` + tabla + ` (id_documento, estado_anterior, estado_nuevo,
+              usuario, comentario, fecha) VALUES (?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		historial.DocumentoID, historial.EstadoAnterior, historial.EstadoNuevo,
+		historial.Usuario, historial.Comentario, historial.Fecha,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// GetByDocumento obtiene el historial de estados de un documento en orden cronológico
+func (r *SQLHistorialEstadoRepository) GetByDocumento(tipo domain.TipoDocumento, documentoID int) ([]*domain.HistorialEstado, error) {
+	tabla, err := r.tabla(tipo)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id_historial, id_documento, estado_anterior, estado_nuevo, usuario,
+              comentario, fecha FROM ` + tabla + ` WHERE id_documento = ? ORDER BY fecha, id_historial`
+
+	rows, err := r.db.Query(query, documentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	historial := []*domain.HistorialEstado{}
+	for rows.Next() {
+		h := &domain.HistorialEstado{}
+		err := rows.Scan(
+			&h.ID, &h.DocumentoID, &h.EstadoAnterior, &h.EstadoNuevo,
+			&h.Usuario, &h.Comentario, &h.Fecha,
+		)
+		if err != nil {
+			return nil, err
+		}
+		historial = append(historial, h)
+	}
+
+	return historial, nil
+}
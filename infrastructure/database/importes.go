@@ -0,0 +1,105 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"database/sql"
+)
+
+// Un descuento se guarda en dos columnas: descuento_porcentaje y
+// descuento_monto. A lo más una de ellas tiene valor; ambas en NULL indican
+// que no hay descuento.
+
+// valoresDescuento retorna los valores de descuento_porcentaje y descuento_monto
+func valoresDescuento(descuento *domain.Descuento) (interface{}, interface{}) {
+	if descuento.Vacio() {
+		return nil, nil
+	}
+	if descuento.Porcentaje != 0 {
+		return descuento.Porcentaje, nil
+	}
+	return nil, descuento.Monto
+}
+
+// leerDescuento arma el descuento a partir de las columnas leídas
+func leerDescuento(porcentaje sql.Null[domain.Porcentaje], monto sql.Null[domain.Money]) *domain.Descuento {
+	if porcentaje.Valid {
+		return &domain.Descuento{Porcentaje: porcentaje.V}
+	}
+	if monto.Valid {
+		return &domain.Descuento{Monto: monto.V}
+	}
+	return nil
+}
+
+// columnasImportesLinea son las columnas de importes de Detalles_Pedido y
+// Detalles_Venta, en el orden de importesLineaScan y valoresImportesLinea
+const columnasImportesLinea = `descuento_porcentaje, descuento_monto, id_promocion, descuento, tasa_iva, impuesto, total`
+
+// asignacionesImportesLinea es la lista SET de columnasImportesLinea para un UPDATE
+const asignacionesImportesLinea = `descuento_porcentaje = ?, descuento_monto = ?, id_promocion = ?,
+              descuento = ?, tasa_iva = ?, impuesto = ?, total = ?`
+
+// importesLineaScan recibe las columnas de columnasImportesLinea de una fila
+type importesLineaScan struct {
+	importes    *domain.ImportesLinea
+	porcentaje  sql.Null[domain.Porcentaje]
+	monto       sql.Null[domain.Money]
+	promocionID sql.NullInt64
+}
+
+// destinos retorna los destinos de Scan en el orden de columnasImportesLinea
+func (s *importesLineaScan) destinos() []interface{} {
+	return []interface{}{
+		&s.porcentaje, &s.monto, &s.promocionID,
+		&s.importes.Descuento, &s.importes.TasaIVA, &s.importes.Impuesto, &s.importes.Total,
+	}
+}
+
+// asignar completa los importes con las columnas que admiten NULL
+func (s *importesLineaScan) asignar() {
+	s.importes.DescuentoLinea = leerDescuento(s.porcentaje, s.monto)
+	if s.promocionID.Valid {
+		id := int(s.promocionID.Int64)
+		s.importes.PromocionID = &id
+	}
+}
+
+// valoresImportesLinea retorna los valores a guardar en el orden de columnasImportesLinea
+func valoresImportesLinea(importes *domain.ImportesLinea) []interface{} {
+	porcentaje, monto := valoresDescuento(importes.DescuentoLinea)
+	return []interface{}{
+		porcentaje, monto, importes.PromocionID,
+		importes.Descuento, importes.TasaIVA, importes.Impuesto, importes.Total,
+	}
+}
+
+// columnasImportesDocumento son las columnas de importes de Pedido y Venta,
+// en el orden de importesDocumentoScan y valoresImportesDocumento
+const columnasImportesDocumento = `subtotal, descuento_porcentaje, descuento_monto, descuento, impuesto`
+
+// importesDocumentoScan recibe las columnas de columnasImportesDocumento de una fila
+type importesDocumentoScan struct {
+	importes   *domain.ImportesDocumento
+	porcentaje sql.Null[domain.Porcentaje]
+	monto      sql.Null[domain.Money]
+}
+
+// destinos retorna los destinos de Scan en el orden de columnasImportesDocumento
+func (s *importesDocumentoScan) destinos() []interface{} {
+	return []interface{}{
+		&s.importes.Subtotal, &s.porcentaje, &s.monto, &s.importes.Descuento, &s.importes.Impuesto,
+	}
+}
+
+// asignar completa los importes con las columnas que admiten NULL
+func (s *importesDocumentoScan) asignar() {
+	s.importes.DescuentoDocumento = leerDescuento(s.porcentaje, s.monto)
+}
+
+// valoresImportesDocumento retorna los valores a guardar en el orden de columnasImportesDocumento
+func valoresImportesDocumento(importes *domain.ImportesDocumento) []interface{} {
+	porcentaje, monto := valoresDescuento(importes.DescuentoDocumento)
+	return []interface{}{
+		importes.Subtotal, porcentaje, monto, importes.Descuento, importes.Impuesto,
+	}
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ordenListado describe cómo se puede ordenar un listado
+type ordenListado struct {
+	// campos asocia cada campo de la API con su columna
+	campos map[string]string
+	// predeterminado es el campo con el que se ordena si no se indica otro
+	predeterminado string
+	// descendente indica la dirección del orden predeterminado
+	descendente bool
+	// desempate es la columna que hace determinista el orden entre páginas
+	desempate string
+}
+
+// listado arma las consultas paginadas de un listado a partir de las
+// condiciones de sus filtros
+type listado struct {
+	condiciones []string
+	args        []interface{}
+}
+
+// donde agrega una condición al listado
+func (l *listado) donde(condicion string, args ...interface{}) {
+	l.condiciones = append(l.condiciones, condicion)
+	l.args = append(l.args, args...)
+}
+
+// rangoFechas agrega la condición de que una columna esté en [desde, hasta)
+func (l *listado) rangoFechas(columna string, desde, hasta *time.Time) {
+	if desde != nil {
+		l.donde(columna+` >= ?`, *desde)
+	}
+	if hasta != nil {
+		l.donde(columna+` < ?`, *hasta)
+	}
+}
+
+// rangoMontos agrega la condición de que una columna esté en [minimo, maximo]
+func (l *listado) rangoMontos(columna string, minimo, maximo *domain.Money) {
+	if minimo != nil {
+		l.donde(columna+` >= ?`, *minimo)
+	}
+	if maximo != nil {
+		l.donde(columna+` <= ?`, *maximo)
+	}
+}
+
+// enteros agrega la condición de que una columna tenga alguno de los valores
+// indicados. Sin valores no filtra.
+func (l *listado) enteros(columna string, valores []int) {
+	if len(valores) == 0 {
+		return
+	}
+	args := make([]interface{}, len(valores))
+	for i, valor := range valores {
+		args[i] = valor
+	}
+	l.donde(columna+` IN (`+marcadores(len(valores))+`)`, args...)
+}
+
+// where retorna la cláusula WHERE de las condiciones, o vacío si no hay
+func (l *listado) where() string {
+	if len(l.condiciones) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(l.condiciones, ` AND `)
+}
+
+// orderBy retorna la cláusula ORDER BY de las opciones. Retorna
+// domain.ErrConsultaInvalida si el campo no se puede usar para ordenar.
+func (o ordenListado) orderBy(opciones ports.QueryOptions) (string, error) {
+	campo := opciones.OrdenarPor
+	if campo == "" {
+		campo = o.predeterminado
+	}
+	columna, ok := o.campos[campo]
+	if !ok {
+		return "", fmt.Errorf("%w: no se puede ordenar por %q", domain.ErrConsultaInvalida, campo)
+	}
+
+	var direccion string
+	switch opciones.Direccion {
+	case ports.OrdenAscendente:
+		direccion = "ASC"
+	case ports.OrdenDescendente:
+		direccion = "DESC"
+	case "":
+		direccion = "ASC"
+		if opciones.OrdenarPor == "" && o.descendente {
+			direccion = "DESC"
+		}
+	default:
+		return "", fmt.Errorf("%w: dirección %q, use asc o desc", domain.ErrConsultaInvalida, opciones.Direccion)
+	}
+
+	orden := ` ORDER BY ` + columna + ` ` + direccion
+	if columna != o.desempate {
+		orden += `, ` + o.desempate + ` ` + direccion
+	}
+	return orden, nil
+}
+
+// paginar ejecuta el conteo total y la consulta de la página de un listado.
+// seleccion son las columnas del SELECT y desde la cláusula FROM con sus JOIN.
+func paginar[T any](db dbExecutor, l *listado, seleccion, desde string, orden ordenListado, opciones ports.QueryOptions,
+	consultar func(query string, args ...interface{}) ([]T, error)) ([]T, int, error) {
+	orderBy, err := orden.orderBy(opciones)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) `+desde+l.where(), l.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + seleccion + ` ` + desde + l.where() + orderBy
+	args := l.args
+	switch {
+	case opciones.Limite > 0:
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, opciones.Limite, opciones.Offset)
+	case opciones.Offset > 0:
+		// MySQL no admite OFFSET sin LIMIT
+		query += ` LIMIT 18446744073709551615 OFFSET ?`
+		args = append(args, opciones.Offset)
+	}
+
+	elementos, err := consultar(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return elementos, total, nil
+}
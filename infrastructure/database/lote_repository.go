@@ -0,0 +1,206 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"time"
+)
+
+// SQLLoteRepository implementa la interfaz LoteRepository usando MySQL
+type SQLLoteRepository struct {
+	db dbExecutor
+}
+
+// NewSQLLoteRepository crea un nuevo repositorio de lotes SQL
+func NewSQLLoteRepository(db *sql.DB) ports.LoteRepository {
+	return &SQLLoteRepository{
+		db: db,
+	}
+}
+
+// columnasLote son las columnas que se leen de Lote, en el orden de scanLote
+const columnasLote = `id_lote, id_producto, id_almacen, numero_lote, fecha_caducidad,
+              cantidad_inicial, existencia, id_orden_proveedor, fecha_recepcion`
+
+// ordenFEFO ordena los lotes por caducidad, primero el que caduca antes; los
+// lotes sin caducidad van al final
+const ordenFEFO = ` ORDER BY fecha_caducidad IS NULL, fecha_caducidad, id_lote`
+
+// scanner abstrae *sql.Row y *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLote lee un lote de una fila con las columnas de columnasLote
+func scanLote(fila scanner) (*domain.Lote, error) {
+	lote := &domain.Lote{}
+	var caducidad sql.NullTime
+	var ordenID sql.NullInt64
+
+	err := fila.Scan(
+		&lote.ID, &lote.ProductoID, &lote.AlmacenID, &lote.NumeroLote, &caducidad,
+		&lote.CantidadInicial, &lote.Existencia, &ordenID, &lote.FechaRecepcion,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if caducidad.Valid {
+		lote.FechaCaducidad = &caducidad.Time
+	}
+	if ordenID.Valid {
+		id := int(ordenID.Int64)
+		lote.OrdenProveedorID = &id
+	}
+	return lote, nil
+}
+
+// queryLotes ejecuta una consulta que retorna lotes
+func (r *SQLLoteRepository) queryLotes(query string, args ...interface{}) ([]*domain.Lote, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lotes := []*domain.Lote{}
+	for rows.Next() {
+		lote, err := scanLote(rows)
+		if err != nil {
+			return nil, err
+		}
+		lotes = append(lotes, lote)
+	}
+
+	return lotes, rows.Err()
+}
+
+// GetByID obtiene un lote por su ID
+func (r *SQLLoteRepository) GetByID(id int) (*domain.Lote, error) {
+	query := `SELECT ` + columnasLote + ` FROM Lote WHERE id_lote = ?`
+
+	return scanLote(r.db.QueryRow(query, id))
+}
+
+// GetByProducto obtiene los lotes con existencia de un producto en orden FEFO,
+// opcionalmente filtrados por almacén (cero para todos)
+func (r *SQLLoteRepository) GetByProducto(productoID int, almacenID int) ([]*domain.Lote, error) {
+	query := `SELECT ` + columnasLote + ` FROM Lote WHERE id_producto = ? AND existencia > 0`
+	args := []interface{}{productoID}
+
+	if almacenID != 0 {
+		query += ` AND id_almacen = ?`
+		args = append(args, almacenID)
+	}
+
+	return r.queryLotes(query+ordenFEFO, args...)
+}
+
+// GetDisponiblesForUpdate obtiene en orden FEFO los lotes con existencia de un
+// producto en un almacén, bloqueándolos hasta que termine la transacción
+func (r *SQLLoteRepository) GetDisponiblesForUpdate(productoID int, almacenID int) ([]*domain.Lote, error) {
+	query := `SELECT ` + columnasLote + ` FROM Lote
+              WHERE id_producto = ? AND id_almacen = ? AND existencia > 0` + ordenFEFO + ` FOR UPDATE`
+
+	return r.queryLotes(query, productoID, almacenID)
+}
+
+// GetPorCaducar obtiene los lotes con existencia que caducan a más tardar en
+// la fecha hasta, incluidos los ya caducados, opcionalmente filtrados por
+// almacén (cero para todos)
+func (r *SQLLoteRepository) GetPorCaducar(hasta time.Time, almacenID int) ([]*domain.Lote, error) {
+	query := `SELECT ` + columnasLote + ` FROM Lote
+              WHERE existencia > 0 AND fecha_caducidad IS NOT NULL AND fecha_caducidad <= ?`
+	args := []interface{}{hasta.Format("2006-01-02")}
+
+	if almacenID != 0 {
+		query += ` AND id_almacen = ?`
+		args = append(args, almacenID)
+	}
+
+	return r.queryLotes(query+ordenFEFO, args...)
+}
+
+// Create registra un nuevo lote
+func (r *SQLLoteRepository) Create(lote *domain.Lote) (int, error) {
+	query := `INSERT INTO Lote (id_producto, id_almacen, numero_lote, fecha_caducidad,
+              cantidad_inicial, existencia, id_orden_proveedor, fecha_recepcion)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	var caducidad interface{}
+	if lote.FechaCaducidad != nil {
+		caducidad = lote.FechaCaducidad.Format("2006-01-02")
+	}
+
+	result, err := r.db.Exec(query,
+		lote.ProductoID, lote.AlmacenID, lote.NumeroLote, caducidad,
+		lote.CantidadInicial, lote.Existencia, lote.OrdenProveedorID, lote.FechaRecepcion,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// AjustarExistencia suma cantidad (positiva o negativa) a la existencia de un lote
+func (r *SQLLoteRepository) AjustarExistencia(id int, cantidad int) error {
+	query := `UPDATE Lote SET existencia = existencia + ? WHERE id_lote = ?`
+
+	_, err := r.db.Exec(query, cantidad, id)
+
+	return err
+}
+
+// CreateMovimiento registra la parte de un movimiento del kardex que afectó a un lote
+func (r *SQLLoteRepository) CreateMovimiento(movimiento *domain.MovimientoLote) (int, error) {
+	query := `INSERT INTO Movimiento_Lote (id_lote, id_movimiento, cantidad) VALUES (?, ?, ?)`
+
+	result, err := r.db.Exec(query, movimiento.LoteID, movimiento.MovimientoID, movimiento.Cantidad)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// GetNetoPorDocumento obtiene, por lote, el efecto neto que han tenido sobre
+// un producto en un almacén los movimientos de un documento: negativo para
+// lo que el documento sacó del lote y positivo para lo que le metió
+func (r *SQLLoteRepository) GetNetoPorDocumento(tipo domain.TipoDocumento, documentoID int, productoID int, almacenID int) (map[int]int, error) {
+	query := `SELECT ml.id_lote, SUM(ml.cantidad) FROM Movimiento_Lote ml
+              JOIN Movimiento_Inventario m ON m.id_movimiento = ml.id_movimiento
+              WHERE m.tipo_documento = ? AND m.id_documento = ? AND m.id_producto = ? AND m.id_almacen = ?
+              GROUP BY ml.id_lote`
+
+	rows, err := r.db.Query(query, tipo, documentoID, productoID, almacenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	netos := make(map[int]int)
+	for rows.Next() {
+		var loteID, neto int
+		if err := rows.Scan(&loteID, &neto); err != nil {
+			return nil, err
+		}
+		if neto != 0 {
+			netos[loteID] = neto
+		}
+	}
+
+	return netos, rows.Err()
+}
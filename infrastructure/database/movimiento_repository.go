@@ -0,0 +1,102 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"time"
+)
+
+// SQLMovimientoInventarioRepository implementa la interfaz MovimientoInventarioRepository usando MySQL
+type SQLMovimientoInventarioRepository struct {
+	db dbExecutor
+}
+
+// NewSQLMovimientoInventarioRepository crea un nuevo repositorio de movimientos de inventario SQL
+func NewSQLMovimientoInventarioRepository(db *sql.DB) ports.MovimientoInventarioRepository {
+	return &SQLMovimientoInventarioRepository{
+		db: db,
+	}
+}
+
+// Create registra un nuevo movimiento de inventario
+func (r *SQLMovimientoInventarioRepository) Create(movimiento *domain.MovimientoInventario) (int, error) {
+	query := `INSERT INTO Movimiento_Inventario (id_producto, id_almacen, cantidad, saldo_resultante,
+              saldo_almacen, motivo, tipo_documento, id_documento, fecha)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	var tipoDocumento sql.NullString
+	if movimiento.TipoDocumento != "" {
+		tipoDocumento = sql.NullString{String: string(movimiento.TipoDocumento), Valid: true}
+	}
+
+	result, err := r.db.Exec(query,
+		movimiento.ProductoID, movimiento.AlmacenID, movimiento.Cantidad, movimiento.SaldoResultante,
+		movimiento.SaldoAlmacen, movimiento.Motivo, tipoDocumento, movimiento.DocumentoID, movimiento.Fecha,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// GetByProductoID obtiene el kardex de un producto, opcionalmente filtrado por
+// almacén (cero para todos) y por rango de fechas [desde, hasta)
+func (r *SQLMovimientoInventarioRepository) GetByProductoID(productoID int, almacenID int, desde, hasta *time.Time) ([]*domain.MovimientoInventario, error) {
+	query := `SELECT id_movimiento, id_producto, id_almacen, cantidad, saldo_resultante,
+              saldo_almacen, motivo, tipo_documento, id_documento, fecha FROM Movimiento_Inventario
+              WHERE id_producto = ?`
+	args := []interface{}{productoID}
+
+	if almacenID != 0 {
+		query += ` AND id_almacen = ?`
+		args = append(args, almacenID)
+	}
+
+	if desde != nil {
+		query += ` AND fecha >= ?`
+		args = append(args, *desde)
+	}
+	if hasta != nil {
+		query += ` AND fecha < ?`
+		args = append(args, *hasta)
+	}
+	query += ` ORDER BY fecha, id_movimiento`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movimientos := []*domain.MovimientoInventario{}
+	for rows.Next() {
+		movimiento := &domain.MovimientoInventario{}
+		var tipoDocumento sql.NullString
+		var documentoID sql.NullInt64
+		err := rows.Scan(
+			&movimiento.ID, &movimiento.ProductoID, &movimiento.AlmacenID, &movimiento.Cantidad,
+			&movimiento.SaldoResultante, &movimiento.SaldoAlmacen, &movimiento.Motivo, &tipoDocumento,
+			&documentoID, &movimiento.Fecha,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		movimiento.TipoDocumento = domain.TipoDocumento(tipoDocumento.String)
+		if documentoID.Valid {
+			id := int(documentoID.Int64)
+			movimiento.DocumentoID = &id
+		}
+		movimientos = append(movimientos, movimiento)
+	}
+
+	return movimientos, nil
+}
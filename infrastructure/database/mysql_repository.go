@@ -4,12 +4,13 @@ import (
 	"ActividadDesempenioAPIz/core/domain"
 	"ActividadDesempenioAPIz/core/ports"
 	"database/sql"
+	"strings"
 	"time"
 )
 
 // SQLProductoRepository implementa la interfaz ProductoRepository usando MySQL
 type SQLProductoRepository struct {
-	db *sql.DB
+	db dbExecutor
 }
 
 // NewSQLProductoRepository crea un nuevo repositorio de productos SQL
@@ -19,31 +20,62 @@ func NewSQLProductoRepository(db *sql.DB) ports.ProductoRepository {
 	}
 }
 
-// GetByID obtiene un producto por su ID
-func (r *SQLProductoRepository) GetByID(id int) (*domain.Producto, error) {
-	query := `SELECT id_producto, nombre, descripcion, precio, existencia, 
-              id_proveedor, fecha_creacion FROM Producto WHERE id_producto = ?`
+// columnasProducto son las columnas que se leen de Producto, en el orden de scanProducto
+const columnasProducto = `p.id_producto, p.sku, p.codigo_barras, p.nombre, p.descripcion, p.precio,
+              p.costo_promedio, p.existencia, p.stock_minimo, p.punto_reorden, p.cantidad_reorden,
+              p.id_proveedor, p.id_categoria, p.tasa_iva, p.fecha_creacion, p.deleted_at`
 
+// scanProducto lee un producto de una fila con las columnas de columnasProducto
+func scanProducto(fila scanner) (*domain.Producto, error) {
 	producto := &domain.Producto{}
-	err := r.db.QueryRow(query, id).Scan(
-		&producto.ID, &producto.Nombre, &producto.Descripcion,
-		&producto.Precio, &producto.Existencia, &producto.ProveedorID,
-		&producto.FechaCreacion,
+	var sku, codigoBarras sql.NullString
+	var categoriaID sql.NullInt64
+	var tasaIVA sql.Null[domain.Porcentaje]
+	var deletedAt sql.NullTime
+
+	err := fila.Scan(
+		&producto.ID, &sku, &codigoBarras, &producto.Nombre, &producto.Descripcion,
+		&producto.Precio, &producto.CostoPromedio, &producto.Existencia, &producto.StockMinimo,
+		&producto.PuntoReorden, &producto.CantidadReorden, &producto.ProveedorID,
+		&categoriaID, &tasaIVA, &producto.FechaCreacion, &deletedAt,
 	)
-
 	if err != nil {
 		return nil, err
 	}
 
+	producto.SKU = sku.String
+	producto.CodigoBarras = codigoBarras.String
+	if categoriaID.Valid {
+		id := int(categoriaID.Int64)
+		producto.CategoriaID = &id
+	}
+	if tasaIVA.Valid {
+		producto.TasaIVA = &tasaIVA.V
+	}
+	if deletedAt.Valid {
+		producto.DeletedAt = &deletedAt.Time
+	}
 	return producto, nil
 }
 
-// GetAll obtiene todos los productos
-func (r *SQLProductoRepository) GetAll() ([]*domain.Producto, error) {
-	query := `SELECT id_producto, nombre, descripcion, precio, existencia, 
-              id_proveedor, fecha_creacion FROM Producto`
+// getProducto obtiene el producto que cumple la condición, con sus atributos
+func (r *SQLProductoRepository) getProducto(condicion string, args ...interface{}) (*domain.Producto, error) {
+	query := `SELECT ` + columnasProducto + ` FROM Producto p WHERE ` + condicion
+
+	producto, err := scanProducto(r.db.QueryRow(query, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.cargarAtributos([]*domain.Producto{producto}); err != nil {
+		return nil, err
+	}
+	return producto, nil
+}
 
-	rows, err := r.db.Query(query)
+// queryProductos ejecuta una consulta que retorna productos y carga sus atributos
+func (r *SQLProductoRepository) queryProductos(query string, args ...interface{}) ([]*domain.Producto, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -51,33 +83,157 @@ func (r *SQLProductoRepository) GetAll() ([]*domain.Producto, error) {
 
 	productos := []*domain.Producto{}
 	for rows.Next() {
-		producto := &domain.Producto{}
-		err := rows.Scan(
-			&producto.ID, &producto.Nombre, &producto.Descripcion,
-			&producto.Precio, &producto.Existencia, &producto.ProveedorID,
-			&producto.FechaCreacion,
-		)
+		producto, err := scanProducto(rows)
 		if err != nil {
 			return nil, err
 		}
 		productos = append(productos, producto)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	if err := r.cargarAtributos(productos); err != nil {
+		return nil, err
+	}
 	return productos, nil
 }
 
-// Create crea un nuevo producto
+// cargarAtributos lee en una sola consulta los atributos de los productos
+func (r *SQLProductoRepository) cargarAtributos(productos []*domain.Producto) error {
+	if len(productos) == 0 {
+		return nil
+	}
+
+	porID := make(map[int]*domain.Producto, len(productos))
+	args := make([]interface{}, 0, len(productos))
+	for _, producto := range productos {
+		porID[producto.ID] = producto
+		args = append(args, producto.ID)
+	}
+
+	query := `SELECT id_producto, clave, valor FROM Producto_Atributo
+              WHERE id_producto IN (` + marcadores(len(args)) + `) ORDER BY id_producto, clave`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productoID int
+		var clave, valor string
+		if err := rows.Scan(&productoID, &clave, &valor); err != nil {
+			return err
+		}
+		producto := porID[productoID]
+		if producto.Atributos == nil {
+			producto.Atributos = make(map[string]string)
+		}
+		producto.Atributos[clave] = valor
+	}
+
+	return rows.Err()
+}
+
+// marcadores retorna n marcadores de posición separados por comas para IN (...)
+func marcadores(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// filtroCategorias agrega a una consulta la condición de pertenecer a alguna
+// de las categorías indicadas. Sin categorías no filtra.
+func filtroCategorias(query string, args []interface{}, categoriaIDs []int) (string, []interface{}) {
+	if len(categoriaIDs) == 0 {
+		return query, args
+	}
+
+	query += ` AND p.id_categoria IN (` + marcadores(len(categoriaIDs)) + `)`
+	for _, id := range categoriaIDs {
+		args = append(args, id)
+	}
+	return query, args
+}
+
+// GetByID obtiene un producto por su ID, aunque esté eliminado
+func (r *SQLProductoRepository) GetByID(id int) (*domain.Producto, error) {
+	return r.getProducto(`p.id_producto = ?`, id)
+}
+
+// GetBySKU obtiene un producto por su SKU
+func (r *SQLProductoRepository) GetBySKU(sku string) (*domain.Producto, error) {
+	return r.getProducto(`p.sku = ?`, sku)
+}
+
+// GetByCodigoBarras obtiene un producto por su código de barras
+func (r *SQLProductoRepository) GetByCodigoBarras(codigo string) (*domain.Producto, error) {
+	return r.getProducto(`p.codigo_barras = ?`, codigo)
+}
+
+// ordenProductos son los campos por los que se pueden ordenar los productos
+var ordenProductos = ordenListado{
+	campos: map[string]string{
+		"id":             "p.id_producto",
+		"sku":            "p.sku",
+		"nombre":         "p.nombre",
+		"precio":         "p.precio",
+		"existencia":     "p.existencia",
+		"fecha_creacion": "p.fecha_creacion",
+	},
+	predeterminado: "id",
+	desempate:      "p.id_producto",
+}
+
+// GetAll obtiene una página de los productos. Sin el filtro Eliminados sólo
+// considera los que no están eliminados; con él, sólo los eliminados. Filtra
+// por categorías, proveedor, rango de precio, existencia menor a un valor y
+// fecha de creación.
+func (r *SQLProductoRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Producto, int, error) {
+	filtros := opciones.Filtros
+	l := &listado{}
+	if filtros.Eliminados {
+		l.donde(`p.deleted_at IS NOT NULL`)
+	} else {
+		l.donde(`p.deleted_at IS NULL`)
+	}
+	l.enteros(`p.id_categoria`, filtros.CategoriaIDs)
+	if filtros.ProveedorID != 0 {
+		l.donde(`p.id_proveedor = ?`, filtros.ProveedorID)
+	}
+	l.rangoMontos(`p.precio`, filtros.PrecioMin, filtros.PrecioMax)
+	if filtros.StockMenorA != nil {
+		l.donde(`p.existencia < ?`, *filtros.StockMenorA)
+	}
+	l.rangoFechas(`p.fecha_creacion`, filtros.Desde, filtros.Hasta)
+
+	return paginar(r.db, l, columnasProducto, `FROM Producto p`, ordenProductos, opciones, r.queryProductos)
+}
+
+// GetByCategorias obtiene los productos no eliminados de las categorías
+// indicadas; sin categorías obtiene todos
+func (r *SQLProductoRepository) GetByCategorias(categoriaIDs []int) ([]*domain.Producto, error) {
+	query, args := filtroCategorias(`SELECT `+columnasProducto+` FROM Producto p WHERE p.deleted_at IS NULL`, nil, categoriaIDs)
+
+	return r.queryProductos(query+` ORDER BY p.id_producto`, args...)
+}
+
+// Create crea un nuevo producto sin existencia. La existencia inicial se
+// registra después con IncrementStock para que quede asignada a un almacén.
+// Retorna domain.ErrDuplicado si el SKU o el código de barras ya existen.
 func (r *SQLProductoRepository) Create(producto *domain.Producto) (int, error) {
-	query := `INSERT INTO Producto (nombre, descripcion, precio, existencia, 
-              id_proveedor, fecha_creacion) VALUES (?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO Producto (sku, codigo_barras, nombre, descripcion, precio, costo_promedio, existencia,
+              stock_minimo, punto_reorden, cantidad_reorden, id_proveedor, id_categoria, tasa_iva, fecha_creacion)
+              VALUES (NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, 0, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := r.db.Exec(query,
-		producto.Nombre, producto.Descripcion, producto.Precio,
-		producto.Existencia, producto.ProveedorID, time.Now().Format("2006-01-02 15:04:05"),
+		producto.SKU, producto.CodigoBarras, producto.Nombre, producto.Descripcion, producto.Precio,
+		producto.CostoPromedio, producto.StockMinimo, producto.PuntoReorden, producto.CantidadReorden,
+		producto.ProveedorID, producto.CategoriaID, producto.TasaIVA, time.Now().Format("2006-01-02 15:04:05"),
 	)
 
 	if err != nil {
-		return 0, err
+		return 0, errorDuplicado(err)
 	}
 
 	id, err := result.LastInsertId()
@@ -88,40 +244,347 @@ func (r *SQLProductoRepository) Create(producto *domain.Producto) (int, error) {
 	return int(id), nil
 }
 
-// Update actualiza un producto existente
+// Update actualiza un producto existente. La existencia no se modifica aquí:
+// sólo cambia mediante movimientos de inventario. Retorna domain.ErrDuplicado
+// si el SKU o el código de barras pertenecen a otro producto.
 func (r *SQLProductoRepository) Update(producto *domain.Producto) error {
-	query := `UPDATE Producto SET nombre = ?, descripcion = ?, precio = ?, 
-              existencia = ?, id_proveedor = ? WHERE id_producto = ?`
+	query := `UPDATE Producto SET sku = NULLIF(?, ''), codigo_barras = NULLIF(?, ''), nombre = ?,
+              descripcion = ?, precio = ?, stock_minimo = ?, punto_reorden = ?, cantidad_reorden = ?,
+              id_proveedor = ?, id_categoria = ?, tasa_iva = ? WHERE id_producto = ?`
 
 	_, err := r.db.Exec(query,
-		producto.Nombre, producto.Descripcion, producto.Precio,
-		producto.Existencia, producto.ProveedorID, producto.ID,
+		producto.SKU, producto.CodigoBarras, producto.Nombre,
+		producto.Descripcion, producto.Precio, producto.StockMinimo, producto.PuntoReorden,
+		producto.CantidadReorden, producto.ProveedorID, producto.CategoriaID, producto.TasaIVA, producto.ID,
 	)
 
+	return errorDuplicado(err)
+}
+
+// UpdateCampos actualiza sólo las columnas de los campos indicados del
+// producto. Los atributos se actualizan con SetAtributos.
+func (r *SQLProductoRepository) UpdateCampos(producto *domain.Producto, campos []string) error {
+	asignaciones := map[string]asignacion{
+		"sku":              {`sku = NULLIF(?, '')`, []interface{}{producto.SKU}},
+		"codigo_barras":    {`codigo_barras = NULLIF(?, '')`, []interface{}{producto.CodigoBarras}},
+		"nombre":           {`nombre = ?`, []interface{}{producto.Nombre}},
+		"descripcion":      {`descripcion = ?`, []interface{}{producto.Descripcion}},
+		"precio":           {`precio = ?`, []interface{}{producto.Precio}},
+		"stock_minimo":     {`stock_minimo = ?`, []interface{}{producto.StockMinimo}},
+		"punto_reorden":    {`punto_reorden = ?`, []interface{}{producto.PuntoReorden}},
+		"cantidad_reorden": {`cantidad_reorden = ?`, []interface{}{producto.CantidadReorden}},
+		"id_proveedor":     {`id_proveedor = ?`, []interface{}{producto.ProveedorID}},
+		"id_categoria":     {`id_categoria = ?`, []interface{}{producto.CategoriaID}},
+		"tasa_iva":         {`tasa_iva = ?`, []interface{}{producto.TasaIVA}},
+	}
+
+	return errorDuplicado(actualizarCampos(r.db, `Producto`, `id_producto`, producto.ID, asignaciones, campos))
+}
+
+// SetCostoPromedio fija el costo promedio ponderado de un producto
+func (r *SQLProductoRepository) SetCostoPromedio(id int, costo domain.Money) error {
+	query := `UPDATE Producto SET costo_promedio = ? WHERE id_producto = ?`
+
+	_, err := r.db.Exec(query, costo, id)
+
 	return err
 }
 
-// UpdateStock actualiza el stock de un producto
-func (r *SQLProductoRepository) UpdateStock(id int, cantidad int) error {
-	query := `UPDATE Producto SET existencia = ? WHERE id_producto = ?`
+// SetAtributos reemplaza todos los atributos de un producto
+func (r *SQLProductoRepository) SetAtributos(id int, atributos map[string]string) error {
+	_, err := r.db.Exec(`DELETE FROM Producto_Atributo WHERE id_producto = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	for clave, valor := range atributos {
+		_, err := r.db.Exec(`INSERT INTO Producto_Atributo (id_producto, clave, valor) VALUES (?, ?, ?)`,
+			id, clave, valor)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetStockBajo obtiene los productos no eliminados cuya existencia está en o por debajo de
+// su stock mínimo, opcionalmente sólo de las categorías indicadas. Con
+// almacenID igual a cero se evalúa la existencia total; en otro caso la del
+// almacén indicado, que se reporta como existencia.
+func (r *SQLProductoRepository) GetStockBajo(almacenID int, categoriaIDs []int) ([]*domain.Producto, error) {
+	query := `SELECT ` + columnasProducto + ` FROM Producto p
+              WHERE p.deleted_at IS NULL AND p.existencia <= p.stock_minimo`
+	args := []interface{}{}
+	orden := ` ORDER BY p.existencia - p.stock_minimo, p.id_producto`
+
+	if almacenID != 0 {
+		columnas := strings.Replace(columnasProducto, "p.existencia", "COALESCE(e.existencia, 0)", 1)
+		query = `SELECT ` + columnas + ` FROM Producto p
+                  LEFT JOIN Existencia_Almacen e ON e.id_producto = p.id_producto AND e.id_almacen = ?
+                  WHERE p.deleted_at IS NULL AND COALESCE(e.existencia, 0) <= p.stock_minimo`
+		args = append(args, almacenID)
+		orden = ` ORDER BY COALESCE(e.existencia, 0) - p.stock_minimo, p.id_producto`
+	}
+
+	query, args = filtroCategorias(query, args, categoriaIDs)
+
+	return r.queryProductos(query+orden, args...)
+}
+
+// GetStockForUpdate obtiene la existencia de un producto en un almacén
+// bloqueando su fila hasta que termine la transacción en curso. Un producto
+// que nunca ha estado en el almacén tiene existencia cero.
+func (r *SQLProductoRepository) GetStockForUpdate(id int, almacenID int) (int, error) {
+	if err := r.asegurarExistencia(id, almacenID); err != nil {
+		return 0, err
+	}
+
+	query := `SELECT existencia FROM Existencia_Almacen
+              WHERE id_producto = ? AND id_almacen = ? FOR UPDATE`
+
+	var existencia int
+	err := r.db.QueryRow(query, id, almacenID).Scan(&existencia)
+
+	return existencia, err
+}
+
+// asegurarExistencia crea en cero la existencia de un producto en un almacén
+// si aún no existe, de modo que siempre haya una fila que bloquear. Retorna
+// sql.ErrNoRows si el producto no existe.
+func (r *SQLProductoRepository) asegurarExistencia(id int, almacenID int) error {
+	var existe int
+	err := r.db.QueryRow(`SELECT 1 FROM Producto WHERE id_producto = ?`, id).Scan(&existe)
+	if err != nil {
+		return err
+	}
 
-	_, err := r.db.Exec(query, cantidad, id)
+	_, err = r.db.Exec(`INSERT INTO Existencia_Almacen (id_producto, id_almacen, existencia)
+              VALUES (?, ?, 0) ON DUPLICATE KEY UPDATE id_producto = id_producto`, id, almacenID)
 
 	return err
 }
 
-// Delete elimina un producto
-func (r *SQLProductoRepository) Delete(id int) error {
-	query := `DELETE FROM Producto WHERE id_producto = ?`
+// IncrementStock suma cantidad a la existencia de un producto en un almacén y
+// retorna los nuevos saldos del almacén y total. LAST_INSERT_ID(expr) permite
+// leer cada saldo resultante de forma atómica en la misma sentencia.
+func (r *SQLProductoRepository) IncrementStock(id int, almacenID int, cantidad int) (int, int, error) {
+	if err := r.asegurarExistencia(id, almacenID); err != nil {
+		return 0, 0, err
+	}
+
+	query := `UPDATE Existencia_Almacen SET existencia = LAST_INSERT_ID(existencia + ?) 
+              WHERE id_producto = ? AND id_almacen = ?`
+
+	result, err := r.db.Exec(query, cantidad, id, almacenID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	saldoAlmacen, err := stockResultante(result)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	saldoTotal, err := r.ajustarTotal(id, cantidad)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return saldoAlmacen, saldoTotal, nil
+}
+
+// IncrementDanado suma cantidad a la existencia dañada de un producto en un
+// almacén. La existencia dañada no se puede vender y no cuenta en la
+// existencia del producto.
+func (r *SQLProductoRepository) IncrementDanado(id int, almacenID int, cantidad int) error {
+	if err := r.asegurarExistencia(id, almacenID); err != nil {
+		return err
+	}
+
+	query := `UPDATE Existencia_Almacen SET existencia_danada = existencia_danada + ?
+              WHERE id_producto = ? AND id_almacen = ?`
+
+	_, err := r.db.Exec(query, cantidad, id, almacenID)
+
+	return err
+}
+
+// DecrementStock resta cantidad a la existencia de un producto en un almacén
+// sólo si el almacén tiene existencia suficiente y retorna los nuevos saldos
+// del almacén y total
+func (r *SQLProductoRepository) DecrementStock(id int, almacenID int, cantidad int) (int, int, error) {
+	query := `UPDATE Existencia_Almacen SET existencia = LAST_INSERT_ID(existencia - ?) 
+              WHERE id_producto = ? AND id_almacen = ? AND existencia >= ?`
+
+	result, err := r.db.Exec(query, cantidad, id, almacenID, cantidad)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	filas, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if filas == 0 {
+		// O el producto no existe o el almacén no tiene existencia suficiente
+		var existe int
+		err := r.db.QueryRow(`SELECT 1 FROM Producto WHERE id_producto = ?`, id).Scan(&existe)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		var existencia int
+		err = r.db.QueryRow(`SELECT COALESCE(MAX(existencia), 0) FROM Existencia_Almacen
+              WHERE id_producto = ? AND id_almacen = ?`, id, almacenID).Scan(&existencia)
+		if err != nil {
+			return 0, 0, err
+		}
+		return 0, 0, &domain.StockInsuficienteError{Faltantes: []domain.FaltanteStock{{
+			ProductoID: id,
+			AlmacenID:  almacenID,
+			Solicitado: cantidad,
+			Disponible: existencia,
+		}}}
+	}
+
+	saldoAlmacen, err := stockResultante(result)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	saldoTotal, err := r.ajustarTotal(id, -cantidad)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return saldoAlmacen, saldoTotal, nil
+}
+
+// ajustarTotal aplica cantidad a la existencia total del producto, que se
+// mantiene como la suma de sus existencias por almacén, y retorna el nuevo saldo
+func (r *SQLProductoRepository) ajustarTotal(id int, cantidad int) (int, error) {
+	query := `UPDATE Producto SET existencia = LAST_INSERT_ID(existencia + ?) 
+              WHERE id_producto = ?`
+
+	result, err := r.db.Exec(query, cantidad, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockResultante(result)
+}
+
+// stockResultante lee el saldo fijado con LAST_INSERT_ID(expr)
+func stockResultante(result sql.Result) (int, error) {
+	filas, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if filas == 0 {
+		return 0, sql.ErrNoRows
+	}
+
+	saldo, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(saldo), nil
+}
+
+// SoftDelete marca un producto como eliminado. Los documentos que ya lo
+// incluyen no cambian.
+func (r *SQLProductoRepository) SoftDelete(id int) error {
+	query := `UPDATE Producto SET deleted_at = ? WHERE id_producto = ? AND deleted_at IS NULL`
+
+	_, err := r.db.Exec(query, time.Now(), id)
+
+	return err
+}
+
+// Restore quita la marca de eliminado de un producto
+func (r *SQLProductoRepository) Restore(id int) error {
+	query := `UPDATE Producto SET deleted_at = NULL WHERE id_producto = ?`
 
 	_, err := r.db.Exec(query, id)
 
 	return err
 }
 
+// dependenciasProducto son las consultas de los documentos que hacen
+// referencia a un producto, en el orden en que se reportan
+var dependenciasProducto = []struct {
+	tipo  string
+	query string
+}{
+	{"pedido", `SELECT DISTINCT id_pedido FROM Detalles_Pedido WHERE id_producto = ? ORDER BY id_pedido`},
+	{"venta", `SELECT DISTINCT id_venta FROM Detalles_Venta WHERE id_producto = ? ORDER BY id_venta`},
+	{"orden_proveedor", `SELECT DISTINCT id_orden_proveedor FROM Detalles_Orden WHERE id_producto = ? ORDER BY id_orden_proveedor`},
+	{"transferencia", `SELECT DISTINCT id_transferencia FROM Detalles_Transferencia WHERE id_producto = ? ORDER BY id_transferencia`},
+	{"devolucion", `SELECT DISTINCT id_devolucion FROM Detalles_Devolucion WHERE id_producto = ? ORDER BY id_devolucion`},
+	{"lote", `SELECT id_lote FROM Lote WHERE id_producto = ? ORDER BY id_lote`},
+	{"movimiento_inventario", `SELECT id_movimiento FROM Movimiento_Inventario WHERE id_producto = ? ORDER BY id_movimiento`},
+	{"promocion", `SELECT id_promocion FROM Promocion WHERE id_producto = ? ORDER BY id_promocion`},
+}
+
+// GetDependencias obtiene los documentos que hacen referencia a un producto
+// e impiden eliminarlo definitivamente. Los atributos, existencias y entradas
+// de catálogo no cuentan: se eliminan junto con el producto.
+func (r *SQLProductoRepository) GetDependencias(id int) ([]domain.Dependencia, error) {
+	dependencias := []domain.Dependencia{}
+	for _, consulta := range dependenciasProducto {
+		ids, err := consultarIDs(r.db, consulta.query, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			dependencias = append(dependencias, domain.Dependencia{Tipo: consulta.tipo, IDs: ids})
+		}
+	}
+	return dependencias, nil
+}
+
+// Delete elimina definitivamente un producto con sus existencias, que deben
+// estar en cero. Retorna domain.ErrTieneDependencias si algún documento lo
+// sigue referenciando.
+func (r *SQLProductoRepository) Delete(id int) error {
+	_, err := r.db.Exec(`DELETE FROM Existencia_Almacen WHERE id_producto = ?`, id)
+	if err != nil {
+		return errorReferenciado(err)
+	}
+
+	query := `DELETE FROM Producto WHERE id_producto = ?`
+
+	_, err = r.db.Exec(query, id)
+
+	return errorReferenciado(err)
+}
+
+// consultarIDs ejecuta una consulta que retorna una columna de IDs
+func consultarIDs(db dbExecutor, query string, args ...interface{}) ([]int, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 // SQLProveedorRepository implementa la interfaz ProveedorRepository usando MySQL
 type SQLProveedorRepository struct {
-	db *sql.DB
+	db dbExecutor
 }
 
 // NewSQLProveedorRepository crea un nuevo repositorio de proveedores SQL
@@ -131,30 +594,31 @@ func NewSQLProveedorRepository(db *sql.DB) ports.ProveedorRepository {
 	}
 }
 
-// GetByID obtiene un proveedor por su ID
-func (r *SQLProveedorRepository) GetByID(id int) (*domain.Proveedor, error) {
-	query := `SELECT id_proveedor, nombre, direccion, telefono, email, fecha_registro 
-              FROM Proveedor WHERE id_proveedor = ?`
+// columnasProveedor son las columnas que se leen de Proveedor, en el orden de scanProveedor
+const columnasProveedor = `id_proveedor, nombre, direccion, telefono, email, fecha_registro, deleted_at`
 
+// scanProveedor lee un proveedor de una fila con las columnas de columnasProveedor
+func scanProveedor(fila scanner) (*domain.Proveedor, error) {
 	proveedor := &domain.Proveedor{}
-	err := r.db.QueryRow(query, id).Scan(
+	var deletedAt sql.NullTime
+
+	err := fila.Scan(
 		&proveedor.ID, &proveedor.Nombre, &proveedor.Direccion,
-		&proveedor.Telefono, &proveedor.Email, &proveedor.FechaRegistro,
+		&proveedor.Telefono, &proveedor.Email, &proveedor.FechaRegistro, &deletedAt,
 	)
-
 	if err != nil {
 		return nil, err
 	}
 
+	if deletedAt.Valid {
+		proveedor.DeletedAt = &deletedAt.Time
+	}
 	return proveedor, nil
 }
 
-// GetAll obtiene todos los proveedores
-func (r *SQLProveedorRepository) GetAll() ([]*domain.Proveedor, error) {
-	query := `SELECT id_proveedor, nombre, direccion, telefono, email, fecha_registro 
-              FROM Proveedor`
-
-	rows, err := r.db.Query(query)
+// queryProveedores ejecuta una consulta que retorna proveedores
+func (r *SQLProveedorRepository) queryProveedores(query string, args ...interface{}) ([]*domain.Proveedor, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -162,18 +626,55 @@ func (r *SQLProveedorRepository) GetAll() ([]*domain.Proveedor, error) {
 
 	proveedores := []*domain.Proveedor{}
 	for rows.Next() {
-		proveedor := &domain.Proveedor{}
-		err := rows.Scan(
-			&proveedor.ID, &proveedor.Nombre, &proveedor.Direccion,
-			&proveedor.Telefono, &proveedor.Email, &proveedor.FechaRegistro,
-		)
+		proveedor, err := scanProveedor(rows)
 		if err != nil {
 			return nil, err
 		}
 		proveedores = append(proveedores, proveedor)
 	}
 
-	return proveedores, nil
+	return proveedores, rows.Err()
+}
+
+// GetByID obtiene un proveedor por su ID, aunque esté eliminado
+func (r *SQLProveedorRepository) GetByID(id int) (*domain.Proveedor, error) {
+	query := `SELECT ` + columnasProveedor + ` FROM Proveedor WHERE id_proveedor = ?`
+
+	return scanProveedor(r.db.QueryRow(query, id))
+}
+
+// GetByIDForUpdate obtiene un proveedor bloqueando su fila hasta que termine
+// la transacción en curso, para serializar operaciones sobre sus órdenes
+func (r *SQLProveedorRepository) GetByIDForUpdate(id int) (*domain.Proveedor, error) {
+	query := `SELECT ` + columnasProveedor + ` FROM Proveedor WHERE id_proveedor = ? FOR UPDATE`
+
+	return scanProveedor(r.db.QueryRow(query, id))
+}
+
+// ordenProveedores son los campos por los que se pueden ordenar los proveedores
+var ordenProveedores = ordenListado{
+	campos: map[string]string{
+		"id":             "id_proveedor",
+		"nombre":         "nombre",
+		"fecha_registro": "fecha_registro",
+	},
+	predeterminado: "id",
+	desempate:      "id_proveedor",
+}
+
+// GetAll obtiene una página de los proveedores. Sin el filtro Eliminados sólo
+// considera los que no están eliminados; con él, sólo los eliminados. Filtra
+// por fecha de registro.
+func (r *SQLProveedorRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Proveedor, int, error) {
+	l := &listado{}
+	if opciones.Filtros.Eliminados {
+		l.donde(`deleted_at IS NOT NULL`)
+	} else {
+		l.donde(`deleted_at IS NULL`)
+	}
+	l.rangoFechas(`fecha_registro`, opciones.Filtros.Desde, opciones.Filtros.Hasta)
+
+	return paginar(r.db, l, columnasProveedor, `FROM Proveedor`, ordenProveedores, opciones, r.queryProveedores)
 }
 
 // Create crea un nuevo proveedor
@@ -211,18 +712,79 @@ func (r *SQLProveedorRepository) Update(proveedor *domain.Proveedor) error {
 	return err
 }
 
-// Delete elimina un proveedor
-func (r *SQLProveedorRepository) Delete(id int) error {
-	query := `DELETE FROM Proveedor WHERE id_proveedor = ?`
+// UpdateCampos actualiza sólo las columnas de los campos indicados del proveedor
+func (r *SQLProveedorRepository) UpdateCampos(proveedor *domain.Proveedor, campos []string) error {
+	asignaciones := map[string]asignacion{
+		"nombre":    {`nombre = ?`, []interface{}{proveedor.Nombre}},
+		"direccion": {`direccion = ?`, []interface{}{proveedor.Direccion}},
+		"telefono":  {`telefono = ?`, []interface{}{proveedor.Telefono}},
+		"email":     {`email = ?`, []interface{}{proveedor.Email}},
+	}
+
+	return actualizarCampos(r.db, `Proveedor`, `id_proveedor`, proveedor.ID, asignaciones, campos)
+}
+
+// SoftDelete marca un proveedor como eliminado. Sus órdenes no cambian.
+func (r *SQLProveedorRepository) SoftDelete(id int) error {
+	query := `UPDATE Proveedor SET deleted_at = ? WHERE id_proveedor = ? AND deleted_at IS NULL`
+
+	_, err := r.db.Exec(query, time.Now(), id)
+
+	return err
+}
+
+// Restore quita la marca de eliminado de un proveedor
+func (r *SQLProveedorRepository) Restore(id int) error {
+	query := `UPDATE Proveedor SET deleted_at = NULL WHERE id_proveedor = ?`
 
 	_, err := r.db.Exec(query, id)
 
 	return err
 }
 
+// GetDependencias obtiene los productos y órdenes que hacen referencia a un
+// proveedor e impiden eliminarlo definitivamente. Su catálogo no cuenta: se
+// elimina junto con el proveedor.
+func (r *SQLProveedorRepository) GetDependencias(id int) ([]domain.Dependencia, error) {
+	consultas := []struct {
+		tipo  string
+		query string
+	}{
+		{"producto", `SELECT id_producto FROM Producto WHERE id_proveedor = ? ORDER BY id_producto`},
+		{"orden_proveedor", `SELECT id_orden_proveedor FROM Orden_Proveedor WHERE id_proveedor = ? ORDER BY id_orden_proveedor`},
+	}
+
+	dependencias := []domain.Dependencia{}
+	for _, consulta := range consultas {
+		ids, err := consultarIDs(r.db, consulta.query, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			dependencias = append(dependencias, domain.Dependencia{Tipo: consulta.tipo, IDs: ids})
+		}
+	}
+	return dependencias, nil
+}
+
+// Delete elimina definitivamente un proveedor con su catálogo. Retorna
+// domain.ErrTieneDependencias si algún producto u orden lo sigue referenciando.
+func (r *SQLProveedorRepository) Delete(id int) error {
+	_, err := r.db.Exec(`DELETE FROM Proveedor_Producto WHERE id_proveedor = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM Proveedor WHERE id_proveedor = ?`
+
+	_, err = r.db.Exec(query, id)
+
+	return errorReferenciado(err)
+}
+
 // SQLPedidoRepository implementa la interfaz PedidoRepository usando MySQL
 type SQLPedidoRepository struct {
-	db *sql.DB
+	db dbExecutor
 }
 
 // NewSQLPedidoRepository crea un nuevo repositorio de pedidos SQL
@@ -232,28 +794,38 @@ func NewSQLPedidoRepository(db *sql.DB) ports.PedidoRepository {
 	}
 }
 
-// GetByID obtiene un pedido por su ID
-func (r *SQLPedidoRepository) GetByID(id int) (*domain.Pedido, error) {
-	query := `SELECT id_pedido, fecha_pedido, estado, total 
-              FROM Pedido WHERE id_pedido = ?`
+// columnasPedido son las columnas que se leen de Pedido, en el orden de scanPedido
+const columnasPedido = `id_pedido, fecha_pedido, estado, id_almacen, id_cliente, direccion_entrega, ` +
+	columnasImportesDocumento + `, total`
 
+// scanPedido lee un pedido de una fila con las columnas de columnasPedido
+func scanPedido(fila scanner) (*domain.Pedido, error) {
 	pedido := &domain.Pedido{}
-	err := r.db.QueryRow(query, id).Scan(
-		&pedido.ID, &pedido.FechaPedido, &pedido.Estado, &pedido.Total,
-	)
+	var clienteID sql.NullInt64
+	var direccion sql.NullString
+	importes := importesDocumentoScan{importes: &pedido.ImportesDocumento}
 
-	if err != nil {
+	destinos := []interface{}{
+		&pedido.ID, &pedido.FechaPedido, &pedido.Estado, &pedido.AlmacenID, &clienteID, &direccion,
+	}
+	destinos = append(destinos, importes.destinos()...)
+	if err := fila.Scan(append(destinos, &pedido.Total)...); err != nil {
 		return nil, err
 	}
 
+	importes.asignar()
+
+	if clienteID.Valid {
+		id := int(clienteID.Int64)
+		pedido.ClienteID = &id
+	}
+	pedido.DireccionEntrega = direccion.String
 	return pedido, nil
 }
 
-// GetAll obtiene todos los pedidos
-func (r *SQLPedidoRepository) GetAll() ([]*domain.Pedido, error) {
-	query := `SELECT id_pedido, fecha_pedido, estado, total FROM Pedido`
-
-	rows, err := r.db.Query(query)
+// queryPedidos ejecuta una consulta que retorna pedidos
+func (r *SQLPedidoRepository) queryPedidos(query string, args ...interface{}) ([]*domain.Pedido, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -261,26 +833,88 @@ func (r *SQLPedidoRepository) GetAll() ([]*domain.Pedido, error) {
 
 	pedidos := []*domain.Pedido{}
 	for rows.Next() {
-		pedido := &domain.Pedido{}
-		err := rows.Scan(
-			&pedido.ID, &pedido.FechaPedido, &pedido.Estado, &pedido.Total,
-		)
+		pedido, err := scanPedido(rows)
 		if err != nil {
 			return nil, err
 		}
 		pedidos = append(pedidos, pedido)
 	}
 
-	return pedidos, nil
+	return pedidos, rows.Err()
+}
+
+// GetByID obtiene un pedido por su ID
+func (r *SQLPedidoRepository) GetByID(id int) (*domain.Pedido, error) {
+	query := `SELECT ` + columnasPedido + ` FROM Pedido WHERE id_pedido = ?`
+
+	return scanPedido(r.db.QueryRow(query, id))
+}
+
+// GetByIDForUpdate obtiene un pedido bloqueando su fila hasta que termine la
+// transacción actual
+func (r *SQLPedidoRepository) GetByIDForUpdate(id int) (*domain.Pedido, error) {
+	query := `SELECT ` + columnasPedido + ` FROM Pedido WHERE id_pedido = ? FOR UPDATE`
+
+	return scanPedido(r.db.QueryRow(query, id))
+}
+
+// ordenPedidos son los campos por los que se pueden ordenar los pedidos
+var ordenPedidos = ordenListado{
+	campos: map[string]string{
+		"id":     "id_pedido",
+		"fecha":  "fecha_pedido",
+		"estado": "estado",
+		"total":  "total",
+	},
+	predeterminado: "id",
+	desempate:      "id_pedido",
+}
+
+// GetAll obtiene una página de los pedidos. Filtra por estado, fecha, cliente,
+// almacén y rango del total.
+func (r *SQLPedidoRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Pedido, int, error) {
+	l := filtrosDocumento(opciones.Filtros, `fecha_pedido`)
+	return paginar(r.db, l, columnasPedido, `FROM Pedido`, ordenPedidos, opciones, r.queryPedidos)
+}
+
+// filtrosDocumento arma las condiciones comunes de los listados de pedidos,
+// ventas y órdenes: estado, rango de fechas, almacén, rango del total y, si
+// se indica, cliente
+func filtrosDocumento(filtros ports.FiltrosConsulta, columnaFecha string) *listado {
+	l := &listado{}
+	if filtros.Estado != "" {
+		l.donde(`estado = ?`, filtros.Estado)
+	}
+	l.rangoFechas(columnaFecha, filtros.Desde, filtros.Hasta)
+	if filtros.ClienteID != 0 {
+		l.donde(`id_cliente = ?`, filtros.ClienteID)
+	}
+	if filtros.AlmacenID != 0 {
+		l.donde(`id_almacen = ?`, filtros.AlmacenID)
+	}
+	l.rangoMontos(`total`, filtros.PrecioMin, filtros.PrecioMax)
+	return l
+}
+
+// GetByCliente obtiene los pedidos de un cliente, del más reciente al más antiguo
+func (r *SQLPedidoRepository) GetByCliente(clienteID int) ([]*domain.Pedido, error) {
+	query := `SELECT ` + columnasPedido + ` FROM Pedido WHERE id_cliente = ?
+              ORDER BY fecha_pedido DESC, id_pedido DESC`
+
+	return r.queryPedidos(query, clienteID)
 }
 
 // Create crea un nuevo pedido
 func (r *SQLPedidoRepository) Create(pedido *domain.Pedido) (int, error) {
-	query := `INSERT INTO Pedido (fecha_pedido, estado, total) VALUES (?, ?, ?)`
+	query := `INSERT INTO Pedido (fecha_pedido, estado, id_almacen, id_cliente, direccion_entrega, ` +
+		columnasImportesDocumento + `, total) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query,
-		time.Now().Format("2006-01-02 15:04:05"), pedido.Estado, pedido.Total,
-	)
+	args := []interface{}{
+		time.Now().Format("2006-01-02 15:04:05"), pedido.Estado, pedido.AlmacenID,
+		pedido.ClienteID, pedido.DireccionEntrega,
+	}
+	args = append(args, valoresImportesDocumento(&pedido.ImportesDocumento)...)
+	result, err := r.db.Exec(query, append(args, pedido.Total)...)
 
 	if err != nil {
 		return 0, err
@@ -294,20 +928,37 @@ func (r *SQLPedidoRepository) Create(pedido *domain.Pedido) (int, error) {
 	return int(id), nil
 }
 
-// Update actualiza un pedido existente
+// Update actualiza un pedido existente. El estado sólo cambia mediante UpdateEstado.
 func (r *SQLPedidoRepository) Update(pedido *domain.Pedido) error {
-	query := `UPDATE Pedido SET fecha_pedido = ?, estado = ?, total = ? 
+	query := `UPDATE Pedido SET fecha_pedido = ?, id_cliente = ?, direccion_entrega = ?, subtotal = ?,
+              descuento_porcentaje = ?, descuento_monto = ?, descuento = ?, impuesto = ?, total = ?
               WHERE id_pedido = ?`
 
-	_, err := r.db.Exec(query,
-		pedido.FechaPedido, pedido.Estado, pedido.Total, pedido.ID,
-	)
+	args := []interface{}{pedido.FechaPedido, pedido.ClienteID, pedido.DireccionEntrega}
+	args = append(args, valoresImportesDocumento(&pedido.ImportesDocumento)...)
+	_, err := r.db.Exec(query, append(args, pedido.Total, pedido.ID)...)
 
 	return err
 }
 
+// UpdateCampos actualiza sólo las columnas de los campos indicados del
+// pedido. descuento_documento escribe también los importes y el total, que
+// deben venir ya recalculados.
+func (r *SQLPedidoRepository) UpdateCampos(pedido *domain.Pedido, campos []string) error {
+	asignaciones := map[string]asignacion{
+		"id_cliente":        {`id_cliente = ?`, []interface{}{pedido.ClienteID}},
+		"direccion_entrega": {`direccion_entrega = ?`, []interface{}{pedido.DireccionEntrega}},
+		"descuento_documento": {
+			`subtotal = ?, descuento_porcentaje = ?, descuento_monto = ?, descuento = ?, impuesto = ?, total = ?`,
+			append(valoresImportesDocumento(&pedido.ImportesDocumento), pedido.Total),
+		},
+	}
+
+	return actualizarCampos(r.db, `Pedido`, `id_pedido`, pedido.ID, asignaciones, campos)
+}
+
 // UpdateEstado actualiza el estado de un pedido
-func (r *SQLPedidoRepository) UpdateEstado(id int, estado string) error {
+func (r *SQLPedidoRepository) UpdateEstado(id int, estado domain.EstadoPedido) error {
 	query := `UPDATE Pedido SET estado = ? WHERE id_pedido = ?`
 
 	_, err := r.db.Exec(query, estado, id)
@@ -315,6 +966,24 @@ func (r *SQLPedidoRepository) UpdateEstado(id int, estado string) error {
 	return err
 }
 
+// UpdateEstadoDesde cambia el estado de un pedido sólo si su estado actual es
+// estadoActual. Retorna false si el estado ya había cambiado.
+func (r *SQLPedidoRepository) UpdateEstadoDesde(id int, estadoActual domain.EstadoPedido, nuevoEstado domain.EstadoPedido) (bool, error) {
+	query := `UPDATE Pedido SET estado = ? WHERE id_pedido = ? AND estado = ?`
+
+	result, err := r.db.Exec(query, nuevoEstado, id, estadoActual)
+	if err != nil {
+		return false, err
+	}
+
+	filas, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return filas > 0, nil
+}
+
 // Delete elimina un pedido
 func (r *SQLPedidoRepository) Delete(id int) error {
 	query := `DELETE FROM Pedido WHERE id_pedido = ?`
@@ -326,7 +995,7 @@ func (r *SQLPedidoRepository) Delete(id int) error {
 
 // SQLDetallesPedidoRepository implementa la interfaz DetallesPedidoRepository usando MySQL
 type SQLDetallesPedidoRepository struct {
-	db *sql.DB
+	db dbExecutor
 }
 
 // NewSQLDetallesPedidoRepository crea un nuevo repositorio de detalles de pedido SQL
@@ -338,8 +1007,9 @@ func NewSQLDetallesPedidoRepository(db *sql.DB) ports.DetallesPedidoRepository {
 
 // GetByPedidoID obtiene los detalles de un pedido por ID del pedido
 func (r *SQLDetallesPedidoRepository) GetByPedidoID(pedidoID int) ([]*domain.DetallesPedido, error) {
-	query := `SELECT id_detalle_pedido, id_pedido, id_producto, cantidad, 
-              precio_unitario, subtotal FROM Detalles_Pedido WHERE id_pedido = ?`
+	query := `SELECT id_detalle_pedido, id_pedido, id_producto, cantidad, cantidad_facturada,
+              precio_unitario, subtotal, ` + columnasImportesLinea + `
+              FROM Detalles_Pedido WHERE id_pedido = ? ORDER BY id_detalle_pedido`
 
 	rows, err := r.db.Query(query, pedidoID)
 	if err != nil {
@@ -350,27 +1020,32 @@ func (r *SQLDetallesPedidoRepository) GetByPedidoID(pedidoID int) ([]*domain.Det
 	detalles := []*domain.DetallesPedido{}
 	for rows.Next() {
 		detalle := &domain.DetallesPedido{}
-		err := rows.Scan(
+		importes := importesLineaScan{importes: &detalle.ImportesLinea}
+		destinos := []interface{}{
 			&detalle.ID, &detalle.PedidoID, &detalle.ProductoID,
-			&detalle.Cantidad, &detalle.PrecioUnitario, &detalle.Subtotal,
-		)
-		if err != nil {
+			&detalle.Cantidad, &detalle.CantidadFacturada, &detalle.PrecioUnitario, &detalle.Subtotal,
+		}
+		if err := rows.Scan(append(destinos, importes.destinos()...)...); err != nil {
 			return nil, err
 		}
+		importes.asignar()
 		detalles = append(detalles, detalle)
 	}
 
-	return detalles, nil
+	return detalles, rows.Err()
 }
 
 // Create crea un nuevo detalle de pedido
 func (r *SQLDetallesPedidoRepository) Create(detalle *domain.DetallesPedido) (int, error) {
-	query := `INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario) 
-              VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, cantidad_facturada,
+              precio_unitario, subtotal, ` + columnasImportesLinea + `)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query,
-		detalle.PedidoID, detalle.ProductoID, detalle.Cantidad, detalle.PrecioUnitario,
-	)
+	args := []interface{}{
+		detalle.PedidoID, detalle.ProductoID, detalle.Cantidad, detalle.CantidadFacturada,
+		detalle.PrecioUnitario, detalle.Subtotal,
+	}
+	result, err := r.db.Exec(query, append(args, valoresImportesLinea(&detalle.ImportesLinea)...)...)
 
 	if err != nil {
 		return 0, err
@@ -386,13 +1061,16 @@ func (r *SQLDetallesPedidoRepository) Create(detalle *domain.DetallesPedido) (in
 
 // Update actualiza un detalle de pedido existente
 func (r *SQLDetallesPedidoRepository) Update(detalle *domain.DetallesPedido) error {
-	query := `UPDATE Detalles_Pedido SET id_pedido = ?, id_producto = ?, 
-              cantidad = ?, precio_unitario = ? WHERE id_detalle_pedido = ?`
+	query := `UPDATE Detalles_Pedido SET id_pedido = ?, id_producto = ?, cantidad = ?,
+              cantidad_facturada = ?, precio_unitario = ?, subtotal = ?, ` + asignacionesImportesLinea + `
+              WHERE id_detalle_pedido = ?`
 
-	_, err := r.db.Exec(query,
-		detalle.PedidoID, detalle.ProductoID, detalle.Cantidad,
-		detalle.PrecioUnitario, detalle.ID,
-	)
+	args := []interface{}{
+		detalle.PedidoID, detalle.ProductoID, detalle.Cantidad, detalle.CantidadFacturada,
+		detalle.PrecioUnitario, detalle.Subtotal,
+	}
+	args = append(args, valoresImportesLinea(&detalle.ImportesLinea)...)
+	_, err := r.db.Exec(query, append(args, detalle.ID)...)
 
 	return err
 }
@@ -408,7 +1086,7 @@ func (r *SQLDetallesPedidoRepository) Delete(id int) error {
 
 // SQLVentaRepository implementa la interfaz VentaRepository usando MySQL
 type SQLVentaRepository struct {
-	db *sql.DB
+	db dbExecutor
 }
 
 // NewSQLVentaRepository crea un nuevo repositorio de ventas SQL
@@ -418,28 +1096,42 @@ func NewSQLVentaRepository(db *sql.DB) ports.VentaRepository {
 	}
 }
 
-// GetByID obtiene una venta por su ID
-func (r *SQLVentaRepository) GetByID(id int) (*domain.Venta, error) {
-	query := `SELECT id_venta, fecha_venta, estado, total 
-              FROM Venta WHERE id_venta = ?`
+// columnasVenta son las columnas que se leen de Venta, en el orden de scanVenta
+const columnasVenta = `id_venta, fecha_venta, estado, id_almacen, id_cliente, direccion_entrega, id_pedido, ` +
+	columnasImportesDocumento + `, total`
 
+// scanVenta lee una venta de una fila con las columnas de columnasVenta
+func scanVenta(fila scanner) (*domain.Venta, error) {
 	venta := &domain.Venta{}
-	err := r.db.QueryRow(query, id).Scan(
-		&venta.ID, &venta.FechaVenta, &venta.Estado, &venta.Total,
-	)
+	var clienteID, pedidoID sql.NullInt64
+	var direccion sql.NullString
+	importes := importesDocumentoScan{importes: &venta.ImportesDocumento}
 
-	if err != nil {
+	destinos := []interface{}{
+		&venta.ID, &venta.FechaVenta, &venta.Estado, &venta.AlmacenID, &clienteID, &direccion, &pedidoID,
+	}
+	destinos = append(destinos, importes.destinos()...)
+	if err := fila.Scan(append(destinos, &venta.Total)...); err != nil {
 		return nil, err
 	}
 
+	importes.asignar()
+
+	if clienteID.Valid {
+		id := int(clienteID.Int64)
+		venta.ClienteID = &id
+	}
+	if pedidoID.Valid {
+		id := int(pedidoID.Int64)
+		venta.PedidoID = &id
+	}
+	venta.DireccionEntrega = direccion.String
 	return venta, nil
 }
 
-// GetAll obtiene todas las ventas
-func (r *SQLVentaRepository) GetAll() ([]*domain.Venta, error) {
-	query := `SELECT id_venta, fecha_venta, estado, total FROM Venta`
-
-	rows, err := r.db.Query(query)
+// queryVentas ejecuta una consulta que retorna ventas
+func (r *SQLVentaRepository) queryVentas(query string, args ...interface{}) ([]*domain.Venta, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -447,26 +1139,77 @@ func (r *SQLVentaRepository) GetAll() ([]*domain.Venta, error) {
 
 	ventas := []*domain.Venta{}
 	for rows.Next() {
-		venta := &domain.Venta{}
-		err := rows.Scan(
-			&venta.ID, &venta.FechaVenta, &venta.Estado, &venta.Total,
-		)
+		venta, err := scanVenta(rows)
 		if err != nil {
 			return nil, err
 		}
 		ventas = append(ventas, venta)
 	}
 
-	return ventas, nil
+	return ventas, rows.Err()
+}
+
+// GetByID obtiene una venta por su ID
+func (r *SQLVentaRepository) GetByID(id int) (*domain.Venta, error) {
+	query := `SELECT ` + columnasVenta + ` FROM Venta WHERE id_venta = ?`
+
+	return scanVenta(r.db.QueryRow(query, id))
+}
+
+// GetByIDForUpdate obtiene una venta bloqueando su fila hasta que termine la
+// transacción actual
+func (r *SQLVentaRepository) GetByIDForUpdate(id int) (*domain.Venta, error) {
+	query := `SELECT ` + columnasVenta + ` FROM Venta WHERE id_venta = ? FOR UPDATE`
+
+	return scanVenta(r.db.QueryRow(query, id))
+}
+
+// ordenVentas son los campos por los que se pueden ordenar las ventas
+var ordenVentas = ordenListado{
+	campos: map[string]string{
+		"id":     "id_venta",
+		"fecha":  "fecha_venta",
+		"estado": "estado",
+		"total":  "total",
+	},
+	predeterminado: "id",
+	desempate:      "id_venta",
+}
+
+// GetAll obtiene una página de las ventas. Filtra por estado, fecha, cliente,
+// almacén y rango del total.
+func (r *SQLVentaRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Venta, int, error) {
+	l := filtrosDocumento(opciones.Filtros, `fecha_venta`)
+	return paginar(r.db, l, columnasVenta, `FROM Venta`, ordenVentas, opciones, r.queryVentas)
+}
+
+// GetByCliente obtiene las ventas de un cliente, de la más reciente a la más antigua
+func (r *SQLVentaRepository) GetByCliente(clienteID int) ([]*domain.Venta, error) {
+	query := `SELECT ` + columnasVenta + ` FROM Venta WHERE id_cliente = ?
+              ORDER BY fecha_venta DESC, id_venta DESC`
+
+	return r.queryVentas(query, clienteID)
+}
+
+// GetByPedido obtiene las ventas generadas al facturar un pedido, en el orden
+// en que se facturaron
+func (r *SQLVentaRepository) GetByPedido(pedidoID int) ([]*domain.Venta, error) {
+	query := `SELECT ` + columnasVenta + ` FROM Venta WHERE id_pedido = ? ORDER BY id_venta`
+
+	return r.queryVentas(query, pedidoID)
 }
 
 // Create crea una nueva venta
 func (r *SQLVentaRepository) Create(venta *domain.Venta) (int, error) {
-	query := `INSERT INTO Venta (fecha_venta, estado, total) VALUES (?, ?, ?)`
+	query := `INSERT INTO Venta (fecha_venta, estado, id_almacen, id_cliente, direccion_entrega, id_pedido, ` +
+		columnasImportesDocumento + `, total) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query,
-		venta.FechaVenta, venta.Estado, venta.Total,
-	)
+	args := []interface{}{
+		venta.FechaVenta, venta.Estado, venta.AlmacenID, venta.ClienteID, venta.DireccionEntrega,
+		venta.PedidoID,
+	}
+	args = append(args, valoresImportesDocumento(&venta.ImportesDocumento)...)
+	result, err := r.db.Exec(query, append(args, venta.Total)...)
 
 	if err != nil {
 		return 0, err
@@ -480,20 +1223,31 @@ func (r *SQLVentaRepository) Create(venta *domain.Venta) (int, error) {
 	return int(id), nil
 }
 
-// Update actualiza una venta existente
+// Update actualiza una venta existente. El estado sólo cambia mediante UpdateEstado.
 func (r *SQLVentaRepository) Update(venta *domain.Venta) error {
-	query := `UPDATE Venta SET fecha_venta = ?, estado = ?, total = ? 
+	query := `UPDATE Venta SET fecha_venta = ?, id_cliente = ?, direccion_entrega = ?, subtotal = ?,
+              descuento_porcentaje = ?, descuento_monto = ?, descuento = ?, impuesto = ?, total = ?
               WHERE id_venta = ?`
 
-	_, err := r.db.Exec(query,
-		venta.FechaVenta, venta.Estado, venta.Total, venta.ID,
-	)
+	args := []interface{}{venta.FechaVenta, venta.ClienteID, venta.DireccionEntrega}
+	args = append(args, valoresImportesDocumento(&venta.ImportesDocumento)...)
+	_, err := r.db.Exec(query, append(args, venta.Total, venta.ID)...)
 
 	return err
 }
 
+// UpdateCampos actualiza sólo las columnas de los campos indicados de la venta
+func (r *SQLVentaRepository) UpdateCampos(venta *domain.Venta, campos []string) error {
+	asignaciones := map[string]asignacion{
+		"id_cliente":        {`id_cliente = ?`, []interface{}{venta.ClienteID}},
+		"direccion_entrega": {`direccion_entrega = ?`, []interface{}{venta.DireccionEntrega}},
+	}
+
+	return actualizarCampos(r.db, `Venta`, `id_venta`, venta.ID, asignaciones, campos)
+}
+
 // UpdateEstado actualiza el estado de una venta
-func (r *SQLVentaRepository) UpdateEstado(id int, estado string) error {
+func (r *SQLVentaRepository) UpdateEstado(id int, estado domain.EstadoVenta) error {
 	query := `UPDATE Venta SET estado = ? WHERE id_venta = ?`
 
 	_, err := r.db.Exec(query, estado, id)
@@ -501,6 +1255,24 @@ func (r *SQLVentaRepository) UpdateEstado(id int, estado string) error {
 	return err
 }
 
+// UpdateEstadoDesde cambia el estado de una venta sólo si su estado actual es
+// estadoActual. Retorna false si el estado ya había cambiado.
+func (r *SQLVentaRepository) UpdateEstadoDesde(id int, estadoActual domain.EstadoVenta, nuevoEstado domain.EstadoVenta) (bool, error) {
+	query := `UPDATE Venta SET estado = ? WHERE id_venta = ? AND estado = ?`
+
+	result, err := r.db.Exec(query, nuevoEstado, id, estadoActual)
+	if err != nil {
+		return false, err
+	}
+
+	filas, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return filas > 0, nil
+}
+
 // Delete elimina una venta
 func (r *SQLVentaRepository) Delete(id int) error {
 	query := `DELETE FROM Venta WHERE id_venta = ?`
@@ -512,7 +1284,7 @@ func (r *SQLVentaRepository) Delete(id int) error {
 
 // SQLDetallesVentaRepository implementa la interfaz DetallesVentaRepository usando MySQL
 type SQLDetallesVentaRepository struct {
-	db *sql.DB
+	db dbExecutor
 }
 
 // NewSQLDetallesVentaRepository crea un nuevo repositorio de detalles de venta SQL
@@ -524,8 +1296,9 @@ func NewSQLDetallesVentaRepository(db *sql.DB) ports.DetallesVentaRepository {
 
 // GetByVentaID obtiene los detalles de una venta por ID de la venta
 func (r *SQLDetallesVentaRepository) GetByVentaID(ventaID int) ([]*domain.DetallesVenta, error) {
-	query := `SELECT id_detalle_venta, id_venta, id_producto, cantidad, 
-              precio_unitario, subtotal FROM Detalles_Venta WHERE id_venta = ?`
+	query := `SELECT id_detalle_venta, id_venta, id_producto, cantidad, precio_unitario, costo_unitario,
+              subtotal, ` + columnasImportesLinea + `
+              FROM Detalles_Venta WHERE id_venta = ? ORDER BY id_detalle_venta`
 
 	rows, err := r.db.Query(query, ventaID)
 	if err != nil {
@@ -536,27 +1309,31 @@ func (r *SQLDetallesVentaRepository) GetByVentaID(ventaID int) ([]*domain.Detall
 	detalles := []*domain.DetallesVenta{}
 	for rows.Next() {
 		detalle := &domain.DetallesVenta{}
-		err := rows.Scan(
+		importes := importesLineaScan{importes: &detalle.ImportesLinea}
+		destinos := []interface{}{
 			&detalle.ID, &detalle.VentaID, &detalle.ProductoID,
-			&detalle.Cantidad, &detalle.PrecioUnitario, &detalle.Subtotal,
-		)
-		if err != nil {
+			&detalle.Cantidad, &detalle.PrecioUnitario, &detalle.CostoUnitario, &detalle.Subtotal,
+		}
+		if err := rows.Scan(append(destinos, importes.destinos()...)...); err != nil {
 			return nil, err
 		}
+		importes.asignar()
 		detalles = append(detalles, detalle)
 	}
 
-	return detalles, nil
+	return detalles, rows.Err()
 }
 
 // Create crea un nuevo detalle de venta
 func (r *SQLDetallesVentaRepository) Create(detalle *domain.DetallesVenta) (int, error) {
-	query := `INSERT INTO Detalles_Venta (id_venta, id_producto, cantidad, precio_unitario) 
-              VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO Detalles_Venta (id_venta, id_producto, cantidad, precio_unitario, costo_unitario,
+              subtotal, ` + columnasImportesLinea + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query,
+	args := []interface{}{
 		detalle.VentaID, detalle.ProductoID, detalle.Cantidad, detalle.PrecioUnitario,
-	)
+		detalle.CostoUnitario, detalle.Subtotal,
+	}
+	result, err := r.db.Exec(query, append(args, valoresImportesLinea(&detalle.ImportesLinea)...)...)
 
 	if err != nil {
 		return 0, err
@@ -572,13 +1349,16 @@ func (r *SQLDetallesVentaRepository) Create(detalle *domain.DetallesVenta) (int,
 
 // Update actualiza un detalle de venta existente
 func (r *SQLDetallesVentaRepository) Update(detalle *domain.DetallesVenta) error {
-	query := `UPDATE Detalles_Venta SET id_venta = ?, id_producto = ?, 
-              cantidad = ?, precio_unitario = ? WHERE id_detalle_venta = ?`
+	query := `UPDATE Detalles_Venta SET id_venta = ?, id_producto = ?, cantidad = ?, precio_unitario = ?,
+              costo_unitario = ?, subtotal = ?, ` + asignacionesImportesLinea + `
+              WHERE id_detalle_venta = ?`
 
-	_, err := r.db.Exec(query,
+	args := []interface{}{
 		detalle.VentaID, detalle.ProductoID, detalle.Cantidad,
-		detalle.PrecioUnitario, detalle.ID,
-	)
+		detalle.PrecioUnitario, detalle.CostoUnitario, detalle.Subtotal,
+	}
+	args = append(args, valoresImportesLinea(&detalle.ImportesLinea)...)
+	_, err := r.db.Exec(query, append(args, detalle.ID)...)
 
 	return err
 }
@@ -594,7 +1374,7 @@ func (r *SQLDetallesVentaRepository) Delete(id int) error {
 
 // SQLOrdenProveedorRepository implementa la interfaz OrdenProveedorRepository usando MySQL
 type SQLOrdenProveedorRepository struct {
-	db *sql.DB
+	db dbExecutor
 }
 
 // NewSQLOrdenProveedorRepository crea un nuevo repositorio de órdenes de proveedor SQL
@@ -606,12 +1386,12 @@ func NewSQLOrdenProveedorRepository(db *sql.DB) ports.OrdenProveedorRepository {
 
 // GetByID obtiene una orden de proveedor por su ID
 func (r *SQLOrdenProveedorRepository) GetByID(id int) (*domain.OrdenProveedor, error) {
-	query := `SELECT id_orden_proveedor, id_proveedor, fecha_orden, estado, total 
+	query := `SELECT id_orden_proveedor, id_proveedor, fecha_orden, estado, id_almacen, total 
               FROM Orden_Proveedor WHERE id_orden_proveedor = ?`
 
 	orden := &domain.OrdenProveedor{}
 	err := r.db.QueryRow(query, id).Scan(
-		&orden.ID, &orden.ProveedorID, &orden.FechaOrden, &orden.Estado, &orden.Total,
+		&orden.ID, &orden.ProveedorID, &orden.FechaOrden, &orden.Estado, &orden.AlmacenID, &orden.Total,
 	)
 
 	if err != nil {
@@ -621,12 +1401,75 @@ func (r *SQLOrdenProveedorRepository) GetByID(id int) (*domain.OrdenProveedor, e
 	return orden, nil
 }
 
-// GetAll obtiene todas las órdenes de proveedor
-func (r *SQLOrdenProveedorRepository) GetAll() ([]*domain.OrdenProveedor, error) {
-	query := `SELECT id_orden_proveedor, id_proveedor, fecha_orden, estado, total 
-              FROM Orden_Proveedor`
+// GetByIDForUpdate obtiene una orden de proveedor bloqueando su fila hasta que
+// termine la transacción en curso, para serializar recepciones concurrentes
+func (r *SQLOrdenProveedorRepository) GetByIDForUpdate(id int) (*domain.OrdenProveedor, error) {
+	query := `SELECT id_orden_proveedor, id_proveedor, fecha_orden, estado, id_almacen, total 
+              FROM Orden_Proveedor WHERE id_orden_proveedor = ? FOR UPDATE`
 
-	rows, err := r.db.Query(query)
+	orden := &domain.OrdenProveedor{}
+	err := r.db.QueryRow(query, id).Scan(
+		&orden.ID, &orden.ProveedorID, &orden.FechaOrden, &orden.Estado, &orden.AlmacenID, &orden.Total,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return orden, nil
+}
+
+// GetBorradorByProveedor obtiene, bloqueándola, la orden en borrador abierta
+// de un proveedor. Retorna nil sin error si el proveedor no tiene borrador.
+func (r *SQLOrdenProveedorRepository) GetBorradorByProveedor(proveedorID int) (*domain.OrdenProveedor, error) {
+	query := `SELECT id_orden_proveedor, id_proveedor, fecha_orden, estado, id_almacen, total 
+              FROM Orden_Proveedor WHERE id_proveedor = ? AND estado = ?
+              ORDER BY id_orden_proveedor LIMIT 1 FOR UPDATE`
+
+	orden := &domain.OrdenProveedor{}
+	err := r.db.QueryRow(query, proveedorID, domain.OrdenBorrador).Scan(
+		&orden.ID, &orden.ProveedorID, &orden.FechaOrden, &orden.Estado, &orden.AlmacenID, &orden.Total,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return orden, nil
+}
+
+// ordenOrdenes son los campos por los que se pueden ordenar las órdenes de proveedor
+var ordenOrdenes = ordenListado{
+	campos: map[string]string{
+		"id":     "id_orden_proveedor",
+		"fecha":  "fecha_orden",
+		"estado": "estado",
+		"total":  "total",
+	},
+	predeterminado: "id",
+	desempate:      "id_orden_proveedor",
+}
+
+// GetAll obtiene una página de las órdenes de proveedor. Filtra por estado,
+// fecha, proveedor, almacén y rango del total.
+func (r *SQLOrdenProveedorRepository) GetAll(opciones ports.QueryOptions) ([]*domain.OrdenProveedor, int, error) {
+	filtros := opciones.Filtros
+	filtros.ClienteID = 0
+	l := filtrosDocumento(filtros, `fecha_orden`)
+	if filtros.ProveedorID != 0 {
+		l.donde(`id_proveedor = ?`, filtros.ProveedorID)
+	}
+
+	return paginar(r.db, l, `id_orden_proveedor, id_proveedor, fecha_orden, estado, id_almacen, total`,
+		`FROM Orden_Proveedor`, ordenOrdenes, opciones, r.queryOrdenes)
+}
+
+// queryOrdenes ejecuta una consulta que retorna órdenes de proveedor
+func (r *SQLOrdenProveedorRepository) queryOrdenes(query string, args ...interface{}) ([]*domain.OrdenProveedor, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -636,7 +1479,7 @@ func (r *SQLOrdenProveedorRepository) GetAll() ([]*domain.OrdenProveedor, error)
 	for rows.Next() {
 		orden := &domain.OrdenProveedor{}
 		err := rows.Scan(
-			&orden.ID, &orden.ProveedorID, &orden.FechaOrden, &orden.Estado, &orden.Total,
+			&orden.ID, &orden.ProveedorID, &orden.FechaOrden, &orden.Estado, &orden.AlmacenID, &orden.Total,
 		)
 		if err != nil {
 			return nil, err
@@ -644,16 +1487,16 @@ func (r *SQLOrdenProveedorRepository) GetAll() ([]*domain.OrdenProveedor, error)
 		ordenes = append(ordenes, orden)
 	}
 
-	return ordenes, nil
+	return ordenes, rows.Err()
 }
 
 // Create crea una nueva orden de proveedor
 func (r *SQLOrdenProveedorRepository) Create(orden *domain.OrdenProveedor) (int, error) {
-	query := `INSERT INTO Orden_Proveedor (id_proveedor, fecha_orden, estado, total) 
-              VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO Orden_Proveedor (id_proveedor, fecha_orden, estado, id_almacen, total) 
+              VALUES (?, ?, ?, ?, ?)`
 
 	result, err := r.db.Exec(query,
-		orden.ProveedorID, time.Now().Format("2006-01-02 15:04:05"), orden.Estado, orden.Total,
+		orden.ProveedorID, time.Now().Format("2006-01-02 15:04:05"), orden.Estado, orden.AlmacenID, orden.Total,
 	)
 
 	if err != nil {
@@ -668,20 +1511,29 @@ func (r *SQLOrdenProveedorRepository) Create(orden *domain.OrdenProveedor) (int,
 	return int(id), nil
 }
 
-// Update actualiza una orden de proveedor existente
+// Update actualiza una orden de proveedor existente. El estado sólo cambia mediante UpdateEstado.
 func (r *SQLOrdenProveedorRepository) Update(orden *domain.OrdenProveedor) error {
 	query := `UPDATE Orden_Proveedor SET id_proveedor = ?, fecha_orden = ?, 
-              estado = ?, total = ? WHERE id_orden_proveedor = ?`
+              total = ? WHERE id_orden_proveedor = ?`
 
 	_, err := r.db.Exec(query,
-		orden.ProveedorID, orden.FechaOrden, orden.Estado, orden.Total, orden.ID,
+		orden.ProveedorID, orden.FechaOrden, orden.Total, orden.ID,
 	)
 
 	return err
 }
 
+// UpdateCampos actualiza sólo las columnas de los campos indicados de la orden
+func (r *SQLOrdenProveedorRepository) UpdateCampos(orden *domain.OrdenProveedor, campos []string) error {
+	asignaciones := map[string]asignacion{
+		"id_proveedor": {`id_proveedor = ?`, []interface{}{orden.ProveedorID}},
+	}
+
+	return actualizarCampos(r.db, `Orden_Proveedor`, `id_orden_proveedor`, orden.ID, asignaciones, campos)
+}
+
 // UpdateEstado actualiza el estado de una orden de proveedor
-func (r *SQLOrdenProveedorRepository) UpdateEstado(id int, estado string) error {
+func (r *SQLOrdenProveedorRepository) UpdateEstado(id int, estado domain.EstadoOrden) error {
 	query := `UPDATE Orden_Proveedor SET estado = ? WHERE id_orden_proveedor = ?`
 
 	_, err := r.db.Exec(query, estado, id)
@@ -689,6 +1541,24 @@ func (r *SQLOrdenProveedorRepository) UpdateEstado(id int, estado string) error
 	return err
 }
 
+// UpdateEstadoDesde cambia el estado de una orden de proveedor sólo si su estado actual es
+// estadoActual. Retorna false si el estado ya había cambiado.
+func (r *SQLOrdenProveedorRepository) UpdateEstadoDesde(id int, estadoActual domain.EstadoOrden, nuevoEstado domain.EstadoOrden) (bool, error) {
+	query := `UPDATE Orden_Proveedor SET estado = ? WHERE id_orden_proveedor = ? AND estado = ?`
+
+	result, err := r.db.Exec(query, nuevoEstado, id, estadoActual)
+	if err != nil {
+		return false, err
+	}
+
+	filas, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return filas > 0, nil
+}
+
 // Delete elimina una orden de proveedor
 func (r *SQLOrdenProveedorRepository) Delete(id int) error {
 	query := `DELETE FROM Orden_Proveedor WHERE id_orden_proveedor = ?`
@@ -700,7 +1570,7 @@ func (r *SQLOrdenProveedorRepository) Delete(id int) error {
 
 // SQLDetallesOrdenRepository implementa la interfaz DetallesOrdenRepository usando MySQL
 type SQLDetallesOrdenRepository struct {
-	db *sql.DB
+	db dbExecutor
 }
 
 // NewSQLDetallesOrdenRepository crea un nuevo repositorio de detalles de orden SQL
@@ -713,7 +1583,8 @@ func NewSQLDetallesOrdenRepository(db *sql.DB) ports.DetallesOrdenRepository {
 // GetByOrdenID obtiene los detalles de una orden por ID de la orden
 func (r *SQLDetallesOrdenRepository) GetByOrdenID(ordenID int) ([]*domain.DetallesOrden, error) {
 	query := `SELECT id_detalle_orden, id_orden_proveedor, id_producto, cantidad, 
-              precio_unitario, subtotal FROM Detalles_Orden WHERE id_orden_proveedor = ?`
+              cantidad_recibida, cantidad_cancelada, precio_unitario, subtotal
+              FROM Detalles_Orden WHERE id_orden_proveedor = ?`
 
 	rows, err := r.db.Query(query, ordenID)
 	if err != nil {
@@ -726,7 +1597,8 @@ func (r *SQLDetallesOrdenRepository) GetByOrdenID(ordenID int) ([]*domain.Detall
 		detalle := &domain.DetallesOrden{}
 		err := rows.Scan(
 			&detalle.ID, &detalle.OrdenProveedorID, &detalle.ProductoID,
-			&detalle.Cantidad, &detalle.PrecioUnitario, &detalle.Subtotal,
+			&detalle.Cantidad, &detalle.CantidadRecibida, &detalle.CantidadCancelada,
+			&detalle.PrecioUnitario, &detalle.Subtotal,
 		)
 		if err != nil {
 			return nil, err
@@ -737,13 +1609,43 @@ func (r *SQLDetallesOrdenRepository) GetByOrdenID(ordenID int) ([]*domain.Detall
 	return detalles, nil
 }
 
+// GetCantidadPendiente obtiene las unidades de un producto que aún faltan por
+// recibir en órdenes abiertas (ni recibidas ni canceladas), incluidos borradores
+func (r *SQLDetallesOrdenRepository) GetCantidadPendiente(productoID int) (int, error) {
+	query := `SELECT COALESCE(SUM(d.cantidad - d.cantidad_recibida - d.cantidad_cancelada), 0)
+              FROM Detalles_Orden d
+              JOIN Orden_Proveedor o ON o.id_orden_proveedor = d.id_orden_proveedor
+              WHERE d.id_producto = ? AND o.estado NOT IN (?, ?)`
+
+	var pendiente int
+	err := r.db.QueryRow(query, productoID, domain.OrdenRecibida, domain.OrdenCancelada).Scan(&pendiente)
+
+	return pendiente, err
+}
+
+// GetUltimoPrecio obtiene el precio unitario de la compra más reciente de un
+// producto. Retorna cero si el producto nunca se ha comprado.
+func (r *SQLDetallesOrdenRepository) GetUltimoPrecio(productoID int) (domain.Money, error) {
+	query := `SELECT precio_unitario FROM Detalles_Orden WHERE id_producto = ?
+              ORDER BY id_detalle_orden DESC LIMIT 1`
+
+	var precio domain.Money
+	err := r.db.QueryRow(query, productoID).Scan(&precio)
+	if err == sql.ErrNoRows {
+		return domain.NewMoney(0), nil
+	}
+
+	return precio, err
+}
+
 // Create crea un nuevo detalle de orden
 func (r *SQLDetallesOrdenRepository) Create(detalle *domain.DetallesOrden) (int, error) {
-	query := `INSERT INTO Detalles_Orden (id_orden_proveedor, id_producto, cantidad, precio_unitario) 
-              VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO Detalles_Orden (id_orden_proveedor, id_producto, cantidad, cantidad_recibida,
+              cantidad_cancelada, precio_unitario, subtotal) VALUES (?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := r.db.Exec(query,
-		detalle.OrdenProveedorID, detalle.ProductoID, detalle.Cantidad, detalle.PrecioUnitario,
+		detalle.OrdenProveedorID, detalle.ProductoID, detalle.Cantidad, detalle.CantidadRecibida,
+		detalle.CantidadCancelada, detalle.PrecioUnitario, detalle.Subtotal,
 	)
 
 	if err != nil {
@@ -761,11 +1663,13 @@ func (r *SQLDetallesOrdenRepository) Create(detalle *domain.DetallesOrden) (int,
 // Update actualiza un detalle de orden existente
 func (r *SQLDetallesOrdenRepository) Update(detalle *domain.DetallesOrden) error {
 	query := `UPDATE Detalles_Orden SET id_orden_proveedor = ?, id_producto = ?, 
-              cantidad = ?, precio_unitario = ? WHERE id_detalle_orden = ?`
+              cantidad = ?, cantidad_recibida = ?, cantidad_cancelada = ?,
+              precio_unitario = ?, subtotal = ? WHERE id_detalle_orden = ?`
 
 	_, err := r.db.Exec(query,
 		detalle.OrdenProveedorID, detalle.ProductoID, detalle.Cantidad,
-		detalle.PrecioUnitario, detalle.ID,
+		detalle.CantidadRecibida, detalle.CantidadCancelada,
+		detalle.PrecioUnitario, detalle.Subtotal, detalle.ID,
 	)
 
 	return err
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLPromocionRepository implementa la interfaz PromocionRepository usando MySQL
+type SQLPromocionRepository struct {
+	db dbExecutor
+}
+
+// NewSQLPromocionRepository crea un nuevo repositorio de promociones SQL
+func NewSQLPromocionRepository(db *sql.DB) ports.PromocionRepository {
+	return &SQLPromocionRepository{
+		db: db,
+	}
+}
+
+// columnasPromocion son las columnas que se leen de Promocion, en el orden de scanPromocion
+const columnasPromocion = `id_promocion, nombre, tipo, id_producto, id_categoria, lleva, paga, porcentaje,
+              activa, fecha_inicio, fecha_fin`
+
+// scanPromocion lee una promoción de una fila con las columnas de columnasPromocion
+func scanPromocion(fila scanner) (*domain.Promocion, error) {
+	promocion := &domain.Promocion{}
+	var productoID, categoriaID sql.NullInt64
+	var inicio, fin sql.NullTime
+
+	err := fila.Scan(
+		&promocion.ID, &promocion.Nombre, &promocion.Tipo, &productoID, &categoriaID,
+		&promocion.Lleva, &promocion.Paga, &promocion.Porcentaje, &promocion.Activa, &inicio, &fin,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if productoID.Valid {
+		id := int(productoID.Int64)
+		promocion.ProductoID = &id
+	}
+	if categoriaID.Valid {
+		id := int(categoriaID.Int64)
+		promocion.CategoriaID = &id
+	}
+	if inicio.Valid {
+		promocion.FechaInicio = &inicio.Time
+	}
+	if fin.Valid {
+		promocion.FechaFin = &fin.Time
+	}
+	return promocion, nil
+}
+
+// queryPromociones ejecuta una consulta que retorna promociones
+func (r *SQLPromocionRepository) queryPromociones(query string, args ...interface{}) ([]*domain.Promocion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promociones := []*domain.Promocion{}
+	for rows.Next() {
+		promocion, err := scanPromocion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promociones = append(promociones, promocion)
+	}
+
+	return promociones, rows.Err()
+}
+
+// GetByID obtiene una promoción por su ID
+func (r *SQLPromocionRepository) GetByID(id int) (*domain.Promocion, error) {
+	query := `SELECT ` + columnasPromocion + ` FROM Promocion WHERE id_promocion = ?`
+
+	return scanPromocion(r.db.QueryRow(query, id))
+}
+
+// ordenPromociones son los campos por los que se pueden ordenar las promociones
+var ordenPromociones = ordenListado{
+	campos: map[string]string{
+		"id":           "id_promocion",
+		"nombre":       "nombre",
+		"fecha_inicio": "fecha_inicio",
+		"fecha_fin":    "fecha_fin",
+	},
+	predeterminado: "id",
+	desempate:      "id_promocion",
+}
+
+// GetAll obtiene una página de las promociones. El estado filtra las activas
+// ("activa") o las inactivas ("inactiva").
+func (r *SQLPromocionRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Promocion, int, error) {
+	l := &listado{}
+	switch opciones.Filtros.Estado {
+	case "":
+	case "activa":
+		l.donde(`activa = TRUE`)
+	case "inactiva":
+		l.donde(`activa = FALSE`)
+	default:
+		return nil, 0, fmt.Errorf("%w: estado %q, use activa o inactiva", domain.ErrConsultaInvalida, opciones.Filtros.Estado)
+	}
+
+	return paginar(r.db, l, columnasPromocion, `FROM Promocion`, ordenPromociones, opciones, r.queryPromociones)
+}
+
+// GetVigentes obtiene las promociones activas en la fecha indicada, con el
+// mismo criterio que Promocion.VigenteEn
+func (r *SQLPromocionRepository) GetVigentes(fecha time.Time) ([]*domain.Promocion, error) {
+	query := `SELECT ` + columnasPromocion + ` FROM Promocion
+              WHERE activa = TRUE AND (fecha_inicio IS NULL OR fecha_inicio <= ?)
+              AND (fecha_fin IS NULL OR fecha_fin > ?)
+              ORDER BY id_promocion`
+
+	return r.queryPromociones(query, fecha, fecha)
+}
+
+// Create crea una nueva promoción
+func (r *SQLPromocionRepository) Create(promocion *domain.Promocion) (int, error) {
+	query := `INSERT INTO Promocion (nombre, tipo, id_producto, id_categoria, lleva, paga, porcentaje,
+              activa, fecha_inicio, fecha_fin) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		promocion.Nombre, promocion.Tipo, promocion.ProductoID, promocion.CategoriaID, promocion.Lleva,
+		promocion.Paga, promocion.Porcentaje, promocion.Activa, promocion.FechaInicio, promocion.FechaFin,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Update actualiza una promoción existente
+func (r *SQLPromocionRepository) Update(promocion *domain.Promocion) error {
+	query := `UPDATE Promocion SET nombre = ?, tipo = ?, id_producto = ?, id_categoria = ?, lleva = ?,
+              paga = ?, porcentaje = ?, activa = ?, fecha_inicio = ?, fecha_fin = ?
+              WHERE id_promocion = ?`
+
+	_, err := r.db.Exec(query,
+		promocion.Nombre, promocion.Tipo, promocion.ProductoID, promocion.CategoriaID, promocion.Lleva,
+		promocion.Paga, promocion.Porcentaje, promocion.Activa, promocion.FechaInicio, promocion.FechaFin,
+		promocion.ID,
+	)
+
+	return err
+}
+
+// Delete elimina una promoción. Las líneas que la aplicaron conservan su
+// descuento y quedan sin referencia a ella.
+func (r *SQLPromocionRepository) Delete(id int) error {
+	query := `DELETE FROM Promocion WHERE id_promocion = ?`
+
+	_, err := r.db.Exec(query, id)
+
+	return err
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"time"
+)
+
+// SQLReporteRepository implementa la interfaz ReporteRepository usando MySQL
+type SQLReporteRepository struct {
+	db dbExecutor
+}
+
+// NewSQLReporteRepository crea un nuevo repositorio de reportes SQL
+func NewSQLReporteRepository(db *sql.DB) ports.ReporteRepository {
+	return &SQLReporteRepository{
+		db: db,
+	}
+}
+
+// columnasAgrupacionMargen son las expresiones de clave y nombre de cada
+// agrupación del reporte de margen
+var columnasAgrupacionMargen = map[domain.AgrupacionMargen][2]string{
+	domain.MargenPorProducto:  {"CAST(p.id_producto AS CHAR)", "p.nombre"},
+	domain.MargenPorProveedor: {"COALESCE(CAST(pv.id_proveedor AS CHAR), '')", "COALESCE(pv.nombre, 'Sin proveedor')"},
+	domain.MargenPorDia:       {"DATE_FORMAT(v.fecha_venta, '%Y-%m-%d')", "DATE_FORMAT(v.fecha_venta, '%Y-%m-%d')"},
+	domain.MargenPorSemana:    {"DATE_FORMAT(v.fecha_venta, '%x-W%v')", "DATE_FORMAT(v.fecha_venta, '%x-W%v')"},
+	domain.MargenPorMes:       {"DATE_FORMAT(v.fecha_venta, '%Y-%m')", "DATE_FORMAT(v.fecha_venta, '%Y-%m')"},
+}
+
+// GetMargen obtiene los ingresos, el costo y el margen bruto de las ventas no
+// canceladas en el rango [desde, hasta), agrupados según se indique. El costo
+// de cada línea es el que se registró al momento de la venta; el proveedor es
+// el asignado actualmente al producto. Los ingresos son el subtotal de cada
+// línea menos sus descuentos, sin IVA; las unidades devueltas se descuentan
+// de la línea con la parte proporcional de sus ingresos.
+func (r *SQLReporteRepository) GetMargen(agrupacion domain.AgrupacionMargen, desde, hasta *time.Time) ([]*domain.LineaMargen, error) {
+	if err := domain.ValidarAgrupacionMargen(agrupacion); err != nil {
+		return nil, err
+	}
+	columnas := columnasAgrupacionMargen[agrupacion]
+
+	query := `SELECT ` + columnas[0] + ` AS clave, ` + columnas[1] + ` AS nombre,
+              SUM(d.cantidad - COALESCE(dv.cantidad, 0)),
+              SUM(ROUND((d.subtotal - d.descuento) * (d.cantidad - COALESCE(dv.cantidad, 0)) / d.cantidad, 2)),
+              SUM(d.costo_unitario * (d.cantidad - COALESCE(dv.cantidad, 0)))
+              FROM Detalles_Venta d
+              JOIN Venta v ON v.id_venta = d.id_venta
+              LEFT JOIN (
+                  SELECT id_detalle_venta, SUM(cantidad) AS cantidad
+                  FROM Detalles_Devolucion GROUP BY id_detalle_venta
+              ) dv ON dv.id_detalle_venta = d.id_detalle_venta
+              JOIN Producto p ON p.id_producto = d.id_producto
+              LEFT JOIN Proveedor pv ON pv.id_proveedor = p.id_proveedor
+              WHERE v.estado <> ?`
+	args := []interface{}{domain.VentaCancelada}
+
+	if desde != nil {
+		query += ` AND v.fecha_venta >= ?`
+		args = append(args, *desde)
+	}
+	if hasta != nil {
+		query += ` AND v.fecha_venta < ?`
+		args = append(args, *hasta)
+	}
+	query += ` GROUP BY clave, nombre ORDER BY clave`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lineas := []*domain.LineaMargen{}
+	for rows.Next() {
+		linea := &domain.LineaMargen{}
+		err := rows.Scan(&linea.Clave, &linea.Nombre, &linea.Cantidad, &linea.Ingresos, &linea.Costo)
+		if err != nil {
+			return nil, err
+		}
+		linea.CalcularMargen()
+		lineas = append(lineas, linea)
+	}
+
+	return lineas, rows.Err()
+}
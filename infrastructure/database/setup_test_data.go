@@ -1,6 +1,7 @@
 package database
 
 import (
+	"ActividadDesempenioAPIz/core/domain"
 	"log"
 	"time"
 )
@@ -12,9 +13,24 @@ func SetupTestData() {
 	// Insertar proveedores
 	insertProveedores()
 
+	// Insertar almacenes adicionales al principal
+	insertAlmacenes()
+
+	// Insertar categorías de productos
+	insertCategorias()
+
 	// Insertar productos
 	insertProductos()
 
+	// Insertar el catálogo de costos de los proveedores
+	insertCatalogoProveedores()
+
+	// Insertar lotes de los productos perecederos
+	insertLotes()
+
+	// Insertar clientes
+	insertClientes()
+
 	// Insertar pedidos y sus detalles
 	insertPedidos()
 
@@ -41,6 +57,7 @@ func insertProveedores() {
 		{"Muebles Premium", "Calle Decoración 456", "555-5678", "ventas@mueblespremium.com"},
 		{"Alimentos Frescos", "Blvd. Nutrición 789", "555-9012", "info@alimentosfrescos.com"},
 		{"Textiles Modernos", "Plaza Fashion 321", "555-3456", "ventas@textilesmodernos.com"},
+		{"Distribuidora Digital", "Av. Comercio 654", "555-7890", "pedidos@distribuidoradigital.com"},
 	}
 
 	for _, p := range proveedores {
@@ -54,35 +71,224 @@ func insertProveedores() {
 	}
 }
 
-// insertProductos inserta productos de prueba
-func insertProductos() {
+// insertAlmacenes inserta almacenes de prueba. El almacén principal ya lo
+// crea la migración.
+func insertAlmacenes() {
 	db := GetDB()
 
-	productos := []struct {
+	almacenes := []struct {
+		nombre    string
+		direccion string
+	}{
+		{"Sucursal Norte", "Av. Industrial 950"},
+	}
+
+	for _, a := range almacenes {
+		_, err := db.Exec(
+			"INSERT INTO Almacen (nombre, direccion, fecha_registro) VALUES (?, ?, ?)",
+			a.nombre, a.direccion, time.Now().Format("2006-01-02 15:04:05"),
+		)
+		if err != nil {
+			log.Printf("Error al insertar almacén %s: %v", a.nombre, err)
+		}
+	}
+}
+
+// insertCategorias inserta un árbol de categorías de prueba. Cada categoría
+// se inserta después de su padre para que los IDs coincidan con el arreglo.
+func insertCategorias() {
+	db := GetDB()
+
+	categorias := []struct {
 		nombre      string
 		descripcion string
-		precio      int
-		existencia  int
-		proveedorID int
+		padreID     *int
 	}{
-		{"Laptop Pro", "Laptop de última generación", 15000, 20, 1},
-		{"Smartphone X", "Teléfono inteligente", 8000, 30, 1},
-		{"Mesa de Centro", "Mesa de centro de madera", 3500, 10, 2},
-		{"Silla Ergonómica", "Silla para oficina", 2500, 15, 2},
-		{"Frutas Mixtas", "Pack de frutas variadas", 150, 50, 3},
-		{"Verduras Orgánicas", "Pack de verduras orgánicas", 200, 40, 3},
-		{"Camisa Casual", "Camisa de algodón", 600, 25, 4},
-		{"Pantalón Formal", "Pantalón de vestir", 800, 20, 4},
-		{"Tablet Mini", "Tablet compacta", 3000, 4, 1}, // Producto con stock bajo
+		{"Electrónica", "Dispositivos electrónicos", nil},
+		{"Cómputo", "Laptops y tablets", intPtr(1)},
+		{"Telefonía", "Teléfonos y accesorios", intPtr(1)},
+		{"Hogar", "Artículos para el hogar", nil},
+		{"Muebles", "Muebles de casa y oficina", intPtr(4)},
+		{"Alimentos", "Alimentos perecederos", nil},
+		{"Ropa", "Prendas de vestir", nil},
 	}
 
-	for _, p := range productos {
+	for _, c := range categorias {
 		_, err := db.Exec(
-			"INSERT INTO Producto (nombre, descripcion, precio, existencia, id_proveedor, fecha_creacion) VALUES (?, ?, ?, ?, ?, ?)",
-			p.nombre, p.descripcion, p.precio, p.existencia, p.proveedorID, time.Now().Format("2006-01-02 15:04:05"),
+			"INSERT INTO Categoria (nombre, descripcion, id_categoria_padre) VALUES (?, ?, ?)",
+			c.nombre, c.descripcion, c.padreID,
+		)
+		if err != nil {
+			log.Printf("Error al insertar categoría %s: %v", c.nombre, err)
+		}
+	}
+}
+
+// intPtr retorna un apuntador al entero indicado
+func intPtr(valor int) *int {
+	return &valor
+}
+
+// insertProductos inserta productos de prueba con toda su existencia en el
+// almacén principal
+func insertProductos() {
+	db := GetDB()
+
+	productos := []struct {
+		sku             string
+		codigoBarras    string
+		nombre          string
+		descripcion     string
+		precio          int
+		costo           int
+		existencia      int
+		stockMinimo     int
+		puntoReorden    int
+		cantidadReorden int
+		proveedorID     int
+		categoriaID     int
+		atributos       map[string]string
+	}{
+		{"ELE-LAP-001", "7501000000012", "Laptop Pro", "Laptop de última generación", 15000, 15000, 20, 3, 5, 10, 1, 2,
+			map[string]string{"ram": "16GB", "color": "gris"}},
+		{"ELE-TEL-001", "7501000000029", "Smartphone X", "Teléfono inteligente", 8000, 6500, 30, 5, 8, 20, 1, 3,
+			map[string]string{"almacenamiento": "128GB", "color": "negro"}},
+		{"HOG-MUE-001", "7501000000036", "Mesa de Centro", "Mesa de centro de madera", 3500, 2400, 10, 2, 4, 6, 2, 5,
+			map[string]string{"material": "roble"}},
+		{"HOG-MUE-002", "7501000000043", "Silla Ergonómica", "Silla para oficina", 2500, 1700, 15, 3, 5, 10, 2, 5, nil},
+		{"ALI-FRU-001", "7501000000050", "Frutas Mixtas", "Pack de frutas variadas", 150, 90, 50, 20, 30, 60, 3, 6,
+			map[string]string{"peso": "2kg"}},
+		{"ALI-VER-001", "7501000000067", "Verduras Orgánicas", "Pack de verduras orgánicas", 200, 120, 40, 15, 25, 50, 3, 6, nil},
+		{"ROP-CAM-001", "7501000000074", "Camisa Casual", "Camisa de algodón", 600, 350, 25, 5, 10, 20, 4, 7,
+			map[string]string{"talla": "M", "color": "azul"}},
+		{"ROP-PAN-001", "7501000000081", "Pantalón Formal", "Pantalón de vestir", 800, 480, 20, 5, 8, 15, 4, 7,
+			map[string]string{"talla": "32", "color": "negro"}},
+		{"ELE-TAB-001", "7501000000098", "Tablet Mini", "Tablet compacta", 3000, 2100, 4, 5, 8, 10, 1, 2, nil}, // Producto con stock bajo
+	}
+
+	for _, p := range productos {
+		result, err := db.Exec(
+			"INSERT INTO Producto (sku, codigo_barras, nombre, descripcion, precio, costo_promedio, existencia, stock_minimo, punto_reorden, cantidad_reorden, id_proveedor, id_categoria, fecha_creacion) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			p.sku, p.codigoBarras, p.nombre, p.descripcion, p.precio, p.costo, p.existencia, p.stockMinimo, p.puntoReorden, p.cantidadReorden, p.proveedorID, p.categoriaID, time.Now().Format("2006-01-02 15:04:05"),
 		)
 		if err != nil {
 			log.Printf("Error al insertar producto %s: %v", p.nombre, err)
+			continue
+		}
+
+		productoID, _ := result.LastInsertId()
+		_, err = db.Exec(
+			"INSERT INTO Existencia_Almacen (id_producto, id_almacen, existencia) VALUES (?, ?, ?)",
+			productoID, domain.AlmacenPrincipalID, p.existencia,
+		)
+		if err != nil {
+			log.Printf("Error al insertar existencia del producto %s: %v", p.nombre, err)
+		}
+
+		for clave, valor := range p.atributos {
+			_, err = db.Exec(
+				"INSERT INTO Producto_Atributo (id_producto, clave, valor) VALUES (?, ?, ?)",
+				productoID, clave, valor,
+			)
+			if err != nil {
+				log.Printf("Error al insertar atributo %s del producto %s: %v", clave, p.nombre, err)
+			}
+		}
+	}
+}
+
+// insertCatalogoProveedores inserta el catálogo de costos de los proveedores.
+// Algunos productos electrónicos se ofrecen también por un segundo proveedor
+// para poder comparar costos.
+func insertCatalogoProveedores() {
+	db := GetDB()
+
+	catalogo := []struct {
+		proveedorID    int
+		productoID     int
+		skuProveedor   string
+		costo          int
+		cantidadMinima int
+		tiempoEntrega  int
+		preferido      bool
+	}{
+		{1, 1, "EG-LP-15", 15000, 1, 5, true},
+		{5, 1, "DD-LAP-PRO", 14200, 5, 12, false},
+		{1, 2, "EG-SX-08", 6500, 5, 5, true},
+		{5, 2, "DD-SMX", 6300, 10, 12, false},
+		{2, 3, "MP-MC-01", 2400, 1, 10, true},
+		{2, 4, "MP-SE-02", 1700, 2, 10, true},
+		{3, 5, "AF-FRU", 90, 20, 2, true},
+		{3, 6, "AF-VER", 120, 20, 2, true},
+		{4, 7, "TM-CAM-M", 350, 10, 7, true},
+		{4, 8, "TM-PAN-32", 480, 10, 7, true},
+		{1, 9, "EG-TM-03", 2200, 1, 5, false},
+		{5, 9, "DD-TAB-MINI", 2100, 3, 12, true},
+	}
+
+	for _, e := range catalogo {
+		_, err := db.Exec(
+			"INSERT INTO Proveedor_Producto (id_proveedor, id_producto, sku_proveedor, costo, cantidad_minima, tiempo_entrega_dias, preferido, fecha_actualizacion) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			e.proveedorID, e.productoID, e.skuProveedor, e.costo, e.cantidadMinima, e.tiempoEntrega, e.preferido, time.Now().Format("2006-01-02 15:04:05"),
+		)
+		if err != nil {
+			log.Printf("Error al insertar catálogo del proveedor %d para el producto %d: %v", e.proveedorID, e.productoID, err)
+		}
+	}
+}
+
+// insertLotes reparte en lotes con caducidad la existencia de los productos
+// perecederos de prueba, con algunos lotes próximos a caducar
+func insertLotes() {
+	db := GetDB()
+
+	lotes := []struct {
+		productoID    int
+		numeroLote    string
+		diasCaducidad int
+		cantidad      int
+	}{
+		{5, "FRU-0001", 4, 20},  // Frutas Mixtas, por caducar
+		{5, "FRU-0002", 45, 30}, // Frutas Mixtas
+		{6, "VER-0001", -1, 5},  // Verduras Orgánicas, caducado
+		{6, "VER-0002", 12, 35}, // Verduras Orgánicas
+	}
+
+	ahora := time.Now()
+	for _, l := range lotes {
+		_, err := db.Exec(
+			"INSERT INTO Lote (id_producto, id_almacen, numero_lote, fecha_caducidad, cantidad_inicial, existencia, fecha_recepcion) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			l.productoID, domain.AlmacenPrincipalID, l.numeroLote, ahora.AddDate(0, 0, l.diasCaducidad).Format("2006-01-02"),
+			l.cantidad, l.cantidad, ahora.Format("2006-01-02 15:04:05"),
+		)
+		if err != nil {
+			log.Printf("Error al insertar lote %s: %v", l.numeroLote, err)
+		}
+	}
+}
+
+// insertClientes inserta clientes de prueba
+func insertClientes() {
+	db := GetDB()
+
+	clientes := []struct {
+		nombre    string
+		rfc       string
+		email     string
+		telefono  string
+		direccion string
+	}{
+		{"Ana López", "LOPA850101AB1", "ana.lopez@correo.com", "555-1111", "Calle Roble 12, Col. Centro"},
+		{"Comercializadora del Norte", "CNO990315XY2", "compras@cnorte.com", "555-2222", "Av. Industrial 77, Monterrey"},
+	}
+
+	for _, c := range clientes {
+		_, err := db.Exec(
+			"INSERT INTO Cliente (nombre, rfc, email, telefono, direccion, fecha_registro) VALUES (?, ?, ?, ?, ?, ?)",
+			c.nombre, c.rfc, c.email, c.telefono, c.direccion, time.Now().Format("2006-01-02 15:04:05"),
+		)
+		if err != nil {
+			log.Printf("Error al insertar cliente %s: %v", c.nombre, err)
 		}
 	}
 }
@@ -93,18 +299,20 @@ func insertPedidos() {
 
 	// Insertar pedidos
 	pedidos := []struct {
-		estado string
-		total  float64
+		estado    string
+		total     float64
+		clienteID int
+		direccion string
 	}{
-		{"pendiente", 18000.0},
-		{"completado", 3500.0},
-		{"cancelado", 5000.0},
+		{"pendiente", 18000.0, 1, "Calle Roble 12, Col. Centro"},
+		{"completado", 3500.0, 2, "Av. Industrial 77, Monterrey"},
+		{"cancelado", 5000.0, 1, "Calle Roble 12, Col. Centro"},
 	}
 
 	for _, p := range pedidos {
 		result, err := db.Exec(
-			"INSERT INTO Pedido (fecha_pedido, estado, total) VALUES (?, ?, ?)",
-			time.Now().Format("2006-01-02 15:04:05"), p.estado, p.total,
+			"INSERT INTO Pedido (fecha_pedido, estado, id_cliente, direccion_entrega, subtotal, total) VALUES (?, ?, ?, ?, ?, ?)",
+			time.Now().Format("2006-01-02 15:04:05"), p.estado, p.clienteID, p.direccion, p.total, p.total,
 		)
 		if err != nil {
 			log.Printf("Error al insertar pedido: %v", err)
@@ -117,16 +325,16 @@ func insertPedidos() {
 		if pedidoID == 1 {
 			// Detalles para el primer pedido
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				pedidoID, 1, 1, 15000.0,
+				"INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario, subtotal, total) VALUES (?, ?, ?, ?, ?, ?)",
+				pedidoID, 1, 1, 15000.0, 15000.0, 15000.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de pedido: %v", err)
 			}
 
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				pedidoID, 2, 1, 3000.0,
+				"INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario, subtotal, total) VALUES (?, ?, ?, ?, ?, ?)",
+				pedidoID, 2, 1, 3000.0, 3000.0, 3000.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de pedido: %v", err)
@@ -134,8 +342,8 @@ func insertPedidos() {
 		} else if pedidoID == 2 {
 			// Detalles para el segundo pedido
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				pedidoID, 3, 1, 3500.0,
+				"INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario, subtotal, total) VALUES (?, ?, ?, ?, ?, ?)",
+				pedidoID, 3, 1, 3500.0, 3500.0, 3500.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de pedido: %v", err)
@@ -143,8 +351,8 @@ func insertPedidos() {
 		} else if pedidoID == 3 {
 			// Detalles para el tercer pedido
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				pedidoID, 4, 2, 2500.0,
+				"INSERT INTO Detalles_Pedido (id_pedido, id_producto, cantidad, precio_unitario, subtotal, total) VALUES (?, ?, ?, ?, ?, ?)",
+				pedidoID, 4, 2, 2500.0, 5000.0, 5000.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de pedido: %v", err)
@@ -159,18 +367,20 @@ func insertVentas() {
 
 	// Insertar ventas
 	ventas := []struct {
-		estado string
-		total  float64
+		estado    string
+		total     float64
+		clienteID *int
+		direccion string
 	}{
-		{"completada", 8000.0},
-		{"completada", 5000.0},
-		{"cancelada", 3000.0},
+		{"completada", 8000.0, intPtr(1), "Calle Roble 12, Col. Centro"},
+		{"completada", 5000.0, intPtr(2), "Av. Industrial 77, Monterrey"},
+		{"cancelada", 3000.0, nil, ""},
 	}
 
 	for _, v := range ventas {
 		result, err := db.Exec(
-			"INSERT INTO Venta (fecha_venta, estado, total) VALUES (?, ?, ?)",
-			time.Now(), v.estado, v.total,
+			"INSERT INTO Venta (fecha_venta, estado, id_cliente, direccion_entrega, subtotal, total) VALUES (?, ?, ?, ?, ?, ?)",
+			time.Now(), v.estado, v.clienteID, v.direccion, v.total, v.total,
 		)
 		if err != nil {
 			log.Printf("Error al insertar venta: %v", err)
@@ -183,8 +393,8 @@ func insertVentas() {
 		if ventaID == 1 {
 			// Detalles para la primera venta
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Venta (id_venta, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				ventaID, 2, 1, 8000.0,
+				"INSERT INTO Detalles_Venta (id_venta, id_producto, cantidad, precio_unitario, costo_unitario, subtotal, total) VALUES (?, ?, ?, ?, ?, ?, ?)",
+				ventaID, 2, 1, 8000.0, 6500.0, 8000.0, 8000.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de venta: %v", err)
@@ -192,8 +402,8 @@ func insertVentas() {
 		} else if ventaID == 2 {
 			// Detalles para la segunda venta
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Venta (id_venta, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				ventaID, 4, 2, 2500.0,
+				"INSERT INTO Detalles_Venta (id_venta, id_producto, cantidad, precio_unitario, costo_unitario, subtotal, total) VALUES (?, ?, ?, ?, ?, ?, ?)",
+				ventaID, 4, 2, 2500.0, 1700.0, 5000.0, 5000.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de venta: %v", err)
@@ -201,8 +411,8 @@ func insertVentas() {
 		} else if ventaID == 3 {
 			// Detalles para la tercera venta
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Venta (id_venta, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				ventaID, 9, 1, 3000.0,
+				"INSERT INTO Detalles_Venta (id_venta, id_producto, cantidad, precio_unitario, costo_unitario, subtotal, total) VALUES (?, ?, ?, ?, ?, ?, ?)",
+				ventaID, 9, 1, 3000.0, 2100.0, 3000.0, 3000.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de venta: %v", err)
@@ -242,17 +452,17 @@ func insertOrdenesProveedor() {
 		if ordenID == 1 {
 			// Detalles para la primera orden
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Orden (id_orden_proveedor, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				ordenID, 1, 2, 15000.0,
+				"INSERT INTO Detalles_Orden (id_orden_proveedor, id_producto, cantidad, precio_unitario, subtotal) VALUES (?, ?, ?, ?, ?)",
+				ordenID, 1, 2, 15000.0, 30000.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de orden: %v", err)
 			}
 		} else if ordenID == 2 {
-			// Detalles para la segunda orden
+			// Detalles para la segunda orden, ya recibida por completo
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Orden (id_orden_proveedor, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				ordenID, 4, 5, 2500.0,
+				"INSERT INTO Detalles_Orden (id_orden_proveedor, id_producto, cantidad, cantidad_recibida, precio_unitario, subtotal) VALUES (?, ?, ?, ?, ?, ?)",
+				ordenID, 4, 5, 5, 2500.0, 12500.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de orden: %v", err)
@@ -260,8 +470,8 @@ func insertOrdenesProveedor() {
 		} else if ordenID == 3 {
 			// Detalles para la tercera orden
 			_, err = db.Exec(
-				"INSERT INTO Detalles_Orden (id_orden_proveedor, id_producto, cantidad, precio_unitario) VALUES (?, ?, ?, ?)",
-				ordenID, 5, 20, 175.0,
+				"INSERT INTO Detalles_Orden (id_orden_proveedor, id_producto, cantidad, precio_unitario, subtotal) VALUES (?, ?, ?, ?, ?)",
+				ordenID, 5, 20, 175.0, 3500.0,
 			)
 			if err != nil {
 				log.Printf("Error al insertar detalle de orden: %v", err)
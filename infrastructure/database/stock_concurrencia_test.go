@@ -0,0 +1,155 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+)
+
+// TestDecrementStockConcurrente lanza más descuentos de stock en paralelo de
+// los que alcanza la existencia de un producto y verifica que sólo se acepten
+// tantos como unidades había, que cada uno lea con LAST_INSERT_ID su propio
+// saldo y que la existencia nunca quede negativa.
+//
+// Requiere una base de datos MySQL desechable en TEST_DB_DSN, por ejemplo
+// root:secreto@tcp(localhost:3306)/ventas_prueba?parseTime=true
+func TestDecrementStockConcurrente(t *testing.T) {
+	db := abrirBaseDePrueba(t)
+
+	const existenciaInicial = 50
+	const solicitudes = 4 * existenciaInicial
+
+	productoID, almacenID := crearProductoDePrueba(t, db, existenciaInicial)
+	repo := &SQLProductoRepository{db: db}
+
+	var (
+		wg          sync.WaitGroup
+		mutex       sync.Mutex
+		saldos      = make(map[int]bool)
+		rechazos    int
+		inesperados []error
+	)
+
+	inicio := make(chan struct{})
+	for i := 0; i < solicitudes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-inicio
+
+			saldoAlmacen, _, err := repo.DecrementStock(productoID, almacenID, 1)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			var stockErr *domain.StockInsuficienteError
+			switch {
+			case errors.As(err, &stockErr):
+				rechazos++
+			case err != nil:
+				inesperados = append(inesperados, err)
+			default:
+				if saldoAlmacen < 0 {
+					t.Errorf("saldo negativo tras descontar: %d", saldoAlmacen)
+				}
+				if saldos[saldoAlmacen] {
+					t.Errorf("dos descuentos leyeron el mismo saldo: %d", saldoAlmacen)
+				}
+				saldos[saldoAlmacen] = true
+			}
+		}()
+	}
+	close(inicio)
+	wg.Wait()
+
+	for _, err := range inesperados {
+		t.Errorf("error inesperado: %v", err)
+	}
+	if len(saldos) != existenciaInicial {
+		t.Errorf("descuentos aceptados = %d, se esperaban %d", len(saldos), existenciaInicial)
+	}
+	if rechazos != solicitudes-existenciaInicial {
+		t.Errorf("descuentos rechazados = %d, se esperaban %d", rechazos, solicitudes-existenciaInicial)
+	}
+
+	var existenciaAlmacen, existenciaTotal int
+	err := db.QueryRow(`SELECT existencia FROM Existencia_Almacen WHERE id_producto = ? AND id_almacen = ?`,
+		productoID, almacenID).Scan(&existenciaAlmacen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.QueryRow(`SELECT existencia FROM Producto WHERE id_producto = ?`, productoID).Scan(&existenciaTotal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if existenciaAlmacen != 0 || existenciaTotal != 0 {
+		t.Errorf("existencia final = %d en el almacén y %d en total, se esperaba 0", existenciaAlmacen, existenciaTotal)
+	}
+}
+
+// abrirBaseDePrueba conecta con la base de TEST_DB_DSN y crea las tablas. La
+// prueba se omite si la variable no está definida.
+func abrirBaseDePrueba(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN no está definida")
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	db.SetMaxOpenConns(25)
+	if err := db.Ping(); err != nil {
+		t.Fatalf("no se pudo conectar a TEST_DB_DSN: %v", err)
+	}
+
+	// createTables trabaja sobre la conexión global
+	anterior := DB
+	DB = db
+	t.Cleanup(func() { DB = anterior })
+	createTables()
+
+	return db
+}
+
+// crearProductoDePrueba registra un almacén y un producto con la existencia
+// indicada en ese almacén. Se eliminan al terminar la prueba.
+func crearProductoDePrueba(t *testing.T, db *sql.DB, existencia int) (int, int) {
+	t.Helper()
+
+	result, err := db.Exec(`INSERT INTO Almacen (nombre, fecha_registro) VALUES ('Almacén de prueba', NOW())`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	almacenID, _ := result.LastInsertId()
+
+	result, err = db.Exec(`INSERT INTO Producto (nombre, precio, existencia, fecha_creacion)
+              VALUES ('Producto de prueba de concurrencia', 10, ?, NOW())`, existencia)
+	if err != nil {
+		t.Fatal(err)
+	}
+	productoID, _ := result.LastInsertId()
+
+	_, err = db.Exec(`INSERT INTO Existencia_Almacen (id_producto, id_almacen, existencia) VALUES (?, ?, ?)`,
+		productoID, almacenID, existencia)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM Existencia_Almacen WHERE id_producto = ?`, productoID)
+		db.Exec(`DELETE FROM Producto WHERE id_producto = ?`, productoID)
+		db.Exec(`DELETE FROM Almacen WHERE id_almacen = ?`, almacenID)
+	})
+
+	return int(productoID), int(almacenID)
+}
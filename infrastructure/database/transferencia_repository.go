@@ -0,0 +1,150 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/domain"
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+)
+
+// SQLTransferenciaRepository implementa la interfaz TransferenciaRepository usando MySQL
+type SQLTransferenciaRepository struct {
+	db dbExecutor
+}
+
+// NewSQLTransferenciaRepository crea un nuevo repositorio de transferencias SQL
+func NewSQLTransferenciaRepository(db *sql.DB) ports.TransferenciaRepository {
+	return &SQLTransferenciaRepository{
+		db: db,
+	}
+}
+
+// GetByID obtiene una transferencia por su ID
+func (r *SQLTransferenciaRepository) GetByID(id int) (*domain.Transferencia, error) {
+	query := `SELECT id_transferencia, id_almacen_origen, id_almacen_destino, usuario,
+              comentario, fecha FROM Transferencia WHERE id_transferencia = ?`
+
+	transferencia := &domain.Transferencia{}
+	err := r.db.QueryRow(query, id).Scan(
+		&transferencia.ID, &transferencia.AlmacenOrigenID, &transferencia.AlmacenDestinoID,
+		&transferencia.Usuario, &transferencia.Comentario, &transferencia.Fecha,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return transferencia, nil
+}
+
+// ordenTransferencias son los campos por los que se pueden ordenar las
+// transferencias; por omisión, de la más reciente a la más antigua
+var ordenTransferencias = ordenListado{
+	campos: map[string]string{
+		"id":    "id_transferencia",
+		"fecha": "fecha",
+	},
+	predeterminado: "fecha",
+	descendente:    true,
+	desempate:      "id_transferencia",
+}
+
+// GetAll obtiene una página de las transferencias. Filtra por fecha y por
+// almacén, que puede ser el de origen o el de destino.
+func (r *SQLTransferenciaRepository) GetAll(opciones ports.QueryOptions) ([]*domain.Transferencia, int, error) {
+	l := &listado{}
+	l.rangoFechas(`fecha`, opciones.Filtros.Desde, opciones.Filtros.Hasta)
+	if almacenID := opciones.Filtros.AlmacenID; almacenID != 0 {
+		l.donde(`(id_almacen_origen = ? OR id_almacen_destino = ?)`, almacenID, almacenID)
+	}
+
+	return paginar(r.db, l, `id_transferencia, id_almacen_origen, id_almacen_destino, usuario, comentario, fecha`,
+		`FROM Transferencia`, ordenTransferencias, opciones, r.queryTransferencias)
+}
+
+// queryTransferencias ejecuta una consulta que retorna transferencias
+func (r *SQLTransferenciaRepository) queryTransferencias(query string, args ...interface{}) ([]*domain.Transferencia, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transferencias := []*domain.Transferencia{}
+	for rows.Next() {
+		transferencia := &domain.Transferencia{}
+		err := rows.Scan(
+			&transferencia.ID, &transferencia.AlmacenOrigenID, &transferencia.AlmacenDestinoID,
+			&transferencia.Usuario, &transferencia.Comentario, &transferencia.Fecha,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transferencias = append(transferencias, transferencia)
+	}
+
+	return transferencias, rows.Err()
+}
+
+// Create registra una nueva transferencia
+func (r *SQLTransferenciaRepository) Create(transferencia *domain.Transferencia) (int, error) {
+	query := `INSERT INTO Transferencia (id_almacen_origen, id_almacen_destino, usuario,
+              comentario, fecha) VALUES (?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query,
+		transferencia.AlmacenOrigenID, transferencia.AlmacenDestinoID,
+		transferencia.Usuario, transferencia.Comentario, transferencia.Fecha,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// CreateDetalle registra una línea de una transferencia
+func (r *SQLTransferenciaRepository) CreateDetalle(detalle *domain.DetallesTransferencia) (int, error) {
+	query := `INSERT INTO Detalles_Transferencia (id_transferencia, id_producto, cantidad)
+              VALUES (?, ?, ?)`
+
+	result, err := r.db.Exec(query, detalle.TransferenciaID, detalle.ProductoID, detalle.Cantidad)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// GetDetalles obtiene las líneas de una transferencia
+func (r *SQLTransferenciaRepository) GetDetalles(transferenciaID int) ([]*domain.DetallesTransferencia, error) {
+	query := `SELECT id_detalle_transferencia, id_transferencia, id_producto, cantidad
+              FROM Detalles_Transferencia WHERE id_transferencia = ? ORDER BY id_detalle_transferencia`
+
+	rows, err := r.db.Query(query, transferenciaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	detalles := []*domain.DetallesTransferencia{}
+	for rows.Next() {
+		detalle := &domain.DetallesTransferencia{}
+		err := rows.Scan(&detalle.ID, &detalle.TransferenciaID, &detalle.ProductoID, &detalle.Cantidad)
+		if err != nil {
+			return nil, err
+		}
+		detalles = append(detalles, detalle)
+	}
+
+	return detalles, nil
+}
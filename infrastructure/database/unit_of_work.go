@@ -0,0 +1,78 @@
+package database
+
+import (
+	"ActividadDesempenioAPIz/core/ports"
+	"database/sql"
+	"fmt"
+)
+
+// dbExecutor abstrae las operaciones comunes de *sql.DB y *sql.Tx para que
+// los repositorios puedan trabajar dentro o fuera de una transacción
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// SQLUnitOfWork implementa la interfaz UnitOfWork usando transacciones de MySQL
+type SQLUnitOfWork struct {
+	db *sql.DB
+}
+
+// NewSQLUnitOfWork crea una nueva unidad de trabajo SQL
+func NewSQLUnitOfWork(db *sql.DB) ports.UnitOfWork {
+	return &SQLUnitOfWork{
+		db: db,
+	}
+}
+
+// Execute ejecuta fn dentro de una transacción. Si fn retorna un error o entra
+// en pánico se hace rollback; en caso contrario se hace commit.
+func (u *SQLUnitOfWork) Execute(fn func(repos *ports.TxRepositories) error) (err error) {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(newTxRepositories(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (error al hacer rollback: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// newTxRepositories crea los repositorios ligados a una transacción
+func newTxRepositories(tx *sql.Tx) *ports.TxRepositories {
+	return &ports.TxRepositories{
+		Productos:      &SQLProductoRepository{db: tx},
+		Proveedores:    &SQLProveedorRepository{db: tx},
+		Pedidos:        &SQLPedidoRepository{db: tx},
+		DetallesPedido: &SQLDetallesPedidoRepository{db: tx},
+		Ventas:         &SQLVentaRepository{db: tx},
+		DetallesVenta:  &SQLDetallesVentaRepository{db: tx},
+		Ordenes:        &SQLOrdenProveedorRepository{db: tx},
+		DetallesOrden:  &SQLDetallesOrdenRepository{db: tx},
+		Movimientos:    &SQLMovimientoInventarioRepository{db: tx},
+		Historial:      &SQLHistorialEstadoRepository{db: tx},
+		Almacenes:      &SQLAlmacenRepository{db: tx},
+		Transferencias: &SQLTransferenciaRepository{db: tx},
+		Lotes:          &SQLLoteRepository{db: tx},
+		Catalogo:       &SQLCatalogoProveedorRepository{db: tx},
+		Clientes:       &SQLClienteRepository{db: tx},
+		Devoluciones:   &SQLDevolucionRepository{db: tx},
+		Categorias:     &SQLCategoriaRepository{db: tx},
+		Promociones:    &SQLPromocionRepository{db: tx},
+		Facturas:       &SQLFacturaRepository{db: tx},
+		Auditoria:      &SQLAuditoriaRepository{db: tx},
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"ActividadDesempenioAPIz/application"
+	"ActividadDesempenioAPIz/core/domain"
 	"ActividadDesempenioAPIz/infrastructure/api/routes"
 	"ActividadDesempenioAPIz/infrastructure/database"
 	"ActividadDesempenioAPIz/infrastructure/websocket"
@@ -34,6 +35,21 @@ func main() {
 	detallesVentaRepo := database.NewSQLDetallesVentaRepository(db)
 	ordenRepo := database.NewSQLOrdenProveedorRepository(db)
 	detallesOrdenRepo := database.NewSQLDetallesOrdenRepository(db)
+	movimientoRepo := database.NewSQLMovimientoInventarioRepository(db)
+	historialRepo := database.NewSQLHistorialEstadoRepository(db)
+	almacenRepo := database.NewSQLAlmacenRepository(db)
+	transferenciaRepo := database.NewSQLTransferenciaRepository(db)
+	loteRepo := database.NewSQLLoteRepository(db)
+	categoriaRepo := database.NewSQLCategoriaRepository(db)
+	catalogoRepo := database.NewSQLCatalogoProveedorRepository(db)
+	reporteRepo := database.NewSQLReporteRepository(db)
+	clienteRepo := database.NewSQLClienteRepository(db)
+	devolucionRepo := database.NewSQLDevolucionRepository(db)
+	promocionRepo := database.NewSQLPromocionRepository(db)
+	auditoriaRepo := database.NewSQLAuditoriaRepository(db)
+
+	// Inicializar unidad de trabajo para operaciones transaccionales
+	unitOfWork := database.NewSQLUnitOfWork(db)
 
 	// Inicializar servicios WebSocket
 	stockWS := websocket.NewWebsocketService()
@@ -48,6 +64,23 @@ func main() {
 		proveedorRepo,
 	)
 
+	reabastecimientoService := application.NewReabastecimientoService(unitOfWork, notificationService)
+
+	// Datos fiscales del emisor de las facturas
+	generadorFactura := application.NewGeneradorFactura(domain.Emisor{
+		RFC:           os.Getenv("FACTURA_RFC"),
+		Nombre:        os.Getenv("FACTURA_NOMBRE"),
+		RegimenFiscal: os.Getenv("FACTURA_REGIMEN_FISCAL"),
+		CodigoPostal:  os.Getenv("FACTURA_CODIGO_POSTAL"),
+	})
+
+	// Búsqueda de productos con el índice FULLTEXT y, si no se puede usar, en memoria
+	buscadorProductos := database.NewSQLBuscadorProductos(db, application.NewBuscadorProductos(productoRepo))
+
+	// Revisar cada hora los lotes por caducar
+	caducidadService := application.NewCaducidadService(loteRepo, notificationService, domain.DiasAvisoCaducidadPredeterminado)
+	go caducidadService.Iniciar(time.Hour)
+
 	// Configurar Gin
 	r := gin.Default()
 
@@ -55,7 +88,8 @@ func main() {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Usuario"}
+	config.ExposeHeaders = []string{"X-Total-Count", "Link"}
 	r.Use(cors.New(config))
 
 	// Configurar rutas
@@ -69,7 +103,23 @@ func main() {
 		detallesVentaRepo,
 		ordenRepo,
 		detallesOrdenRepo,
+		movimientoRepo,
+		historialRepo,
+		almacenRepo,
+		transferenciaRepo,
+		loteRepo,
+		categoriaRepo,
+		catalogoRepo,
+		reporteRepo,
+		clienteRepo,
+		devolucionRepo,
+		promocionRepo,
+		auditoriaRepo,
+		unitOfWork,
 		notificationService,
+		reabastecimientoService,
+		generadorFactura,
+		buscadorProductos,
 		stockWS,
 		ordersWS,
 		cancellationsWS,